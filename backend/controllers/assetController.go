@@ -0,0 +1,81 @@
+package controllers
+
+import (
+	"authentication/services"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var assetService *services.AssetService
+
+// InitializeAssetService sets the service that tracks uploaded images and sweeps the unused ones.
+// It is created in main because the scheduler sweeps with it too.
+func InitializeAssetService(service *services.AssetService) {
+	assetService = service
+}
+
+// ingestImage stores an upload in the standard sizes and tracks it as an asset of the user.
+// On failure it answers the request and returns false.
+func ingestImage(ctx context.Context, c *gin.Context, file io.Reader, folder string) (*services.StoredImage, bool) {
+	image, err := imageService.Ingest(ctx, file, folder)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidImage) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return nil, false
+		}
+		log.Printf("[ERROR] Error uploading image: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload image"})
+		return nil, false
+	}
+
+	if _, err := assetService.Track(ctx, c.GetString("user_id"), *image, time.Now()); err != nil {
+		log.Printf("[ERROR] Error tracking image %s: %v", image.Key, err)
+		// Untracked it would never be swept
+		if err := imageService.Delete(ctx, image.Key); err != nil {
+			log.Printf("[ERROR] Error deleting image %s: %v", image.Key, err)
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload image"})
+		return nil, false
+	}
+	return image, true
+}
+
+// GetOrphanedAssets reports the images the next sweep would delete, without deleting them
+func GetOrphanedAssets() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		report, err := assetService.Sweep(ctx, time.Now(), true)
+		if err != nil {
+			log.Printf("[ERROR] Error listing orphaned images: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list orphaned images"})
+			return
+		}
+
+		c.JSON(http.StatusOK, report)
+	}
+}
+
+// SweepAssets deletes the orphaned images now instead of waiting for the scheduler
+func SweepAssets() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+		defer cancel()
+
+		report, err := assetService.Sweep(ctx, time.Now(), false)
+		if err != nil {
+			log.Printf("[ERROR] Error sweeping orphaned images: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to sweep orphaned images"})
+			return
+		}
+
+		c.JSON(http.StatusOK, report)
+	}
+}
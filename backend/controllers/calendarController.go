@@ -0,0 +1,110 @@
+package controllers
+
+import (
+	"authentication/models"
+	"authentication/services"
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var calendarService *services.CalendarService
+
+// InitializeCalendarService initializes the calendar feed service with the database connection
+func InitializeCalendarService(db *mongo.Database) {
+	calendarService = services.NewCalendarService(db)
+}
+
+// calendarFeedURL builds the public feed URL; PUBLIC_API_URL overrides the request host behind a proxy
+func calendarFeedURL(c *gin.Context, token string) string {
+	base := strings.TrimRight(os.Getenv("PUBLIC_API_URL"), "/")
+	if base == "" {
+		scheme := "http"
+		if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+			scheme = "https"
+		}
+		base = scheme + "://" + c.Request.Host
+	}
+	return base + "/calendar/" + token + ".ics"
+}
+
+// GetCalendarFeed tells whether the authenticated user's calendar feed is on and where it is
+func GetCalendarFeed() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user := c.MustGet("user").(*models.User)
+		if user.CalendarToken == "" {
+			c.JSON(http.StatusOK, gin.H{"enabled": false})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"enabled": true, "url": calendarFeedURL(c, user.CalendarToken)})
+	}
+}
+
+// CreateCalendarFeed turns the calendar feed on, or gives it a new URL if it already was
+func CreateCalendarFeed() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		user := c.MustGet("user").(*models.User)
+		token, err := calendarService.GenerateToken(ctx, user.User_id)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create calendar feed"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Calendar feed created successfully",
+			"enabled": true,
+			"url":     calendarFeedURL(c, token),
+		})
+	}
+}
+
+// DeleteCalendarFeed revokes the calendar feed URL
+func DeleteCalendarFeed() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		user := c.MustGet("user").(*models.User)
+		if err := calendarService.RevokeToken(ctx, user.User_id); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke calendar feed"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Calendar feed revoked successfully", "enabled": false})
+	}
+}
+
+// ServeCalendarFeed serves the iCalendar feed for the token in the URL.
+// It is public: calendar clients cannot send a Firebase token, the secret URL is the credential.
+func ServeCalendarFeed() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		token := strings.TrimSuffix(c.Param("token"), ".ics")
+		feed, err := calendarService.Feed(ctx, token)
+		if errors.Is(err, services.ErrCalendarFeedNotFound) {
+			c.String(http.StatusNotFound, "Calendar not found")
+			return
+		}
+		if err != nil {
+			log.Printf("[ERROR] Error building calendar feed: %v", err)
+			c.String(http.StatusInternalServerError, "Failed to build calendar")
+			return
+		}
+
+		c.Header("Cache-Control", "private, max-age=900")
+		c.Header("Content-Disposition", `inline; filename="plante.ics"`)
+		c.Data(http.StatusOK, "text/calendar; charset=utf-8", []byte(feed))
+	}
+}
@@ -0,0 +1,330 @@
+package controllers
+
+import (
+	"authentication/models"
+	"authentication/services"
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var careEventService *services.CareEventService
+
+// InitializeCareEventService initializes the care activity log with the database connection
+func InitializeCareEventService(db *mongo.Database) {
+	careEventService = services.NewCareEventService(db)
+}
+
+// careEventRequest is the body for logging or editing a care event; the photo is uploaded
+// through /api/plants/upload first
+type careEventRequest struct {
+	Type         string     `json:"type" binding:"required"`
+	Date         *time.Time `json:"date"` // Defaults to now
+	Notes        string     `json:"notes"`
+	AmountML     float64    `json:"amount_ml"`
+	Product      string     `json:"product"`
+	NewContainer string     `json:"new_container"`
+	PhotoURL     string     `json:"photo_url"`
+}
+
+// careEvent validates the request and turns it into an event, or returns the reason it is invalid
+func (r careEventRequest) careEvent(now time.Time) (models.CareEvent, string) {
+	event := models.CareEvent{
+		Type:         r.Type,
+		Date:         now,
+		Notes:        strings.TrimSpace(r.Notes),
+		AmountML:     r.AmountML,
+		Product:      strings.TrimSpace(r.Product),
+		NewContainer: strings.TrimSpace(r.NewContainer),
+		PhotoURL:     strings.TrimSpace(r.PhotoURL),
+	}
+	if r.Date != nil {
+		event.Date = *r.Date
+	}
+
+	if !models.IsCareEventType(event.Type) {
+		return event, "type must be one of " + strings.Join(models.CareEventTypes, ", ")
+	}
+	if event.Date.After(now) {
+		return event, "date cannot be in the future"
+	}
+	if event.AmountML < 0 {
+		return event, "amount_ml cannot be negative"
+	}
+	if event.AmountML > 0 && event.Type != models.CareEventWatering && event.Type != models.CareEventMisting {
+		return event, "amount_ml is only for watering and misting"
+	}
+	if event.Product != "" && event.Type != models.CareEventFertilizing && event.Type != models.CareEventPestTreatment {
+		return event, "product is only for fertilizing and pest treatment"
+	}
+	if event.NewContainer != "" && event.Type != models.CareEventRepotting {
+		return event, "new_container is only for repotting"
+	}
+	return event, ""
+}
+
+// parseCareEventQuery reads the listing filters shared by the per-plant and per-user endpoints.
+// Query: ?type (comma separated), ?from and ?to (YYYY-MM-DD in Bangkok time or RFC 3339),
+// ?page (from 1), ?limit (default 20, max 100)
+func parseCareEventQuery(c *gin.Context) (services.CareEventFilter, int64, int64, string) {
+	var filter services.CareEventFilter
+	if types := c.Query("type"); types != "" {
+		for _, t := range strings.Split(types, ",") {
+			filter.Types = append(filter.Types, strings.TrimSpace(t))
+		}
+	}
+
+	var message string
+	if filter.From, filter.To, message = parseDateRange(c); message != "" {
+		return filter, 0, 0, message
+	}
+
+	page, err := strconv.ParseInt(c.DefaultQuery("page", "1"), 10, 64)
+	if err != nil || page < 1 {
+		page = 1
+	}
+	return filter, page, parseLimit(c, 20, 100), ""
+}
+
+// parseDateRange reads ?from and ?to as YYYY-MM-DD in Bangkok time or RFC 3339. A bare date
+// for ?to includes the whole day. Missing bounds are zero.
+func parseDateRange(c *gin.Context) (time.Time, time.Time, string) {
+	var from, to time.Time
+	loc, _ := time.LoadLocation("Asia/Bangkok")
+	for _, bound := range []struct {
+		name   string
+		target *time.Time
+		days   int
+	}{{"from", &from, 0}, {"to", &to, 1}} {
+		value := c.Query(bound.name)
+		if value == "" {
+			continue
+		}
+		date, isDay, ok := parseDate(value, loc)
+		if !ok {
+			return from, to, "Invalid " + bound.name + " date"
+		}
+		if isDay {
+			date = date.AddDate(0, 0, bound.days)
+		}
+		*bound.target = date
+	}
+	return from, to, ""
+}
+
+// parseDate reads YYYY-MM-DD as the start of the day in loc, or an RFC 3339 time.
+// isDay tells which of the two it was.
+func parseDate(value string, loc *time.Location) (date time.Time, isDay bool, ok bool) {
+	if day, err := time.ParseInLocation("2006-01-02", value, loc); err == nil {
+		return day, true, true
+	}
+	if instant, err := time.Parse(time.RFC3339, value); err == nil {
+		return instant, false, true
+	}
+	return time.Time{}, false, false
+}
+
+// listCareEvents responds with one page of the user's events matching the filter
+func listCareEvents(c *gin.Context, ctx context.Context, userID string, filter services.CareEventFilter, page, limit int64) {
+	events, total, err := careEventService.List(ctx, userID, filter, page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch care events"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"events":   events,
+		"page":     page,
+		"limit":    limit,
+		"total":    total,
+		"has_more": page*limit < total,
+	})
+}
+
+// respondCareEventError maps care event service errors to HTTP responses
+func respondCareEventError(c *gin.Context, err error, action string) {
+	if errors.Is(err, services.ErrCareEventNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Care event not found"})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to " + action + " care event"})
+}
+
+// CreateCareEvent logs a care action done on one of the user's plants
+func CreateCareEvent() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		userID := c.GetString("user_id")
+		plant, status, message := ownedPlant(ctx, userID, c.Param("plant_id"))
+		if message != "" {
+			c.JSON(status, gin.H{"error": message})
+			return
+		}
+
+		var request careEventRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		now := reminderClock.Now()
+		event, message := request.careEvent(now)
+		if message != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": message})
+			return
+		}
+		event.UserID = userID
+		event.PlantID = plant.ID
+		event.CreatedAt = now
+
+		created, err := careEventService.Create(ctx, event)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save care event"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, created)
+	}
+}
+
+// GetPlantCareEvents lists the care log of one of the user's plants, most recent first
+func GetPlantCareEvents() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		userID := c.GetString("user_id")
+		plant, status, message := ownedPlant(ctx, userID, c.Param("plant_id"))
+		if message != "" {
+			c.JSON(status, gin.H{"error": message})
+			return
+		}
+
+		filter, page, limit, message := parseCareEventQuery(c)
+		if message != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": message})
+			return
+		}
+		filter.PlantID = &plant.ID
+
+		listCareEvents(c, ctx, userID, filter, page, limit)
+	}
+}
+
+// GetCareEvents lists the care log across all of the user's plants, most recent first.
+// ?plant_id narrows it to one plant in addition to the filters of parseCareEventQuery.
+func GetCareEvents() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+			return
+		}
+
+		filter, page, limit, message := parseCareEventQuery(c)
+		if message != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": message})
+			return
+		}
+		if plantID := c.Query("plant_id"); plantID != "" {
+			objID, err := primitive.ObjectIDFromHex(plantID)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid plant ID"})
+				return
+			}
+			filter.PlantID = &objID
+		}
+
+		listCareEvents(c, ctx, userID.(string), filter, page, limit)
+	}
+}
+
+// UpdateCareEvent edits a logged care event; the plant and reminder it belongs to stay the same
+func UpdateCareEvent() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		eventID, err := primitive.ObjectIDFromHex(c.Param("event_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid care event ID"})
+			return
+		}
+
+		var request careEventRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		userID := c.GetString("user_id")
+		existing, err := careEventService.Get(ctx, userID, eventID)
+		if err != nil {
+			respondCareEventError(c, err, "fetch")
+			return
+		}
+		// Keep the logged date unless a new one is given
+		if request.Date == nil {
+			request.Date = &existing.Date
+		}
+
+		now := reminderClock.Now()
+		event, message := request.careEvent(now)
+		if message != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": message})
+			return
+		}
+		event.ID = existing.ID
+		event.UserID = userID
+		event.UpdatedAt = now
+
+		updated, err := careEventService.Update(ctx, event)
+		if err != nil {
+			respondCareEventError(c, err, "update")
+			return
+		}
+
+		c.JSON(http.StatusOK, updated)
+	}
+}
+
+// DeleteCareEvent removes a logged care event
+func DeleteCareEvent() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		eventID, err := primitive.ObjectIDFromHex(c.Param("event_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid care event ID"})
+			return
+		}
+
+		if err := careEventService.Delete(ctx, c.GetString("user_id"), eventID, reminderClock.Now()); err != nil {
+			respondCareEventError(c, err, "delete")
+			return
+		}
+		photos, err := photoService.DeleteForCareEvent(ctx, eventID, reminderClock.Now())
+		if err != nil {
+			log.Printf("[ERROR] Error deleting photos of care event %s: %v", eventID.Hex(), err)
+		}
+		if len(photos) > 0 {
+			// One of them may have been the cover
+			publishPlantChange(ctx, photos[0].PlantID)
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Care event deleted"})
+	}
+}
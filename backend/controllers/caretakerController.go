@@ -0,0 +1,164 @@
+package controllers
+
+import (
+	"authentication/models"
+	"authentication/services"
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"net/mail"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var caretakerService *services.CaretakerService
+
+// InitializeCaretakerService initializes the caretaker invitations with the database connection
+func InitializeCaretakerService(db *mongo.Database) {
+	caretakerService = services.NewCaretakerService(db)
+}
+
+// userEmail returns the email of the authenticated user, or "" when the account has none
+func userEmail(c *gin.Context) string {
+	user := c.MustGet("user").(*models.User)
+	if user.Email == nil {
+		return ""
+	}
+	return *user.Email
+}
+
+// InviteCaretaker invites someone by email to look after the authenticated user's plants.
+// The answer is the same whether or not the email has an account.
+func InviteCaretaker() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		var request struct {
+			Email string `json:"email" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "email is required"})
+			return
+		}
+		if _, err := mail.ParseAddress(request.Email); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid email"})
+			return
+		}
+		if email := userEmail(c); email != "" && services.SameEmail(email, request.Email) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot invite yourself"})
+			return
+		}
+
+		caretaker, err := caretakerService.Invite(ctx, c.GetString("user_id"), request.Email, time.Now())
+		if err != nil {
+			log.Printf("[ERROR] Error inviting caretaker: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to invite caretaker"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"message":   "Invitation saved; it can be accepted by signing in with this email",
+			"caretaker": caretaker,
+		})
+	}
+}
+
+// GetCaretakers lists the authenticated user's caretakers, the invitations they sent and the ones sent to them
+func GetCaretakers() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		email, err := services.InviteeEmail(c.MustGet("user").(*models.User))
+		if err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Verify your email to see caretaker invitations"})
+			return
+		}
+
+		userID := c.GetString("user_id")
+		caretakers, err := caretakerService.ListForOwner(ctx, userID)
+		if err != nil {
+			log.Printf("[ERROR] Error listing caretakers of user %s: %v", userID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch caretakers"})
+			return
+		}
+		caringFor, err := caretakerService.ListForCaretaker(ctx, userID, email)
+		if err != nil {
+			log.Printf("[ERROR] Error listing invitations of user %s: %v", userID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch caretakers"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"caretakers": caretakers,
+			"caring_for": caringFor,
+		})
+	}
+}
+
+// AcceptCaretakerInvitation accepts an invitation sent to the authenticated user's verified email
+func AcceptCaretakerInvitation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		invitationID, err := primitive.ObjectIDFromHex(c.Param("caretaker_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invitation ID"})
+			return
+		}
+		email, err := services.InviteeEmail(c.MustGet("user").(*models.User))
+		if err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Verify your email to accept invitations"})
+			return
+		}
+
+		caretaker, err := caretakerService.Accept(ctx, invitationID, c.GetString("user_id"), email, time.Now())
+		if errors.Is(err, services.ErrCaretakerNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Invitation not found"})
+			return
+		}
+		if err != nil {
+			log.Printf("[ERROR] Error accepting invitation %s: %v", invitationID.Hex(), err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to accept invitation"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Invitation accepted", "caretaker": caretaker})
+	}
+}
+
+// RemoveCaretaker ends a caretaker relationship, or withdraws or declines an invitation
+func RemoveCaretaker() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		invitationID, err := primitive.ObjectIDFromHex(c.Param("caretaker_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invitation ID"})
+			return
+		}
+
+		// Declining an invitation by its address needs the address verified; owners and
+		// accepted caretakers are matched on their user ID
+		email, _ := services.InviteeEmail(c.MustGet("user").(*models.User))
+		err = caretakerService.Remove(ctx, invitationID, c.GetString("user_id"), email)
+		if errors.Is(err, services.ErrCaretakerNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Caretaker not found"})
+			return
+		}
+		if err != nil {
+			log.Printf("[ERROR] Error removing caretaker %s: %v", invitationID.Hex(), err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove caretaker"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Caretaker removed"})
+	}
+}
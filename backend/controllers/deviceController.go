@@ -0,0 +1,76 @@
+package controllers
+
+import (
+	"authentication/services"
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var deviceService *services.DeviceService
+
+// InitializeDeviceService initializes the device registry with the database connection
+func InitializeDeviceService(db *mongo.Database) {
+	deviceService = services.NewDeviceService(db)
+}
+
+// GetDevices lists the devices registered for push notifications by the authenticated user
+func GetDevices() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+			return
+		}
+
+		devices, err := deviceService.GetUserDevices(ctx, userID.(string))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch devices"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"devices": devices,
+			"count":   len(devices),
+		})
+	}
+}
+
+// DeleteDevice unregisters one of the authenticated user's devices
+func DeleteDevice() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		deviceID, err := primitive.ObjectIDFromHex(c.Param("device_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid device ID"})
+			return
+		}
+
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+			return
+		}
+
+		deleted, err := deviceService.DeleteDevice(ctx, userID.(string), deviceID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete device"})
+			return
+		}
+		if !deleted {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Device not found"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Device deleted successfully"})
+	}
+}
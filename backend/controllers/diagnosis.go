@@ -8,19 +8,23 @@ import (
 	"log"
 	"math"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type DiagnosisController struct {
 	collection *mongo.Collection
+	diagnoses  *mongo.Collection
 }
 
 func NewDiagnosisController(db *mongo.Database) *DiagnosisController {
 	return &DiagnosisController{
 		collection: db.Collection("plant_problems"),
+		diagnoses:  db.Collection("plant_diagnoses"),
 	}
 }
 
@@ -220,3 +224,67 @@ func calculateMatchScore(request models.DiagnosisRequest, condition models.Condi
 	// Normalize score to 0-1 range
 	return score / totalWeight
 }
+
+// DiagnosePlant diagnoses one of the user's plants and keeps the result in its history,
+// where the adaptive watering schedule picks up signs of over- or underwatering
+func (dc *DiagnosisController) DiagnosePlant() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		plantID, err := primitive.ObjectIDFromHex(c.Param("plant_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid plant ID"})
+			return
+		}
+
+		var request models.DiagnosisRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		user := c.MustGet("user").(*models.User)
+		var plant models.Plant
+		if err := plantCollection.FindOne(ctx, bson.M{"_id": plantID, "user_id": user.User_id}).Decode(&plant); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Plant not found"})
+			return
+		}
+
+		cursor, err := dc.collection.Find(ctx, bson.M{})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch diagnosis data"})
+			return
+		}
+		defer cursor.Close(ctx)
+
+		var problems []models.PlantProblem
+		if err := cursor.All(ctx, &problems); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode diagnosis data"})
+			return
+		}
+
+		diagnosis := models.PlantDiagnosis{
+			ID:                primitive.NewObjectID(),
+			UserID:            user.User_id,
+			PlantID:           plant.ID,
+			ProblemPart:       request.ProblemPart,
+			Symptoms:          request.Symptoms,
+			WateringFrequency: request.WateringFrequency,
+			CreatedAt:         time.Now(),
+		}
+		if bestMatch := findBestMatch(request, problems); bestMatch != nil {
+			diagnosis.ProblemID = bestMatch.ID
+			diagnosis.Diagnosis = bestMatch.Diagnosis
+			diagnosis.Solution = bestMatch.Solution
+			diagnosis.Severity = bestMatch.Severity
+		}
+
+		if _, err := dc.diagnoses.InsertOne(ctx, diagnosis); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save diagnosis"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, diagnosis)
+	}
+}
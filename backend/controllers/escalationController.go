@@ -0,0 +1,134 @@
+package controllers
+
+import (
+	"authentication/models"
+	"authentication/services"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	maxEscalationSteps      = 5
+	maxEscalationAfterHours = 72
+)
+
+// resolveEscalation validates a reminder's escalation steps and resolves delegates to the user IDs
+// of the owner's accepted caretakers. It returns an error message for the client when the steps are not valid.
+func resolveEscalation(ctx context.Context, userID string, steps []models.EscalationStep) ([]models.EscalationStep, string, error) {
+	if len(steps) > maxEscalationSteps {
+		return nil, fmt.Sprintf("At most %d escalation steps are allowed", maxEscalationSteps), nil
+	}
+
+	resolved := make([]models.EscalationStep, 0, len(steps))
+	for i, step := range steps {
+		if step.AfterHours < 1 || step.AfterHours > maxEscalationAfterHours {
+			return nil, fmt.Sprintf("Escalation step %d: afterHours must be between 1 and %d", i+1, maxEscalationAfterHours), nil
+		}
+
+		out := models.EscalationStep{AfterHours: step.AfterHours, Action: step.Action}
+		switch step.Action {
+		case models.EscalationActionResend:
+		case models.EscalationActionChannel:
+			switch step.Channel {
+			case models.DeliveryChannelFCM, models.DeliveryChannelEmail, models.DeliveryChannelWebhook:
+				out.Channel = step.Channel
+			default:
+				return nil, fmt.Sprintf("Escalation step %d: unknown channel %q", i+1, step.Channel), nil
+			}
+		case models.EscalationActionDelegate:
+			// Only to someone who accepted to look after the plants; the message does not tell
+			// whether an email has an account
+			var delegate *models.Caretaker
+			var err error
+			switch {
+			case step.DelegateEmail != "":
+				delegate, err = caretakerService.AcceptedByEmail(ctx, userID, step.DelegateEmail)
+			case step.DelegateUserID != "":
+				delegate, err = caretakerService.Accepted(ctx, userID, step.DelegateUserID)
+			default:
+				return nil, fmt.Sprintf("Escalation step %d: delegateEmail is required", i+1), nil
+			}
+			if errors.Is(err, services.ErrCaretakerNotFound) {
+				return nil, fmt.Sprintf("Escalation step %d: the delegate must be a caretaker who accepted your invitation", i+1), nil
+			}
+			if err != nil {
+				return nil, "", err
+			}
+			out.DelegateUserID = delegate.CaretakerID
+		default:
+			return nil, fmt.Sprintf("Escalation step %d: action must be resend, channel or delegate", i+1), nil
+		}
+		resolved = append(resolved, out)
+	}
+	return resolved, "", nil
+}
+
+// UpdateReminderEscalation replaces the escalation steps of one of the user's reminders.
+// An empty list turns escalation off; occurrences already escalating stop at their next check.
+func UpdateReminderEscalation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		reminder, ok := findUserReminder(c, ctx)
+		if !ok {
+			return
+		}
+
+		var request struct {
+			Steps []models.EscalationStep `json:"steps"`
+		}
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		steps, message, err := resolveEscalation(ctx, reminder.UserID, request.Steps)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate escalation steps"})
+			return
+		}
+		if message != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": message})
+			return
+		}
+
+		update := bson.M{"$set": bson.M{"escalation": steps, "updated_at": reminderClock.Now()}, "$inc": bson.M{"version": 1}}
+		if len(steps) == 0 {
+			update = bson.M{"$unset": bson.M{"escalation": ""}, "$set": bson.M{"updated_at": reminderClock.Now()}, "$inc": bson.M{"version": 1}}
+		}
+
+		filter := bson.M{"_id": reminder.ID, "user_id": reminder.UserID}
+		if expectedVersions, conditional := ifMatchVersions(c); conditional {
+			if !matchesVersion(expectedVersions, reminder.Version) {
+				respondPreconditionFailed(c, reminder.Version)
+				return
+			}
+			filter["version"] = versionFilter(reminder.Version)
+		}
+
+		var updated models.Reminder
+		err = reminderCollection.FindOneAndUpdate(ctx, filter, update,
+			options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&updated)
+		if err == mongo.ErrNoDocuments {
+			// Changed since it was read above
+			respondReminderPreconditionFailed(c, ctx, bson.M{"_id": reminder.ID, "user_id": reminder.UserID})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update escalation"})
+			return
+		}
+
+		c.Header("ETag", versionETag(updated.Version))
+		c.JSON(http.StatusOK, updated)
+	}
+}
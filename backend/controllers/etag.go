@@ -0,0 +1,110 @@
+package controllers
+
+import (
+	"authentication/models"
+	"authentication/services"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Plants and reminders carry a version that every change increments. It is sent as the ETag so
+// clients can make conditional GETs (If-None-Match) and refuse to overwrite newer edits (If-Match).
+
+// versionETag is the ETag of a single plant or reminder
+func versionETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// versionStamp is one item of a list as far as its ETag is concerned
+type versionStamp struct {
+	ID      primitive.ObjectID
+	Version int64
+}
+
+// listETag is the weak ETag of a list, which changes when any item changes, is added or removed
+func listETag(stamps []versionStamp) string {
+	hash := sha1.New()
+	for _, stamp := range stamps {
+		fmt.Fprintf(hash, "%s:%d;", stamp.ID.Hex(), stamp.Version)
+	}
+	return `W/"` + hex.EncodeToString(hash.Sum(nil)) + `"`
+}
+
+// reminderListETag is the ETag of a list of reminders
+func reminderListETag(reminders []models.Reminder) string {
+	stamps := make([]versionStamp, len(reminders))
+	for i, reminder := range reminders {
+		stamps[i] = versionStamp{ID: reminder.ID, Version: reminder.Version}
+	}
+	return listETag(stamps)
+}
+
+// notModified sets the ETag header and responds 304 when the client's If-None-Match still matches
+func notModified(c *gin.Context, etag string) bool {
+	c.Header("ETag", etag)
+	if services.MatchesIfNoneMatch(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return true
+	}
+	return false
+}
+
+// ifMatchVersions reads the versions the client accepts from If-Match, see services.IfMatchVersions
+func ifMatchVersions(c *gin.Context) (versions []int64, conditional bool) {
+	return services.IfMatchVersions(c.GetHeader("If-Match"))
+}
+
+// matchesVersion reports whether version is one of the versions from If-Match
+func matchesVersion(versions []int64, version int64) bool {
+	for _, v := range versions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+// versionFilter matches a document at one of the given versions; documents from before versioning count as 0
+func versionFilter(versions ...int64) interface{} {
+	in := bson.A{}
+	for _, version := range versions {
+		in = append(in, version)
+		if version == 0 {
+			in = append(in, nil)
+		}
+	}
+	return bson.M{"$in": in}
+}
+
+// respondReminderPreconditionFailed responds 412 with the current version of the reminder, or 404 when it is gone
+func respondReminderPreconditionFailed(c *gin.Context, ctx context.Context, filter bson.M) {
+	var current models.Reminder
+	err := reminderCollection.FindOne(ctx, filter).Decode(&current)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Reminder not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reminder"})
+		return
+	}
+	respondPreconditionFailed(c, current.Version)
+}
+
+// respondPreconditionFailed tells the client its copy is stale and which version is current
+func respondPreconditionFailed(c *gin.Context, current int64) {
+	c.Header("ETag", versionETag(current))
+	c.JSON(http.StatusPreconditionFailed, gin.H{
+		"error":   "It was changed since you last fetched it",
+		"version": current,
+	})
+}
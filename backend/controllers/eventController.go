@@ -0,0 +1,140 @@
+package controllers
+
+import (
+	"authentication/services"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// eventHeartbeatInterval keeps idle streams open through proxies
+const eventHeartbeatInterval = 25 * time.Second
+
+var eventBus *services.EventBus
+
+// InitializeEventBus sets the bus the controllers publish plant and reminder changes to
+func InitializeEventBus(bus *services.EventBus) {
+	eventBus = bus
+}
+
+// CreateStreamTicket issues a short-lived, single-use ticket for opening the event stream.
+// EventSource cannot send the Authorization header, so the stream is opened with ?ticket= instead.
+func CreateStreamTicket() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if eventBus == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Event stream is not available"})
+			return
+		}
+
+		ticket, expiresAt, err := eventBus.IssueStreamTicket(c.GetString("user_id"))
+		if err != nil {
+			log.Printf("[ERROR] Error issuing stream ticket: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create stream ticket"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"ticket": ticket, "expires_at": expiresAt})
+	}
+}
+
+// AuthenticateStream authenticates the event stream with a ticket from CreateStreamTicket,
+// or with auth when the request has no ticket
+func AuthenticateStream(auth gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ticket := c.Query("ticket")
+		if ticket == "" {
+			auth(c)
+			return
+		}
+		if eventBus == nil {
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "Event stream is not available"})
+			return
+		}
+		userID, ok := eventBus.RedeemStreamTicket(ticket)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired stream ticket"})
+			return
+		}
+		c.Set("user_id", userID)
+		c.Next()
+	}
+}
+
+// StreamEvents streams the authenticated user's events as Server-Sent Events.
+// A reconnecting client resumes with the Last-Event-ID header (or ?last_event_id).
+func StreamEvents() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+			return
+		}
+		if eventBus == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Event stream is not available"})
+			return
+		}
+
+		lastEventID := c.GetHeader("Last-Event-ID")
+		if lastEventID == "" {
+			lastEventID = c.Query("last_event_id")
+		}
+
+		events, backlog, unsubscribe := eventBus.Subscribe(userID.(string), lastEventID)
+		defer unsubscribe()
+
+		c.Header("Content-Type", "text/event-stream")
+		c.Header("Cache-Control", "no-cache")
+		c.Header("Connection", "keep-alive")
+		c.Header("X-Accel-Buffering", "no")
+		c.Status(http.StatusOK)
+
+		for _, event := range backlog {
+			if err := writeEvent(c, event); err != nil {
+				return
+			}
+		}
+		// Comment line so the client sees the stream open right away
+		fmt.Fprint(c.Writer, ": connected\n\n")
+		c.Writer.Flush()
+
+		heartbeat := time.NewTicker(eventHeartbeatInterval)
+		defer heartbeat.Stop()
+
+		for {
+			select {
+			case <-c.Request.Context().Done():
+				return
+			case event, ok := <-events:
+				if !ok {
+					// Dropped for falling behind; the client reconnects and resumes
+					return
+				}
+				if err := writeEvent(c, event); err != nil {
+					return
+				}
+			case <-heartbeat.C:
+				if _, err := fmt.Fprint(c.Writer, ": heartbeat\n\n"); err != nil {
+					return
+				}
+				c.Writer.Flush()
+			}
+		}
+	}
+}
+
+// writeEvent writes one event in text/event-stream format and flushes it
+func writeEvent(c *gin.Context, event services.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(c.Writer, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data); err != nil {
+		return err
+	}
+	c.Writer.Flush()
+	return nil
+}
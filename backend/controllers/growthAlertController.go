@@ -0,0 +1,75 @@
+package controllers
+
+import (
+	"authentication/models"
+	"authentication/services"
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var growthAlertService *services.GrowthAlertService
+
+// InitializeGrowthAlertService sets the service that flags plants from their growth records.
+// It is created in main because it notifies through the notification service.
+func InitializeGrowthAlertService(service *services.GrowthAlertService) {
+	growthAlertService = service
+}
+
+// scanGrowth re-checks a plant after its growth records changed. A failed scan doesn't fail the
+// request, the scheduler scans every plant again later.
+func scanGrowth(ctx context.Context, plant models.Plant) {
+	if growthAlertService == nil {
+		return
+	}
+	if err := growthAlertService.Scan(ctx, plant); err != nil {
+		log.Printf("[ERROR] Error scanning growth of plant %s: %v", plant.ID.Hex(), err)
+	}
+}
+
+// GetGrowthAlerts returns the authenticated user's plants that need attention, most recent first.
+// Query: ?dismissed=true to include dismissed alerts
+func GetGrowthAlerts() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		alerts, err := growthAlertService.List(ctx, c.GetString("user_id"), c.Query("dismissed") == "true")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch growth alerts"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"alerts": alerts})
+	}
+}
+
+// DismissGrowthAlert hides an alert from the attention list until the plant recovers
+func DismissGrowthAlert() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		alertID, err := primitive.ObjectIDFromHex(c.Param("alert_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid alert ID"})
+			return
+		}
+
+		alert, err := growthAlertService.Dismiss(ctx, c.GetString("user_id"), alertID)
+		if err == services.ErrGrowthAlertNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Growth alert not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to dismiss growth alert"})
+			return
+		}
+
+		c.JSON(http.StatusOK, alert)
+	}
+}
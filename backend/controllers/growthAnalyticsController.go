@@ -0,0 +1,102 @@
+package controllers
+
+import (
+	"authentication/models"
+	"authentication/services"
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// parseGrowthAnalyticsQuery reads ?period (week or month, default week), ?from and ?to
+// (see parseDateRange) and ?projection_weeks (default 4)
+func parseGrowthAnalyticsQuery(c *gin.Context) (services.GrowthAnalyticsOptions, string) {
+	opts := services.GrowthAnalyticsOptions{Period: c.DefaultQuery("period", services.GrowthPeriodWeek), ProjectionWeeks: 4}
+	if opts.Period != services.GrowthPeriodWeek && opts.Period != services.GrowthPeriodMonth {
+		return opts, "period must be week or month"
+	}
+
+	var message string
+	if opts.Filter.From, opts.Filter.To, message = parseDateRange(c); message != "" {
+		return opts, message
+	}
+
+	if value := c.Query("projection_weeks"); value != "" {
+		weeks, err := strconv.Atoi(value)
+		if err != nil || weeks < 0 || weeks > services.MaxProjectionWeeks {
+			return opts, "projection_weeks must be between 0 and " + strconv.Itoa(services.MaxProjectionWeeks)
+		}
+		opts.ProjectionWeeks = weeks
+	}
+	return opts, ""
+}
+
+// GetPlantGrowthAnalytics returns the height series, growth rate, mood distribution and
+// projected height of one of the user's plants
+func GetPlantGrowthAnalytics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		plant, status, message := ownedPlant(ctx, c.GetString("user_id"), c.Param("plant_id"))
+		if message != "" {
+			c.JSON(status, gin.H{"error": message})
+			return
+		}
+
+		opts, message := parseGrowthAnalyticsQuery(c)
+		if message != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": message})
+			return
+		}
+
+		loc, _ := time.LoadLocation("Asia/Bangkok")
+		analytics, err := growthService.PlantAnalytics(ctx, *plant, opts, loc)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute growth analytics"})
+			return
+		}
+
+		c.JSON(http.StatusOK, analytics)
+	}
+}
+
+// GetUserGrowthAnalytics compares the growth rates of all the user's plants and summarizes their moods
+func GetUserGrowthAnalytics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		user := c.MustGet("user").(*models.User)
+
+		opts, message := parseGrowthAnalyticsQuery(c)
+		if message != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": message})
+			return
+		}
+
+		cursor, err := plantCollection.Find(ctx, bson.M{"user_id": user.User_id})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch plants"})
+			return
+		}
+		var plants []models.Plant
+		if err := cursor.All(ctx, &plants); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch plants"})
+			return
+		}
+
+		loc, _ := time.LoadLocation("Asia/Bangkok")
+		analytics, err := growthService.UserAnalytics(ctx, user.User_id, plants, opts, loc)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute growth analytics"})
+			return
+		}
+
+		c.JSON(http.StatusOK, analytics)
+	}
+}
@@ -0,0 +1,163 @@
+package controllers
+
+import (
+	"authentication/services"
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var inboxService *services.InboxService
+
+// InitializeInboxService initializes the notification inbox with the database connection
+func InitializeInboxService(db *mongo.Database) {
+	inboxService = services.NewInboxService(db)
+}
+
+// GetInbox lists the authenticated user's inbox, newest first.
+// Query: ?page (from 1), ?limit (default 20, max 100), ?unread=true for unread items only
+func GetInbox() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+			return
+		}
+
+		page, err := strconv.ParseInt(c.DefaultQuery("page", "1"), 10, 64)
+		if err != nil || page < 1 {
+			page = 1
+		}
+		limit := parseLimit(c, 20, 100)
+
+		items, total, err := inboxService.List(ctx, userID.(string), c.Query("unread") == "true", page, limit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch inbox"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"items":    items,
+			"page":     page,
+			"limit":    limit,
+			"total":    total,
+			"has_more": page*limit < total,
+		})
+	}
+}
+
+// GetInboxUnreadCount returns how many inbox items the authenticated user has not read
+func GetInboxUnreadCount() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+			return
+		}
+
+		count, err := inboxService.UnreadCount(ctx, userID.(string))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count unread items"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"unread": count})
+	}
+}
+
+// MarkInboxItemRead marks one of the authenticated user's inbox items read
+func MarkInboxItemRead() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		itemID, err := primitive.ObjectIDFromHex(c.Param("item_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid inbox item ID"})
+			return
+		}
+
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+			return
+		}
+
+		found, err := inboxService.MarkRead(ctx, userID.(string), itemID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mark item read"})
+			return
+		}
+		if !found {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Inbox item not found"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Item marked as read"})
+	}
+}
+
+// MarkAllInboxItemsRead marks every inbox item of the authenticated user read
+func MarkAllInboxItemsRead() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+			return
+		}
+
+		updated, err := inboxService.MarkAllRead(ctx, userID.(string))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mark items read"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "All items marked as read", "updated": updated})
+	}
+}
+
+// DeleteInboxItem removes one of the authenticated user's inbox items
+func DeleteInboxItem() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		itemID, err := primitive.ObjectIDFromHex(c.Param("item_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid inbox item ID"})
+			return
+		}
+
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+			return
+		}
+
+		deleted, err := inboxService.Delete(ctx, userID.(string), itemID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete inbox item"})
+			return
+		}
+		if !deleted {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Inbox item not found"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Inbox item deleted successfully"})
+	}
+}
@@ -0,0 +1,121 @@
+package controllers
+
+import (
+	"authentication/config"
+	"authentication/models"
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var notificationDeliveryCollection *mongo.Collection
+
+func InitNotificationDeliveryCollection() {
+	notificationDeliveryCollection = config.OpenCollection("notification_deliveries")
+}
+
+// parseLimit reads the "limit" query parameter, falling back to def and capping at max
+func parseLimit(c *gin.Context, def, max int64) int64 {
+	limit, err := strconv.ParseInt(c.Query("limit"), 10, 64)
+	if err != nil || limit <= 0 {
+		return def
+	}
+	if limit > max {
+		return max
+	}
+	return limit
+}
+
+// GetMyNotificationDeliveries returns the delivery history of the authenticated user
+func GetMyNotificationDeliveries() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+			return
+		}
+
+		filter := bson.M{"user_id": userID}
+		if status := c.Query("status"); status != "" {
+			filter["status"] = status
+		}
+
+		opts := options.Find().
+			SetSort(bson.D{{Key: "created_at", Value: -1}}).
+			SetLimit(parseLimit(c, 50, 200))
+
+		cursor, err := notificationDeliveryCollection.Find(ctx, filter, opts)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch notification deliveries"})
+			return
+		}
+		defer cursor.Close(ctx)
+
+		deliveries := []models.NotificationDelivery{}
+		if err := cursor.All(ctx, &deliveries); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode notification deliveries"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"deliveries": deliveries,
+			"count":      len(deliveries),
+		})
+	}
+}
+
+// GetFailedNotificationDeliveries returns failed deliveries of all users (admin only).
+// By default both retrying and dead-lettered deliveries are returned, "status" narrows it down.
+func GetFailedNotificationDeliveries() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		filter := bson.M{"status": bson.M{"$in": []string{
+			models.DeliveryStatusRetrying,
+			models.DeliveryStatusDeadLetter,
+		}}}
+		switch status := c.Query("status"); status {
+		case "":
+		case models.DeliveryStatusRetrying, models.DeliveryStatusDeadLetter:
+			filter["status"] = status
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status, expected retrying or dead_letter"})
+			return
+		}
+		if userID := c.Query("userId"); userID != "" {
+			filter["user_id"] = userID
+		}
+
+		opts := options.Find().
+			SetSort(bson.D{{Key: "updated_at", Value: -1}}).
+			SetLimit(parseLimit(c, 100, 500))
+
+		cursor, err := notificationDeliveryCollection.Find(ctx, filter, opts)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch notification deliveries"})
+			return
+		}
+		defer cursor.Close(ctx)
+
+		deliveries := []models.NotificationDelivery{}
+		if err := cursor.All(ctx, &deliveries); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode notification deliveries"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"deliveries": deliveries,
+			"count":      len(deliveries),
+		})
+	}
+}
@@ -0,0 +1,237 @@
+package controllers
+
+import (
+	"authentication/models"
+	"authentication/services"
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var occurrenceService *services.OccurrenceService
+
+// InitializeOccurrenceService initializes the reminder occurrence service with the database connection
+func InitializeOccurrenceService(db *mongo.Database) {
+	occurrenceService = services.NewOccurrenceService(db)
+}
+
+// respondOccurrenceError maps occurrence service errors to HTTP responses
+func respondOccurrenceError(c *gin.Context, err error, action string) {
+	switch {
+	case errors.Is(err, services.ErrOccurrenceNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Occurrence not found"})
+	case errors.Is(err, services.ErrOccurrenceClosed):
+		c.JSON(http.StatusConflict, gin.H{"error": "Occurrence is already acknowledged or skipped"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to " + action + " occurrence"})
+	}
+}
+
+// AcknowledgeOccurrence marks a reminder occurrence as done and records a care event
+func AcknowledgeOccurrence() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		occurrenceID, err := primitive.ObjectIDFromHex(c.Param("occurrence_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid occurrence ID"})
+			return
+		}
+
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+			return
+		}
+
+		// The body is optional; it adds details to the recorded care event
+		var req struct {
+			Notes    string  `json:"notes"`
+			AmountML float64 `json:"amountMl"`
+			Product  string  `json:"product"`
+			PhotoURL string  `json:"photoUrl"`
+		}
+		_ = c.ShouldBindJSON(&req)
+		if req.AmountML < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "amountMl cannot be negative"})
+			return
+		}
+
+		details := models.CareEvent{
+			Notes:    strings.TrimSpace(req.Notes),
+			AmountML: req.AmountML,
+			Product:  strings.TrimSpace(req.Product),
+			PhotoURL: strings.TrimSpace(req.PhotoURL),
+		}
+		occurrence, err := occurrenceService.Acknowledge(ctx, userID.(string), occurrenceID, details, reminderClock.Now())
+		if err != nil {
+			respondOccurrenceError(c, err, "acknowledge")
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"message":    "Occurrence acknowledged",
+			"occurrence": occurrence,
+		})
+	}
+}
+
+// SnoozeOccurrence postpones a reminder occurrence; it is sent again when the snooze ends
+func SnoozeOccurrence() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		occurrenceID, err := primitive.ObjectIDFromHex(c.Param("occurrence_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid occurrence ID"})
+			return
+		}
+
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+			return
+		}
+
+		var req struct {
+			Minutes int `json:"minutes"`
+		}
+		_ = c.ShouldBindJSON(&req)
+
+		duration := services.DefaultSnooze
+		if req.Minutes != 0 {
+			duration = time.Duration(req.Minutes) * time.Minute
+		}
+		if duration <= 0 || duration > services.MaxSnooze {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Snooze must be between 1 and 1440 minutes"})
+			return
+		}
+
+		occurrence, err := occurrenceService.Snooze(ctx, userID.(string), occurrenceID, duration, reminderClock.Now())
+		if err != nil {
+			respondOccurrenceError(c, err, "snooze")
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"message":    "Occurrence snoozed",
+			"occurrence": occurrence,
+		})
+	}
+}
+
+// SkipOccurrence closes a reminder occurrence without doing the task
+func SkipOccurrence() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		occurrenceID, err := primitive.ObjectIDFromHex(c.Param("occurrence_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid occurrence ID"})
+			return
+		}
+
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+			return
+		}
+
+		occurrence, err := occurrenceService.Skip(ctx, userID.(string), occurrenceID, reminderClock.Now())
+		if err != nil {
+			respondOccurrenceError(c, err, "skip")
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"message":    "Occurrence skipped",
+			"occurrence": occurrence,
+		})
+	}
+}
+
+// findUserReminder loads a reminder by the "id" path parameter and checks it belongs to the user.
+// It writes the error response and returns false when the reminder cannot be used.
+func findUserReminder(c *gin.Context, ctx context.Context) (*models.Reminder, bool) {
+	reminderID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reminder ID"})
+		return nil, false
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return nil, false
+	}
+
+	var reminder models.Reminder
+	err = reminderCollection.FindOne(ctx, bson.M{"_id": reminderID, "user_id": userID}).Decode(&reminder)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Reminder not found"})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reminder"})
+		return nil, false
+	}
+	return &reminder, true
+}
+
+// GetReminderOccurrences lists the occurrences of one of the user's reminders, newest first
+func GetReminderOccurrences() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		reminder, ok := findUserReminder(c, ctx)
+		if !ok {
+			return
+		}
+
+		occurrences, err := occurrenceService.ListForReminder(ctx, reminder.UserID, reminder.ID, parseLimit(c, 50, 200))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch occurrences"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"occurrences": occurrences,
+			"count":       len(occurrences),
+		})
+	}
+}
+
+// GetReminderStats returns the on-time rate and current streak of one of the user's reminders
+func GetReminderStats() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		reminder, ok := findUserReminder(c, ctx)
+		if !ok {
+			return
+		}
+
+		occurrences, err := occurrenceService.ListForReminder(ctx, reminder.UserID, reminder.ID, 0)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch occurrences"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"reminderId": reminder.ID.Hex(),
+			"stats":      services.ComputeReminderStats(occurrences, reminderClock.Now()),
+		})
+	}
+}
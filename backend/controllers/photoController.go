@@ -0,0 +1,332 @@
+package controllers
+
+import (
+	"authentication/models"
+	"authentication/services"
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// maxPhotoCaptionLength is the longest caption accepted, in characters
+const maxPhotoCaptionLength = 500
+
+var photoService *services.PhotoService
+var imageService *services.ImageService
+
+// InitializePhotoService initializes plant galleries with the database connection
+func InitializePhotoService(db *mongo.Database) {
+	photoService = services.NewPhotoService(db)
+}
+
+// InitializeImageService sets where uploaded plant, photo and profile images are kept
+func InitializeImageService(service *services.ImageService) {
+	imageService = service
+}
+
+// photoDetails are the editable fields of a photo, sent as form fields on upload and as JSON on edit
+type photoDetails struct {
+	Caption        string `json:"caption" form:"caption"`
+	TakenAt        string `json:"taken_at" form:"taken_at"` // YYYY-MM-DD in Bangkok time or RFC 3339
+	GrowthRecordID string `json:"growth_record_id" form:"growth_record_id"`
+	CareEventID    string `json:"care_event_id" form:"care_event_id"`
+}
+
+// apply validates the details and sets them on the photo, or returns the reason they are invalid.
+// Without a date the photo is dated like the record or event it belongs to, or keeps its date.
+func (d photoDetails) apply(ctx context.Context, userID string, plant *models.Plant, photo *models.PlantPhoto, now time.Time) string {
+	photo.Caption = strings.TrimSpace(d.Caption)
+	if utf8.RuneCountInString(photo.Caption) > maxPhotoCaptionLength {
+		return "caption is too long"
+	}
+
+	var linkedDate time.Time
+	photo.GrowthRecordID = nil
+	if d.GrowthRecordID != "" {
+		recordID, err := primitive.ObjectIDFromHex(d.GrowthRecordID)
+		if err != nil {
+			return "Invalid growth record ID"
+		}
+		record, err := growthService.Get(ctx, plant.ID, recordID)
+		if errors.Is(err, services.ErrGrowthRecordNotFound) {
+			return "Growth record not found on this plant"
+		}
+		if err != nil {
+			return "Failed to fetch growth record"
+		}
+		photo.GrowthRecordID = &record.ID
+		linkedDate = record.Date
+	}
+
+	photo.CareEventID = nil
+	if d.CareEventID != "" {
+		eventID, err := primitive.ObjectIDFromHex(d.CareEventID)
+		if err != nil {
+			return "Invalid care event ID"
+		}
+		event, err := careEventService.Get(ctx, userID, eventID)
+		if err != nil || event.PlantID != plant.ID {
+			return "Care event not found on this plant"
+		}
+		photo.CareEventID = &event.ID
+		if linkedDate.IsZero() {
+			linkedDate = event.Date
+		}
+	}
+
+	switch {
+	case d.TakenAt != "":
+		loc, _ := time.LoadLocation("Asia/Bangkok")
+		takenAt, _, ok := parseDate(d.TakenAt, loc)
+		if !ok {
+			return "Invalid taken_at date"
+		}
+		photo.TakenAt = takenAt
+	case !linkedDate.IsZero():
+		photo.TakenAt = linkedDate
+	case photo.TakenAt.IsZero():
+		photo.TakenAt = now
+	}
+	if photo.TakenAt.After(now) {
+		return "taken_at cannot be in the future"
+	}
+	return ""
+}
+
+func respondPhotoError(c *gin.Context, err error, action string) {
+	if errors.Is(err, services.ErrPhotoNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Photo not found"})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to " + action + " photo"})
+}
+
+// publishPlantChange tells open dashboards the plant changed, e.g. its cover
+func publishPlantChange(ctx context.Context, plantID primitive.ObjectID) {
+	plant, err := plantWithRecentGrowth(ctx, plantID)
+	if err != nil {
+		log.Printf("[ERROR] Error fetching plant %s after a photo change: %v", plantID.Hex(), err)
+		return
+	}
+	eventBus.Publish(plant.UserID, services.EventPlantUpdated, plant)
+}
+
+// UploadPlantPhoto adds a photo to one of the user's plants. Multipart form: image (the file),
+// caption, taken_at, growth_record_id, care_event_id and cover=true to make it the cover.
+func UploadPlantPhoto() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		userID := c.GetString("user_id")
+		plant, status, message := ownedPlant(ctx, userID, c.Param("plant_id"))
+		if message != "" {
+			c.JSON(status, gin.H{"error": message})
+			return
+		}
+
+		var details photoDetails
+		if err := c.ShouldBind(&details); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		now := reminderClock.Now()
+		photo := models.PlantPhoto{UserID: userID, PlantID: plant.ID, CreatedAt: now, UpdatedAt: now}
+		if message := details.apply(ctx, userID, plant, &photo, now); message != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": message})
+			return
+		}
+
+		file, err := c.FormFile("image")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "No image file provided"})
+			return
+		}
+		src, err := file.Open()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to open image file"})
+			return
+		}
+		defer src.Close()
+
+		image, ok := ingestImage(ctx, c, src, "plante/photos")
+		if !ok {
+			return
+		}
+		photo.URL, photo.ImageURLs, photo.ImageKey = image.URLs.Full, &image.URLs, image.Key
+
+		created, err := photoService.Create(ctx, photo)
+		if err != nil {
+			// The unused image is swept
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save photo"})
+			return
+		}
+
+		if c.PostForm("cover") == "true" {
+			if err := photoService.SetCover(ctx, *created, now); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set cover photo"})
+				return
+			}
+			publishPlantChange(ctx, plant.ID)
+		}
+
+		c.JSON(http.StatusCreated, created)
+	}
+}
+
+// GetPhotoTimeline returns the photos of one of the user's plants, oldest first.
+// Query: ?from and ?to (YYYY-MM-DD in Bangkok time or RFC 3339)
+func GetPhotoTimeline() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		plant, status, message := ownedPlant(ctx, c.GetString("user_id"), c.Param("plant_id"))
+		if message != "" {
+			c.JSON(status, gin.H{"error": message})
+			return
+		}
+
+		var filter services.GrowthFilter
+		if filter.From, filter.To, message = parseDateRange(c); message != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": message})
+			return
+		}
+
+		photos, err := photoService.Timeline(ctx, plant.ID, filter)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch photos"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"photos":         photos,
+			"cover_photo_id": plant.CoverPhotoID,
+		})
+	}
+}
+
+// UpdatePlantPhoto edits the caption, date and the growth record or care event of a photo.
+// An empty growth_record_id or care_event_id detaches the photo from it.
+func UpdatePlantPhoto() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		userID := c.GetString("user_id")
+		plant, status, message := ownedPlant(ctx, userID, c.Param("plant_id"))
+		if message != "" {
+			c.JSON(status, gin.H{"error": message})
+			return
+		}
+		photoID, err := primitive.ObjectIDFromHex(c.Param("photo_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid photo ID"})
+			return
+		}
+
+		var details photoDetails
+		if err := c.ShouldBindJSON(&details); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		photo, err := photoService.Get(ctx, plant.ID, photoID)
+		if err != nil {
+			respondPhotoError(c, err, "fetch")
+			return
+		}
+		now := reminderClock.Now()
+		if message := details.apply(ctx, userID, plant, photo, now); message != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": message})
+			return
+		}
+		photo.UpdatedAt = now
+
+		updated, err := photoService.Update(ctx, *photo)
+		if err != nil {
+			respondPhotoError(c, err, "update")
+			return
+		}
+
+		c.JSON(http.StatusOK, updated)
+	}
+}
+
+// SetCoverPhoto makes one of the plant's photos its cover
+func SetCoverPhoto() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		plant, status, message := ownedPlant(ctx, c.GetString("user_id"), c.Param("plant_id"))
+		if message != "" {
+			c.JSON(status, gin.H{"error": message})
+			return
+		}
+		photoID, err := primitive.ObjectIDFromHex(c.Param("photo_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid photo ID"})
+			return
+		}
+
+		photo, err := photoService.Get(ctx, plant.ID, photoID)
+		if err != nil {
+			respondPhotoError(c, err, "fetch")
+			return
+		}
+		if err := photoService.SetCover(ctx, *photo, reminderClock.Now()); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set cover photo"})
+			return
+		}
+
+		updatedPlant, err := plantWithRecentGrowth(ctx, plant.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch updated plant"})
+			return
+		}
+
+		eventBus.Publish(updatedPlant.UserID, services.EventPlantUpdated, updatedPlant)
+		c.Header("ETag", versionETag(updatedPlant.Version))
+		c.JSON(http.StatusOK, updatedPlant)
+	}
+}
+
+// DeletePlantPhoto removes a photo; its image is swept once nothing else uses it.
+// Deleting the cover leaves the plant without one.
+func DeletePlantPhoto() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		plant, status, message := ownedPlant(ctx, c.GetString("user_id"), c.Param("plant_id"))
+		if message != "" {
+			c.JSON(status, gin.H{"error": message})
+			return
+		}
+		photoID, err := primitive.ObjectIDFromHex(c.Param("photo_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid photo ID"})
+			return
+		}
+
+		photo, err := photoService.Delete(ctx, plant.ID, photoID, reminderClock.Now())
+		if err != nil {
+			respondPhotoError(c, err, "delete")
+			return
+		}
+		if plant.CoverPhotoID != nil && *plant.CoverPhotoID == photo.ID {
+			publishPlantChange(ctx, plant.ID)
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Photo deleted"})
+	}
+}
@@ -3,8 +3,10 @@ package controllers
 import (
 	"authentication/config"
 	"authentication/models"
+	"authentication/services"
 	"context"
-	"fmt"
+	"errors"
+	"log"
 	"net/http"
 	"time"
 
@@ -12,14 +14,21 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 var plantCollection *mongo.Collection
+var growthService *services.GrowthService
 
 func InitPlantCollection() {
 	plantCollection = config.OpenCollection("plants")
 }
 
+// InitializeGrowthService initializes the growth record service with the database connection
+func InitializeGrowthService(db *mongo.Database) {
+	growthService = services.NewGrowthService(db)
+}
+
 func CreatePlant() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -38,6 +47,12 @@ func CreatePlant() gin.HandlerFunc {
 		plant.UserID = user.User_id
 		plant.CreatedAt = time.Now()
 		plant.UpdatedAt = time.Now()
+		// Growth records are added through their own endpoint
+		plant.GrowthRecords = nil
+		plant.GrowthSummary = nil
+		plant.Version = 0
+		plant.CoverPhotoID = nil
+		plant.ImageURLs = nil
 
 		result, err := plantCollection.InsertOne(ctx, plant)
 		if err != nil {
@@ -45,6 +60,14 @@ func CreatePlant() gin.HandlerFunc {
 			return
 		}
 
+		// The uploaded image starts the plant's gallery as its cover
+		if plant.ImageURL != "" {
+			plant.ID = result.InsertedID.(primitive.ObjectID)
+			if _, err := photoService.AdoptImage(ctx, plant, assetService.Find(ctx, plant.ImageURL), plant.CreatedAt, plant.CreatedAt); err != nil {
+				log.Printf("[ERROR] Error adding the image of plant %s to its gallery: %v", plant.ID.Hex(), err)
+			}
+		}
+
 		// Get the created plant data
 		var createdPlant models.Plant
 		err = plantCollection.FindOne(ctx, bson.M{"_id": result.InsertedID}).Decode(&createdPlant)
@@ -53,6 +76,8 @@ func CreatePlant() gin.HandlerFunc {
 			return
 		}
 
+		eventBus.Publish(user.User_id, services.EventPlantCreated, createdPlant)
+		c.Header("ETag", versionETag(createdPlant.Version))
 		c.JSON(http.StatusCreated, createdPlant)
 	}
 }
@@ -66,7 +91,8 @@ func GetUserPlants() gin.HandlerFunc {
 		user := c.MustGet("user").(*models.User)
 
 		// Find all plants for the user
-		cursor, err := plantCollection.Find(ctx, bson.M{"user_id": user.User_id})
+		// A stable order keeps the list's ETag stable
+		cursor, err := plantCollection.Find(ctx, bson.M{"user_id": user.User_id}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
@@ -85,6 +111,7 @@ func GetUserPlants() gin.HandlerFunc {
 			// Use the most recent data
 			cleanedPlant := models.Plant{
 				ID:            plant.ID,
+				Version:       plant.Version,
 				UserID:        plant.UserID,
 				Name:          plant.Name,
 				Type:          plant.Type,
@@ -92,9 +119,12 @@ func GetUserPlants() gin.HandlerFunc {
 				PlantHeight:   plant.PlantHeight,
 				PlantDate:     plant.PlantDate,
 				ImageURL:      plant.ImageURL,
+				ImageURLs:     plant.ImageURLs,
+				CoverPhotoID:  plant.CoverPhotoID,
+				CatalogID:     plant.CatalogID,
 				CreatedAt:     plant.CreatedAt,
 				UpdatedAt:     plant.UpdatedAt,
-				GrowthRecords: plant.GrowthRecords,
+				GrowthSummary: plant.GrowthSummary,
 			}
 
 			// If new fields are empty but old fields exist, use old fields
@@ -108,38 +138,54 @@ func GetUserPlants() gin.HandlerFunc {
 				cleanedPlant.ImageURL = plant.Imageurl
 			}
 
-			// Update the plant in database to use new field names
-			update := bson.M{
-				"$set": bson.M{
-					"plant_height": cleanedPlant.PlantHeight,
-					"plant_date":   cleanedPlant.PlantDate,
-					"image_url":    cleanedPlant.ImageURL,
-					"updated_at":   time.Now(),
-				},
-				"$unset": bson.M{
-					"plantheight": "",
-					"plantdate":   "",
-					"imageurl":    "",
-				},
-			}
-
-			_, err = plantCollection.UpdateOne(
-				ctx,
-				bson.M{"_id": plant.ID},
-				update,
-			)
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to clean up plant data"})
-				return
+			// Move plants still using the old field names to the new ones
+			if hasLegacyFields(plant) {
+				if err := migrateLegacyPlantFields(ctx, cleanedPlant); err != nil {
+					c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to clean up plant data"})
+					return
+				}
+				cleanedPlant.Version++
 			}
 
 			cleanedPlants[i] = cleanedPlant
 		}
 
+		stamps := make([]versionStamp, len(cleanedPlants))
+		for i, plant := range cleanedPlants {
+			stamps[i] = versionStamp{ID: plant.ID, Version: plant.Version}
+		}
+		if notModified(c, listETag(stamps)) {
+			return
+		}
 		c.JSON(http.StatusOK, cleanedPlants)
 	}
 }
 
+// hasLegacyFields reports whether the plant still has values under the old field names
+func hasLegacyFields(plant models.Plant) bool {
+	return plant.Plantheight > 0 || !plant.Plantdate.IsZero() || plant.Imageurl != ""
+}
+
+// migrateLegacyPlantFields stores the cleaned plant's values under the new field names and drops the old ones
+func migrateLegacyPlantFields(ctx context.Context, plant models.Plant) error {
+	update := bson.M{
+		"$set": bson.M{
+			"plant_height": plant.PlantHeight,
+			"plant_date":   plant.PlantDate,
+			"image_url":    plant.ImageURL,
+			"updated_at":   time.Now(),
+		},
+		"$unset": bson.M{
+			"plantheight": "",
+			"plantdate":   "",
+			"imageurl":    "",
+		},
+		"$inc": bson.M{"version": 1},
+	}
+	_, err := plantCollection.UpdateOne(ctx, bson.M{"_id": plant.ID}, update)
+	return err
+}
+
 func GetPlant() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -169,6 +215,7 @@ func GetPlant() gin.HandlerFunc {
 		// Clean up plant data
 		cleanedPlant := models.Plant{
 			ID:            plant.ID,
+			Version:       plant.Version,
 			UserID:        plant.UserID,
 			Name:          plant.Name,
 			Type:          plant.Type,
@@ -176,9 +223,12 @@ func GetPlant() gin.HandlerFunc {
 			PlantHeight:   plant.PlantHeight,
 			PlantDate:     plant.PlantDate,
 			ImageURL:      plant.ImageURL,
+			ImageURLs:     plant.ImageURLs,
+			CoverPhotoID:  plant.CoverPhotoID,
+			CatalogID:     plant.CatalogID,
 			CreatedAt:     plant.CreatedAt,
 			UpdatedAt:     plant.UpdatedAt,
-			GrowthRecords: plant.GrowthRecords,
+			GrowthSummary: plant.GrowthSummary,
 		}
 
 		// If new fields are empty but old fields exist, use old fields
@@ -192,28 +242,24 @@ func GetPlant() gin.HandlerFunc {
 			cleanedPlant.ImageURL = plant.Imageurl
 		}
 
-		// Update the plant in database to use new field names
-		update := bson.M{
-			"$set": bson.M{
-				"plant_height": cleanedPlant.PlantHeight,
-				"plant_date":   cleanedPlant.PlantDate,
-				"image_url":    cleanedPlant.ImageURL,
-				"updated_at":   time.Now(),
-			},
-			"$unset": bson.M{
-				"plantheight": "",
-				"plantdate":   "",
-				"imageurl":    "",
-			},
+		// Move a plant still using the old field names to the new ones
+		if hasLegacyFields(plant) {
+			if err := migrateLegacyPlantFields(ctx, cleanedPlant); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to clean up plant data"})
+				return
+			}
+			cleanedPlant.Version++
 		}
 
-		_, err = plantCollection.UpdateOne(
-			ctx,
-			bson.M{"_id": plant.ID},
-			update,
-		)
+		// The version covers the growth records too, so they needn't be fetched for a 304
+		if notModified(c, versionETag(cleanedPlant.Version)) {
+			return
+		}
+
+		// Only the latest records come with the plant; older ones are paged through /growth
+		cleanedPlant.GrowthRecords, _, err = growthService.List(ctx, plant.ID, services.GrowthFilter{}, "", services.RecentGrowthRecords)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to clean up plant data"})
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch growth records"})
 			return
 		}
 
@@ -248,6 +294,13 @@ func UpdatePlant() gin.HandlerFunc {
 			return
 		}
 
+		// With If-Match the update only applies to the version the client last fetched
+		expectedVersions, conditional := ifMatchVersions(c)
+		if conditional && !matchesVersion(expectedVersions, existingPlant.Version) {
+			respondPreconditionFailed(c, existingPlant.Version)
+			return
+		}
+
 		// Parse update data
 		var updateData models.Plant
 		if err := c.BindJSON(&updateData); err != nil {
@@ -260,6 +313,7 @@ func UpdatePlant() gin.HandlerFunc {
 			"$set": bson.M{
 				"updated_at": time.Now(),
 			},
+			"$inc": bson.M{"version": 1},
 		}
 
 		// Only update fields that are provided and not empty
@@ -283,26 +337,52 @@ func UpdatePlant() gin.HandlerFunc {
 		}
 
 		// Perform update
-		_, err = plantCollection.UpdateOne(ctx, bson.M{"_id": objID}, update)
+		filter := bson.M{"_id": objID}
+		if conditional {
+			// Another change may have landed since the plant was read above
+			filter["version"] = versionFilter(existingPlant.Version)
+		}
+		result, err := plantCollection.UpdateOne(ctx, filter, update)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
+		if result.MatchedCount == 0 {
+			var current models.Plant
+			if err := plantCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&current); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch updated plant"})
+				return
+			}
+			respondPreconditionFailed(c, current.Version)
+			return
+		}
+
+		// A newly uploaded image joins the gallery as the cover, the previous one stays in the gallery
+		if updateData.ImageURL != "" && updateData.ImageURL != existingPlant.ImageURL {
+			now := time.Now()
+			if _, err := photoService.AdoptImage(ctx, existingPlant, assetService.Find(ctx, updateData.ImageURL), now, now); err != nil {
+				log.Printf("[ERROR] Error adding the new image of plant %s to its gallery: %v", existingPlant.ID.Hex(), err)
+			}
+		}
 
 		// Get updated plant data
-		var updatedPlant models.Plant
-		err = plantCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&updatedPlant)
+		updatedPlant, err := plantWithRecentGrowth(ctx, objID)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch updated plant"})
 			return
 		}
 
+		eventBus.Publish(updatedPlant.UserID, services.EventPlantUpdated, updatedPlant)
+		c.Header("ETag", versionETag(updatedPlant.Version))
 		c.JSON(http.StatusOK, updatedPlant)
 	}
 }
 
 func UploadPlantImage() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
 		file, err := c.FormFile("image")
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "No image file provided"})
@@ -317,14 +397,13 @@ func UploadPlantImage() gin.HandlerFunc {
 		}
 		defer src.Close()
 
-		// Upload to Cloudinary
-		imageURL, err := config.UploadImage(src, "plante/plants")
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload image"})
+		// Swept unless a plant is created or updated with it within the grace period
+		image, ok := ingestImage(ctx, c, src, "plante/plants")
+		if !ok {
 			return
 		}
 
-		c.JSON(http.StatusOK, gin.H{"image_url": imageURL})
+		c.JSON(http.StatusOK, gin.H{"image_url": image.URLs.Full, "image_urls": image.URLs})
 	}
 }
 
@@ -355,15 +434,11 @@ func DeletePlant() gin.HandlerFunc {
 			return
 		}
 
-		// Delete image from Cloudinary if exists
-		if plant.ImageURL != "" {
-			publicID := config.GetPublicIDFromURL(plant.ImageURL)
-			if publicID != "" {
-				if err := config.DeleteImage(publicID); err != nil {
-					// Log error but continue with plant deletion
-					fmt.Printf("Failed to delete image from Cloudinary: %v\n", err)
-				}
-			}
+		// The gallery's images are released and swept once nothing else uses them
+		now := reminderClock.Now()
+		if _, err := photoService.DeleteForPlant(ctx, objID, now); err != nil {
+			// Log error but continue with plant deletion
+			log.Printf("[ERROR] Failed to delete photos of plant %s: %v", plantID, err)
 		}
 
 		// Delete plant from database
@@ -373,10 +448,47 @@ func DeletePlant() gin.HandlerFunc {
 			return
 		}
 
+		// The plant is gone either way; leftover growth records are never listed
+		if err := growthService.DeleteForPlant(ctx, objID); err != nil {
+			log.Printf("[ERROR] Failed to delete growth records of plant %s: %v", plantID, err)
+		}
+		if growthAlertService != nil {
+			if err := growthAlertService.DeleteForPlant(ctx, objID); err != nil {
+				log.Printf("[ERROR] Failed to delete growth alerts of plant %s: %v", plantID, err)
+			}
+		}
+		if err := careEventService.DeleteForPlant(ctx, objID, now); err != nil {
+			log.Printf("[ERROR] Failed to delete care events of plant %s: %v", plantID, err)
+		}
+
+		eventBus.Publish(user.User_id, services.EventPlantDeleted, gin.H{"_id": objID})
 		c.JSON(http.StatusOK, gin.H{"message": "Plant deleted successfully"})
 	}
 }
 
+// plantWithRecentGrowth fetches the plant with its most recent growth records filled in
+func plantWithRecentGrowth(ctx context.Context, plantID primitive.ObjectID) (*models.Plant, error) {
+	var plant models.Plant
+	if err := plantCollection.FindOne(ctx, bson.M{"_id": plantID}).Decode(&plant); err != nil {
+		return nil, err
+	}
+	records, _, err := growthService.List(ctx, plantID, services.GrowthFilter{}, "", services.RecentGrowthRecords)
+	if err != nil {
+		return nil, err
+	}
+	plant.GrowthRecords = records
+	return &plant, nil
+}
+
+// respondGrowthRecordError maps growth service errors to HTTP responses
+func respondGrowthRecordError(c *gin.Context, err error) {
+	if errors.Is(err, services.ErrGrowthRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Growth record not found"})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
 func AddGrowthRecord() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -411,43 +523,62 @@ func AddGrowthRecord() gin.HandlerFunc {
 			return
 		}
 
-		// Generate a new ObjectID for the growth record
-		newRecord.ID = primitive.NewObjectID()
-		newRecord.CreatedAt = time.Now()
+		// The record's height becomes the plant's height
+		if _, err := growthService.Add(ctx, plant, newRecord, time.Now()); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Get updated plant data to return
+		updatedPlant, err := plantWithRecentGrowth(ctx, objID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch updated plant"})
+			return
+		}
 
-		// Calculate height difference and update plant height
-		oldHeight := plant.PlantHeight
-		plant.PlantHeight = newRecord.Height
-		newRecord.HeightDifference = newRecord.Height - oldHeight
+		scanGrowth(ctx, *updatedPlant)
+		eventBus.Publish(updatedPlant.UserID, services.EventGrowthRecordAdded, updatedPlant)
+		c.JSON(http.StatusOK, updatedPlant)
+	}
+}
 
-		// Add new record to the beginning of the slice (for reverse chronological order display)
-		plant.GrowthRecords = append([]models.GrowthRecord{newRecord}, plant.GrowthRecords...)
+// GetGrowthHistory pages through a plant's growth records, latest first.
+// Query: ?cursor (next_cursor of the previous page), ?limit (default 20, max 100),
+// ?from and ?to (YYYY-MM-DD in Bangkok time or RFC 3339)
+func GetGrowthHistory() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
 
-		// Prepare update fields
-		update := bson.M{
-			"$set": bson.M{
-				"plant_height":   plant.PlantHeight,
-				"growth_records": plant.GrowthRecords,
-				"updated_at":     time.Now(),
-			},
+		plant, status, message := ownedPlant(ctx, c.GetString("user_id"), c.Param("plant_id"))
+		if message != "" {
+			c.JSON(status, gin.H{"error": message})
+			return
 		}
 
-		// Perform update
-		_, err = plantCollection.UpdateOne(ctx, bson.M{"_id": objID}, update)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		var filter services.GrowthFilter
+		filter.From, filter.To, message = parseDateRange(c)
+		if message != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": message})
 			return
 		}
 
-		// Get updated plant data to return
-		var updatedPlant models.Plant
-		err = plantCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&updatedPlant)
+		records, nextCursor, err := growthService.List(ctx, plant.ID, filter, c.Query("cursor"), parseLimit(c, 20, 100))
+		if errors.Is(err, services.ErrInvalidGrowthCursor) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return
+		}
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch updated plant"})
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch growth history"})
 			return
 		}
 
-		c.JSON(http.StatusOK, updatedPlant)
+		c.JSON(http.StatusOK, gin.H{
+			"records":     records,
+			"summary":     plant.GrowthSummary,
+			"next_cursor": nextCursor,
+			"has_more":    nextCursor != "",
+		})
 	}
 }
 
@@ -493,58 +624,21 @@ func UpdateGrowthRecord() gin.HandlerFunc {
 			return
 		}
 
-		// Find the record index
-		recordIndex := -1
-		for i, record := range plant.GrowthRecords {
-			if record.ID == recordObjID {
-				recordIndex = i
-				break
-			}
-		}
-
-		if recordIndex == -1 {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Growth record not found"})
-			return
-		}
-
-		// Calculate height difference
-		oldHeight := plant.GrowthRecords[recordIndex].Height
-		heightDiff := updateData.Height - oldHeight
-
-		// Update the record
-		plant.GrowthRecords[recordIndex].Height = updateData.Height
-		plant.GrowthRecords[recordIndex].HeightDifference = heightDiff
-		plant.GrowthRecords[recordIndex].Mood = updateData.Mood
-		plant.GrowthRecords[recordIndex].Notes = updateData.Notes
-		plant.GrowthRecords[recordIndex].Date = updateData.Date
-
-		// Update plant height
-		plant.PlantHeight = updateData.Height
-
-		// Prepare update fields
-		update := bson.M{
-			"$set": bson.M{
-				"plant_height":   plant.PlantHeight,
-				"growth_records": plant.GrowthRecords,
-				"updated_at":     time.Now(),
-			},
-		}
-
-		// Perform update
-		_, err = plantCollection.UpdateOne(ctx, bson.M{"_id": plantObjID}, update)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		// The plant's height follows its latest record
+		if _, err := growthService.Update(ctx, plantObjID, recordObjID, updateData, time.Now()); err != nil {
+			respondGrowthRecordError(c, err)
 			return
 		}
 
 		// Get updated plant data
-		var updatedPlant models.Plant
-		err = plantCollection.FindOne(ctx, bson.M{"_id": plantObjID}).Decode(&updatedPlant)
+		updatedPlant, err := plantWithRecentGrowth(ctx, plantObjID)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch updated plant"})
 			return
 		}
 
+		scanGrowth(ctx, *updatedPlant)
+		eventBus.Publish(updatedPlant.UserID, services.EventGrowthRecordUpdated, updatedPlant)
 		c.JSON(http.StatusOK, updatedPlant)
 	}
 }
@@ -584,52 +678,24 @@ func DeleteGrowthRecord() gin.HandlerFunc {
 			return
 		}
 
-		// Find the record index and calculate height adjustment
-		recordIndex := -1
-		var heightAdjustment float64
-		for i, record := range plant.GrowthRecords {
-			if record.ID == recordObjID {
-				recordIndex = i
-				heightAdjustment = -record.Height // Subtract the height of the deleted record
-				break
-			}
-		}
-
-		if recordIndex == -1 {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Growth record not found"})
+		// The plant's height goes back to the latest remaining record
+		if err := growthService.Delete(ctx, plantObjID, recordObjID, time.Now()); err != nil {
+			respondGrowthRecordError(c, err)
 			return
 		}
-
-		// Remove the record
-		plant.GrowthRecords = append(plant.GrowthRecords[:recordIndex], plant.GrowthRecords[recordIndex+1:]...)
-
-		// Update plant height
-		plant.PlantHeight += heightAdjustment
-
-		// Prepare update fields
-		update := bson.M{
-			"$set": bson.M{
-				"plant_height":   plant.PlantHeight,
-				"growth_records": plant.GrowthRecords,
-				"updated_at":     time.Now(),
-			},
-		}
-
-		// Perform update
-		_, err = plantCollection.UpdateOne(ctx, bson.M{"_id": plantObjID}, update)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
+		if _, err := photoService.DeleteForGrowthRecord(ctx, recordObjID, time.Now()); err != nil {
+			log.Printf("[ERROR] Error deleting photos of growth record %s: %v", recordObjID.Hex(), err)
 		}
 
 		// Get updated plant data
-		var updatedPlant models.Plant
-		err = plantCollection.FindOne(ctx, bson.M{"_id": plantObjID}).Decode(&updatedPlant)
+		updatedPlant, err := plantWithRecentGrowth(ctx, plantObjID)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch updated plant"})
 			return
 		}
 
+		scanGrowth(ctx, *updatedPlant)
+		eventBus.Publish(updatedPlant.UserID, services.EventGrowthRecordDeleted, updatedPlant)
 		c.JSON(http.StatusOK, updatedPlant)
 	}
 }
@@ -0,0 +1,126 @@
+package controllers
+
+import (
+	"authentication/models"
+	"authentication/services"
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var presetService *services.PresetService
+
+// InitializePresetService initializes the reminder preset service with the database connection
+func InitializePresetService(db *mongo.Database) {
+	presetService = services.NewPresetService(db)
+}
+
+// GetReminderPresets suggests watering, fertilizing and misting reminders for one of the user's plants
+func GetReminderPresets() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		plant, status, message := ownedPlant(ctx, c.GetString("user_id"), c.Param("plant_id"))
+		if message != "" {
+			c.JSON(status, gin.H{"error": message})
+			return
+		}
+
+		presets, err := presetService.Suggest(ctx, *plant)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to suggest reminders"})
+			return
+		}
+
+		c.JSON(http.StatusOK, presets)
+	}
+}
+
+// ApplyReminderPresets creates the suggested reminders for a plant in one call. Types the
+// plant already has a reminder for are skipped unless includeExisting is set.
+func ApplyReminderPresets() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		var request struct {
+			Types           []string `json:"types"` // Empty for all suggested types
+			IncludeExisting bool     `json:"includeExisting"`
+		}
+		// The body is optional
+		if c.Request.ContentLength > 0 {
+			if err := c.ShouldBindJSON(&request); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+
+		user := c.MustGet("user").(*models.User)
+		plant, status, message := ownedPlant(ctx, user.User_id, c.Param("plant_id"))
+		if message != "" {
+			c.JSON(status, gin.H{"error": message})
+			return
+		}
+
+		suggested, err := presetService.Suggest(ctx, *plant)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to suggest reminders"})
+			return
+		}
+
+		devices, err := deviceService.GetUserDevices(ctx, user.User_id)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user devices"})
+			return
+		}
+
+		wanted := make(map[string]bool, len(request.Types))
+		for _, reminderType := range request.Types {
+			wanted[reminderType] = true
+		}
+
+		loc, _ := time.LoadLocation("Asia/Bangkok")
+		created := []models.Reminder{}
+		skipped := []gin.H{}
+		for _, preset := range suggested.Presets {
+			if len(wanted) > 0 && !wanted[preset.Type] {
+				continue
+			}
+			if preset.Exists && !request.IncludeExisting {
+				skipped = append(skipped, gin.H{"type": preset.Type, "error": "Plant already has a reminder of this type"})
+				continue
+			}
+			// At least one of the channels chosen for this type must be able to reach the user
+			if len(services.ReachableChannels(user, devices, preset.Type)) == 0 {
+				skipped = append(skipped, gin.H{"type": preset.Type, "error": "User has not enabled notifications"})
+				continue
+			}
+
+			reminder := preset.Reminder(*plant)
+			if message := applyPlantDefaults(ctx, &reminder, *plant); message != "" {
+				skipped = append(skipped, gin.H{"type": preset.Type, "error": message})
+				continue
+			}
+			reminder.ID = primitive.NewObjectID()
+			reminder.IsActive = true
+			reminder.CreatedAt = reminderClock.Now().In(loc)
+			reminder.UpdatedAt = reminder.CreatedAt
+
+			if _, err := reminderCollection.InsertOne(ctx, reminder); err != nil {
+				skipped = append(skipped, gin.H{"type": preset.Type, "error": "Failed to create reminder"})
+				continue
+			}
+			created = append(created, reminder)
+		}
+
+		c.JSON(http.StatusCreated, gin.H{
+			"created": created,
+			"skipped": skipped,
+		})
+	}
+}
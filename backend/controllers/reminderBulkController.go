@@ -0,0 +1,409 @@
+package controllers
+
+import (
+	"authentication/models"
+	"authentication/services"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// maxBulkPlants caps how many plants one bulk request may touch
+const maxBulkPlants = 100
+
+// bulkReminderTarget selects reminders by plant, by type, or both. Without plant IDs it
+// covers every plant the user has reminders of the type for.
+type bulkReminderTarget struct {
+	PlantIDs []string `json:"plantIds"`
+	Type     string   `json:"type"`
+}
+
+// bulkPlantResult reports what a bulk request did for one plant
+type bulkPlantResult struct {
+	PlantID  string           `json:"plantId"`
+	Success  bool             `json:"success"`
+	Error    string           `json:"error,omitempty"`
+	Count    int64            `json:"count"` // Reminders created, updated or deleted
+	Reminder *models.Reminder `json:"reminder,omitempty"`
+}
+
+// respondBulk sends the per-plant results with totals
+func respondBulk(c *gin.Context, results []bulkPlantResult) {
+	succeeded := 0
+	for _, result := range results {
+		if result.Success {
+			succeeded++
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"results":   results,
+		"succeeded": succeeded,
+		"failed":    len(results) - succeeded,
+	})
+}
+
+// ownedPlant fetches a plant by hex ID if the user owns it, or returns the status and
+// message to respond with when it can't be used
+func ownedPlant(ctx context.Context, userID, plantID string) (*models.Plant, int, string) {
+	objID, err := primitive.ObjectIDFromHex(plantID)
+	if err != nil {
+		return nil, http.StatusBadRequest, "Invalid plant ID"
+	}
+	var plant models.Plant
+	err = plantCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&plant)
+	if err == mongo.ErrNoDocuments {
+		return nil, http.StatusNotFound, "Plant not found"
+	}
+	if err != nil {
+		return nil, http.StatusInternalServerError, "Failed to fetch plant data"
+	}
+	if plant.UserID != userID {
+		return nil, http.StatusForbidden, "You are not the owner of this plant"
+	}
+	return &plant, http.StatusOK, ""
+}
+
+// bulkPlant is one plant of a bulk request, with the reason it is left out if it is
+type bulkPlant struct {
+	ID    string
+	Plant *models.Plant
+	Error string
+}
+
+// resolveBulkTarget returns the plants a bulk request applies to, in request order.
+// It responds with an error and returns false when the request selects nothing.
+func resolveBulkTarget(c *gin.Context, ctx context.Context, userID string, target bulkReminderTarget) ([]bulkPlant, bool) {
+	if len(target.PlantIDs) == 0 && target.Type == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "plantIds or type is required"})
+		return nil, false
+	}
+
+	plantIDs := target.PlantIDs
+	if len(plantIDs) == 0 {
+		ids, err := reminderCollection.Distinct(ctx, "plant_id", bson.M{"user_id": userID, "type": target.Type})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reminders"})
+			return nil, false
+		}
+		for _, id := range ids {
+			if objID, ok := id.(primitive.ObjectID); ok {
+				plantIDs = append(plantIDs, objID.Hex())
+			}
+		}
+	}
+	if len(plantIDs) > maxBulkPlants {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("At most %d plants per request", maxBulkPlants)})
+		return nil, false
+	}
+
+	plants := make([]bulkPlant, 0, len(plantIDs))
+	seen := make(map[string]bool, len(plantIDs))
+	for _, plantID := range plantIDs {
+		if seen[plantID] {
+			continue
+		}
+		seen[plantID] = true
+
+		plant, _, message := ownedPlant(ctx, userID, plantID)
+		plants = append(plants, bulkPlant{ID: plantID, Plant: plant, Error: message})
+	}
+	return plants, true
+}
+
+// bulkFilter matches the user's reminders of one plant, narrowed to the target's type
+func bulkFilter(userID string, plantID primitive.ObjectID, reminderType string) bson.M {
+	filter := bson.M{"user_id": userID, "plant_id": plantID}
+	if reminderType != "" {
+		filter["type"] = reminderType
+	}
+	return filter
+}
+
+// BulkCreateReminders creates the same reminder for many plants, once per plant even if it is listed twice
+func BulkCreateReminders() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		var request struct {
+			models.Reminder
+			PlantIDs []string `json:"plantIds"`
+		}
+		if err := c.BindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		spec := request.Reminder
+
+		user := c.MustGet("user").(*models.User)
+
+		if len(request.PlantIDs) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "plantIds is required"})
+			return
+		}
+		if len(request.PlantIDs) > maxBulkPlants {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("At most %d plants per request", maxBulkPlants)})
+			return
+		}
+		if message := validateReminderSchedule(spec); message != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": message})
+			return
+		}
+
+		escalation, message, err := resolveEscalation(ctx, user.User_id, spec.Escalation)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate escalation steps"})
+			return
+		}
+		if message != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": message})
+			return
+		}
+		spec.Escalation = escalation
+
+		// At least one of the channels chosen for this type must be able to reach the user
+		devices, err := deviceService.GetUserDevices(ctx, user.User_id)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user devices"})
+			return
+		}
+		if len(services.ReachableChannels(user, devices, spec.Type)) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "User has not enabled notifications"})
+			return
+		}
+
+		plants, ok := resolveBulkTarget(c, ctx, user.User_id, bulkReminderTarget{PlantIDs: request.PlantIDs})
+		if !ok {
+			return
+		}
+
+		loc, _ := time.LoadLocation("Asia/Bangkok")
+		results := make([]bulkPlantResult, 0, len(plants))
+		for _, target := range plants {
+			result := bulkPlantResult{PlantID: target.ID, Error: target.Error}
+			if target.Error != "" {
+				results = append(results, result)
+				continue
+			}
+			plant := target.Plant
+
+			reminder := spec
+			reminder.PlantID = plant.ID
+			if message := applyPlantDefaults(ctx, &reminder, *plant); message != "" {
+				result.Error = message
+				results = append(results, result)
+				continue
+			}
+			reminder.ID = primitive.NewObjectID()
+			reminder.UserID = user.User_id
+			reminder.IsActive = true
+			reminder.NotificationData = ""
+			reminder.Version = 0
+			reminder.CreatedAt = reminderClock.Now().In(loc)
+			reminder.UpdatedAt = reminder.CreatedAt
+
+			if _, err := reminderCollection.InsertOne(ctx, reminder); err != nil {
+				result.Error = "Failed to create reminder"
+				results = append(results, result)
+				continue
+			}
+			result.Success = true
+			result.Count = 1
+			result.Reminder = &reminder
+			results = append(results, result)
+		}
+
+		respondBulk(c, results)
+	}
+}
+
+// BulkSetRemindersActive pauses or resumes all reminders of the given plants and/or type
+func BulkSetRemindersActive() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		var request struct {
+			bulkReminderTarget
+			IsActive *bool `json:"isActive"`
+		}
+		if err := c.BindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if request.IsActive == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "isActive is required"})
+			return
+		}
+
+		userID := c.GetString("user_id")
+		plants, ok := resolveBulkTarget(c, ctx, userID, request.bulkReminderTarget)
+		if !ok {
+			return
+		}
+
+		results := make([]bulkPlantResult, 0, len(plants))
+		for _, target := range plants {
+			result := bulkPlantResult{PlantID: target.ID, Error: target.Error}
+			if target.Error != "" {
+				results = append(results, result)
+				continue
+			}
+			plant := target.Plant
+			updated, err := reminderCollection.UpdateMany(ctx, bulkFilter(userID, plant.ID, request.Type), bson.M{
+				"$set": bson.M{"is_active": *request.IsActive, "updated_at": reminderClock.Now()},
+				"$inc": bson.M{"version": 1},
+			})
+			if err != nil {
+				result.Error = "Failed to update reminders"
+			} else {
+				result.Success = true
+				result.Count = updated.ModifiedCount
+			}
+			results = append(results, result)
+		}
+
+		respondBulk(c, results)
+	}
+}
+
+// BulkShiftReminders moves all reminders of the given plants and/or type by a number of minutes,
+// e.g. 60 to water an hour later
+func BulkShiftReminders() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		var request struct {
+			bulkReminderTarget
+			OffsetMinutes int `json:"offsetMinutes"`
+		}
+		if err := c.BindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if request.OffsetMinutes == 0 || request.OffsetMinutes < -7*24*60 || request.OffsetMinutes > 7*24*60 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "offsetMinutes must be non-zero and at most a week"})
+			return
+		}
+		offset := time.Duration(request.OffsetMinutes) * time.Minute
+
+		userID := c.GetString("user_id")
+		plants, ok := resolveBulkTarget(c, ctx, userID, request.bulkReminderTarget)
+		if !ok {
+			return
+		}
+
+		results := make([]bulkPlantResult, 0, len(plants))
+		for _, target := range plants {
+			result := bulkPlantResult{PlantID: target.ID, Error: target.Error}
+			if target.Error != "" {
+				results = append(results, result)
+				continue
+			}
+			plant := target.Plant
+
+			cursor, err := reminderCollection.Find(ctx, bulkFilter(userID, plant.ID, request.Type))
+			if err != nil {
+				result.Error = "Failed to fetch reminders"
+				results = append(results, result)
+				continue
+			}
+			var reminders []models.Reminder
+			if err := cursor.All(ctx, &reminders); err != nil {
+				result.Error = "Failed to decode reminders"
+				results = append(results, result)
+				continue
+			}
+
+			for _, reminder := range reminders {
+				shifted, err := services.ShiftReminderSchedule(reminder, offset)
+				if err != nil {
+					result.Error = fmt.Sprintf("Reminder %s: %v", reminder.ID.Hex(), err)
+					break
+				}
+				set := bson.M{"scheduled_time": shifted.ScheduledTime, "updated_at": reminderClock.Now()}
+				if shifted.TimeOfDay != "" {
+					set["time_of_day"] = shifted.TimeOfDay
+				}
+				if shifted.DayOfWeek != "" {
+					set["day_of_week"] = shifted.DayOfWeek
+				}
+				_, err = reminderCollection.UpdateOne(ctx, bson.M{"_id": reminder.ID, "user_id": userID}, bson.M{"$set": set, "$inc": bson.M{"version": 1}})
+				if err != nil {
+					result.Error = "Failed to update reminders"
+					break
+				}
+				result.Count++
+			}
+			result.Success = result.Error == ""
+			results = append(results, result)
+		}
+
+		respondBulk(c, results)
+	}
+}
+
+// BulkDeleteReminders deletes all reminders of the given plants and/or type
+func BulkDeleteReminders() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		var request bulkReminderTarget
+		if err := c.BindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		userID := c.GetString("user_id")
+		plants, ok := resolveBulkTarget(c, ctx, userID, request)
+		if !ok {
+			return
+		}
+
+		results := make([]bulkPlantResult, 0, len(plants))
+		for _, target := range plants {
+			result := bulkPlantResult{PlantID: target.ID, Error: target.Error}
+			if target.Error != "" {
+				results = append(results, result)
+				continue
+			}
+			plant := target.Plant
+			ids, err := reminderCollection.Distinct(ctx, "_id", bulkFilter(userID, plant.ID, request.Type))
+			if err != nil {
+				result.Error = "Failed to fetch reminders"
+				results = append(results, result)
+				continue
+			}
+			reminderIDs := make([]primitive.ObjectID, 0, len(ids))
+			for _, id := range ids {
+				if objID, ok := id.(primitive.ObjectID); ok {
+					reminderIDs = append(reminderIDs, objID)
+				}
+			}
+
+			deleted, err := reminderCollection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": reminderIDs}, "user_id": userID})
+			if err != nil {
+				result.Error = "Failed to delete reminders"
+				results = append(results, result)
+				continue
+			}
+			if err := occurrenceService.DeleteForReminders(ctx, reminderIDs); err != nil {
+				log.Printf("[ERROR] Failed to delete occurrences of the reminders of plant %s: %v", target.ID, err)
+			}
+			result.Success = true
+			result.Count = deleted.DeletedCount
+			results = append(results, result)
+		}
+
+		respondBulk(c, results)
+	}
+}
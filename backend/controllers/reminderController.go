@@ -14,11 +14,15 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 var reminderCollection *mongo.Collection
 var reminderService *services.ReminderService
 
+// reminderClock is the time reminders and occurrences are stamped with
+var reminderClock services.Clock = services.RealClock{}
+
 func InitReminderCollection() {
 	reminderCollection = config.OpenCollection("reminders")
 }
@@ -28,6 +32,11 @@ func InitializeReminderService(db *mongo.Database) {
 	reminderService = services.NewReminderService(db)
 }
 
+// InitializeClock sets the clock the reminder controllers read the time from
+func InitializeClock(clock services.Clock) {
+	reminderClock = clock
+}
+
 // CreateReminder handles the creation of a new reminder
 func CreateReminder() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -47,7 +56,7 @@ func CreateReminder() gin.HandlerFunc {
 			return
 		}
 
-		// Get user's FCM token
+		// Get user's notification settings
 		var user models.User
 		err := userCollection.FindOne(ctx, bson.M{"user_id": userID}).Decode(&user)
 		if err != nil {
@@ -56,20 +65,8 @@ func CreateReminder() gin.HandlerFunc {
 		}
 
 		// Validate reminder fields
-		if reminder.Type == "" || reminder.Frequency == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Missing type or frequency"})
-			return
-		}
-		if reminder.Frequency == "once" && reminder.ScheduledTime.IsZero() {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Missing scheduled time for one-time reminder"})
-			return
-		}
-		if (reminder.Frequency == "daily" || reminder.Frequency == "weekly") && reminder.TimeOfDay == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Missing time of day for daily/weekly reminder"})
-			return
-		}
-		if reminder.Frequency == "weekly" && reminder.DayOfWeek == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Missing day of week for weekly reminder"})
+		if message := validateReminderSchedule(reminder); message != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": message})
 			return
 		}
 
@@ -97,45 +94,46 @@ func CreateReminder() gin.HandlerFunc {
 			return
 		}
 
-		// FCM Token check
-		if user.FCMToken == nil || *user.FCMToken == "" {
+		if message := applyPlantDefaults(ctx, &reminder, plant); message != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": message})
+			return
+		}
+
+		escalation, message, err := resolveEscalation(ctx, user.User_id, reminder.Escalation)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate escalation steps"})
+			return
+		}
+		if message != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": message})
+			return
+		}
+		reminder.Escalation = escalation
+
+		// At least one of the channels chosen for this type must be able to reach the user
+		devices, err := deviceService.GetUserDevices(ctx, user.User_id)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user devices"})
+			return
+		}
+		if len(services.ReachableChannels(&user, devices, reminder.Type)) == 0 {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "User has not enabled notifications"})
 			return
 		}
 
 		// Timezone handling (set to Asia/Bangkok)
 		loc, _ := time.LoadLocation("Asia/Bangkok")
-		reminder.CreatedAt = time.Now().In(loc)
-		reminder.UpdatedAt = time.Now().In(loc)
+		reminder.CreatedAt = reminderClock.Now().In(loc)
+		reminder.UpdatedAt = reminderClock.Now().In(loc)
 
 		// Set reminder ID BEFORE creating notification data
 		reminder.ID = primitive.NewObjectID()
 		reminder.UserID = plant.UserID
 		reminder.IsActive = true
+		reminder.Version = 0
 
-		// Create reminder data for notification (add title/body)
-		// Frontend now sends notificationData as a JSON string
-		// We just need to ensure it's stored correctly.
-		// The NotificationData field is already part of the reminder struct
-
-		// Remove backend-side generation:
-		/*
-			reminderData := map[string]interface{}{
-				"reminderId": reminder.ID.Hex(),
-				"plantId":    reminder.PlantID.Hex(),
-				"type":       reminder.Type,
-				"frequency":  reminder.Frequency,
-				"plantName":  plant.Name,
-				"title":      fmt.Sprintf("ถึงเวลาสำหรับ %s", reminder.Type),
-				"body":       fmt.Sprintf("อย่าลืม %s ให้กับ %s!", reminder.Type, plant.Name),
-			}
-			reminderJSON, err := json.Marshal(reminderData)
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to prepare notification data"})
-				return
-			}
-			reminder.NotificationData = string(reminderJSON)
-		*/
+		// Notification text is rendered from server-side templates when the reminder fires
+		reminder.NotificationData = ""
 
 		_, insertErr := reminderCollection.InsertOne(ctx, reminder)
 		if insertErr != nil {
@@ -147,6 +145,49 @@ func CreateReminder() gin.HandlerFunc {
 	}
 }
 
+// validateReminderSchedule checks that the reminder has what its frequency needs
+// and returns the error message for the client otherwise
+func validateReminderSchedule(reminder models.Reminder) string {
+	if reminder.Type == "" || reminder.Frequency == "" {
+		return "Missing type or frequency"
+	}
+	if reminder.Frequency == "once" && reminder.ScheduledTime.IsZero() {
+		return "Missing scheduled time for one-time reminder"
+	}
+	if (reminder.Frequency == "daily" || reminder.Frequency == "weekly") && reminder.TimeOfDay == "" {
+		return "Missing time of day for daily/weekly reminder"
+	}
+	if reminder.Frequency == "weekly" && reminder.DayOfWeek == "" {
+		return "Missing day of week for weekly reminder"
+	}
+	if reminder.Frequency == "interval" && reminder.TimeOfDay == "" {
+		return "Missing time of day for interval reminder"
+	}
+	return ""
+}
+
+// applyPlantDefaults fills in what an interval reminder leaves out: its length starts from
+// the plant's catalog watering requirement and it counts from today
+func applyPlantDefaults(ctx context.Context, reminder *models.Reminder, plant models.Plant) string {
+	if reminder.Frequency != "interval" {
+		return ""
+	}
+	if reminder.IntervalDays < 1 {
+		days, ok := 0, false
+		if reminder.Type == "watering" {
+			days, ok = wateringService.CatalogInterval(ctx, plant)
+		}
+		if !ok {
+			return "Missing interval days for interval reminder"
+		}
+		reminder.IntervalDays = days
+	}
+	if reminder.ScheduledTime.IsZero() {
+		reminder.ScheduledTime = reminderClock.Now()
+	}
+	return ""
+}
+
 // GetReminders handles fetching reminders for the authenticated user, optionally filtered by plantId
 func GetReminders() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -172,7 +213,8 @@ func GetReminders() gin.HandlerFunc {
 			filter["plant_id"] = plantObjID
 		}
 
-		cursor, err := reminderCollection.Find(ctx, filter)
+		// A stable order keeps the list's ETag stable
+		cursor, err := reminderCollection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reminders"})
 			return
@@ -185,62 +227,127 @@ func GetReminders() gin.HandlerFunc {
 			return
 		}
 
+		if notModified(c, reminderListETag(reminders)) {
+			return
+		}
 		c.JSON(http.StatusOK, reminders)
 	}
 }
 
-// UpdateReminder handles updating an existing reminder
-func UpdateReminder() gin.HandlerFunc {
+// GetReminder returns one of the user's reminders; its ETag is used with If-Match to update it
+func GetReminder() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 
-		reminderID := c.Param("id")
-		objID, err := primitive.ObjectIDFromHex(reminderID)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reminder ID"})
+		reminder, ok := findUserReminder(c, ctx)
+		if !ok {
 			return
 		}
 
-		userID, exists := c.Get("user_id")
-		if !exists {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		if notModified(c, versionETag(reminder.Version)) {
 			return
 		}
+		c.JSON(http.StatusOK, reminder)
+	}
+}
 
-		var updatedReminder models.Reminder
-		if err := c.BindJSON(&updatedReminder); err != nil {
+// UpdateReminder handles updating an existing reminder. The fields sent are merged into the
+// stored reminder and the merged schedule is checked like a new one.
+func UpdateReminder() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		var request struct {
+			models.Reminder
+			Adaptive *bool `json:"adaptive"` // Left as it is when not sent
+		}
+		if err := c.BindJSON(&request); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
+		updatedReminder := request.Reminder
 
-		// Ensure the user owns the reminder
-		filter := bson.M{"_id": objID, "user_id": userID}
-
-		updateFields := bson.M{
-			"updated_at": time.Now(),
+		existing, ok := findUserReminder(c, ctx)
+		if !ok {
+			return
 		}
+		objID := existing.ID
+		userID := existing.UserID
 
-		// Only update fields that are provided and allowed
+		// Only merge fields that are provided and allowed
+		merged := *existing
 		if updatedReminder.Type != "" {
-			updateFields["type"] = updatedReminder.Type
+			merged.Type = updatedReminder.Type
 		}
 		if updatedReminder.Frequency != "" {
-			updateFields["frequency"] = updatedReminder.Frequency
+			merged.Frequency = updatedReminder.Frequency
 		}
 		if !updatedReminder.ScheduledTime.IsZero() {
-			updateFields["scheduled_time"] = updatedReminder.ScheduledTime
+			merged.ScheduledTime = updatedReminder.ScheduledTime
 		}
 		if updatedReminder.DayOfWeek != "" {
-			updateFields["day_of_week"] = updatedReminder.DayOfWeek
+			merged.DayOfWeek = updatedReminder.DayOfWeek
 		}
 		if updatedReminder.TimeOfDay != "" {
-			updateFields["time_of_day"] = updatedReminder.TimeOfDay
+			merged.TimeOfDay = updatedReminder.TimeOfDay
+		}
+		if updatedReminder.IntervalDays > 0 {
+			merged.IntervalDays = updatedReminder.IntervalDays
+		}
+		if request.Adaptive != nil {
+			merged.Adaptive = *request.Adaptive
+		}
+		// Only an interval schedule can be adaptive
+		merged.Adaptive = merged.Adaptive && merged.Frequency == "interval"
+
+		if message := validateReminderSchedule(merged); message != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": message})
+			return
+		}
+		var plant models.Plant
+		err := plantCollection.FindOne(ctx, bson.M{"_id": merged.PlantID}).Decode(&plant)
+		if err != nil && err != mongo.ErrNoDocuments {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch plant data"})
+			return
+		}
+		if message := applyPlantDefaults(ctx, &merged, plant); message != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": message})
+			return
+		}
+
+		// Ensure the user owns the reminder
+		filter := bson.M{"_id": objID, "user_id": userID}
+
+		// With If-Match the update only applies to the version the client last fetched
+		expectedVersions, conditional := ifMatchVersions(c)
+		if conditional {
+			if len(expectedVersions) == 0 {
+				respondReminderPreconditionFailed(c, ctx, filter)
+				return
+			}
+			filter["version"] = versionFilter(expectedVersions...)
+		}
+
+		updateFields := bson.M{
+			"type":        merged.Type,
+			"frequency":   merged.Frequency,
+			"day_of_week": merged.DayOfWeek,
+			"time_of_day": merged.TimeOfDay,
+			"adaptive":    merged.Adaptive,
+			"updated_at":  reminderClock.Now(),
+		}
+		if !merged.ScheduledTime.IsZero() {
+			updateFields["scheduled_time"] = merged.ScheduledTime
+		}
+		if merged.IntervalDays > 0 {
+			updateFields["interval_days"] = merged.IntervalDays
 		}
 		// Allow updating IsActive status
 		updateFields["is_active"] = updatedReminder.IsActive
 
-		update := bson.M{"$set": updateFields}
+		update := bson.M{"$set": updateFields, "$inc": bson.M{"version": 1}}
 
 		result, err := reminderCollection.UpdateOne(ctx, filter, update)
 		if err != nil {
@@ -248,6 +355,10 @@ func UpdateReminder() gin.HandlerFunc {
 			return
 		}
 
+		if result.MatchedCount == 0 && conditional {
+			respondReminderPreconditionFailed(c, ctx, bson.M{"_id": objID, "user_id": userID})
+			return
+		}
 		if result.ModifiedCount == 0 {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Reminder not found or no changes made"})
 			return
@@ -261,6 +372,7 @@ func UpdateReminder() gin.HandlerFunc {
 			return
 		}
 
+		c.Header("ETag", versionETag(reminder.Version))
 		c.JSON(http.StatusOK, reminder)
 	}
 }
@@ -297,6 +409,9 @@ func DeleteReminder() gin.HandlerFunc {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Reminder not found or already deleted"})
 			return
 		}
+		if err := occurrenceService.DeleteForReminders(ctx, []primitive.ObjectID{objID}); err != nil {
+			log.Printf("[ERROR] Failed to delete occurrences of reminder %s: %v", reminderID, err)
+		}
 
 		c.JSON(http.StatusOK, gin.H{"message": "Reminder deleted successfully"})
 	}
@@ -342,6 +457,9 @@ func GetPlantReminders() gin.HandlerFunc {
 			reminders = []models.Reminder{}
 		}
 
+		if notModified(c, reminderListETag(reminders)) {
+			return
+		}
 		c.JSON(http.StatusOK, gin.H{
 			"reminders": reminders,
 			"count":     len(reminders),
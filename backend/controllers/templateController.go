@@ -0,0 +1,96 @@
+package controllers
+
+import (
+	"authentication/models"
+	"authentication/services"
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var templateService *services.TemplateService
+
+// InitializeTemplateService initializes the notification template service with the database connection
+func InitializeTemplateService(db *mongo.Database) {
+	templateService = services.NewTemplateService(db)
+}
+
+// GetNotificationTemplates lists all notification templates (admin only)
+func GetNotificationTemplates() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		templates, err := templateService.List(ctx)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch notification templates"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"templates": templates,
+			"count":     len(templates),
+		})
+	}
+}
+
+// UpdateNotificationTemplate creates or replaces the template of a reminder type and locale (admin only)
+func UpdateNotificationTemplate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		locale := c.Param("locale")
+		if !services.IsSupportedLocale(locale) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported locale, expected th or en"})
+			return
+		}
+
+		var request struct {
+			Title string `json:"title" binding:"required"`
+			Body  string `json:"body" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Title and body are required"})
+			return
+		}
+
+		user := c.MustGet("user").(*models.User)
+		saved, err := templateService.Save(ctx, models.NotificationTemplate{
+			Type:      c.Param("type"),
+			Locale:    locale,
+			Title:     request.Title,
+			Body:      request.Body,
+			UpdatedBy: user.User_id,
+		})
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, saved)
+	}
+}
+
+// ResetNotificationTemplate restores the built-in text of a template or removes a custom one (admin only)
+func ResetNotificationTemplate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		reset, err := templateService.Reset(ctx, c.Param("type"), c.Param("locale"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset notification template"})
+			return
+		}
+		if !reset {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Notification template not found"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Notification template reset successfully"})
+	}
+}
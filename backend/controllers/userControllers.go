@@ -4,7 +4,9 @@ import (
 	"authentication/config"
 	"authentication/helpers"
 	"authentication/models"
+	"authentication/services"
 	"context"
+	"errors"
 	"io/ioutil"
 	"log"
 	"net/http"
@@ -17,6 +19,7 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 var validate = validator.New()
@@ -268,7 +271,7 @@ func Logout() gin.HandlerFunc {
 
 func UploadProfileImage() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
 
 		// Get user ID from context (set by auth middleware)
@@ -293,29 +296,42 @@ func UploadProfileImage() gin.HandlerFunc {
 		}
 		defer src.Close()
 
-		// Upload to Cloudinary
-		imageURL, err := config.UploadImage(src, "plante/profiles")
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload image"})
+		image, ok := ingestImage(ctx, c, src, "plante/profiles")
+		if !ok {
 			return
 		}
+		imageURL := image.URLs.Full
 
 		// Update user document with the new image URL
 		filter := bson.M{"user_id": userID.(string)}
+		now := time.Now()
 		update := bson.M{
 			"$set": bson.M{
 				"profileImageUrl": imageURL,
-				"updated_at":      time.Now(),
+				"updated_at":      now,
 			},
 		}
 
-		_, err = userCollection.UpdateOne(ctx, filter, update)
+		var previous struct {
+			ProfileImageURL string `bson:"profileImageUrl"`
+		}
+		err = userCollection.FindOneAndUpdate(ctx, filter, update,
+			options.FindOneAndUpdate().SetProjection(bson.M{"profileImageUrl": 1}),
+		).Decode(&previous)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user profile image"})
 			return
 		}
 
-		c.JSON(http.StatusOK, gin.H{"message": "Profile image uploaded successfully", "profileImageUrl": imageURL})
+		// The previous image is swept once nothing else uses it
+		if err := assetService.Retain(ctx, imageURL, now); err != nil {
+			log.Printf("[ERROR] Error retaining profile image of user %s: %v", userID, err)
+		}
+		if err := assetService.Release(ctx, previous.ProfileImageURL, now); err != nil {
+			log.Printf("[ERROR] Error releasing previous profile image of user %s: %v", userID, err)
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Profile image uploaded successfully", "profileImageUrl": imageURL, "profileImageUrls": image.URLs})
 	}
 }
 
@@ -378,14 +394,16 @@ func UpdateUser() gin.HandlerFunc {
 	}
 }
 
-// SaveFCMTokenHandler handles saving the FCM token for a user
+// SaveFCMTokenHandler registers the FCM token as one of the user's devices
 func SaveFCMTokenHandler() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 
 		var request struct {
-			FCMToken string `json:"fcmToken" binding:"required"`
+			FCMToken   string `json:"fcmToken" binding:"required"`
+			Platform   string `json:"platform"`
+			AppVersion string `json:"appVersion"`
 		}
 
 		if err := c.ShouldBindJSON(&request); err != nil {
@@ -400,21 +418,274 @@ func SaveFCMTokenHandler() gin.HandlerFunc {
 			return
 		}
 
-		// Update user document with FCM token
-		filter := bson.M{"user_id": userID.(string)}
-		update := bson.M{
+		device, err := deviceService.RegisterDevice(ctx, userID.(string), request.FCMToken, request.Platform, request.AppVersion)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update FCM token"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "FCM token updated successfully", "device": device})
+	}
+}
+
+// GetNotificationPreferences returns the notification channels chosen by the authenticated user
+func GetNotificationPreferences() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user := c.MustGet("user").(*models.User)
+
+		prefs := user.NotificationPreferences
+		if prefs == nil {
+			prefs = &models.NotificationPreferences{}
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"preferences":    prefs,
+			"webhook_signed": prefs.WebhookSecret != "",
+		})
+	}
+}
+
+// UpdateNotificationPreferences replaces the channel choices and webhook settings of the authenticated user
+func UpdateNotificationPreferences() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		user := c.MustGet("user").(*models.User)
+
+		var request struct {
+			Channels      map[string][]string `json:"channels"`
+			WebhookURL    string              `json:"webhook_url"`
+			WebhookSecret *string             `json:"webhook_secret"`
+			Digest        *bool               `json:"digest"`
+			GrowthAlerts  *bool               `json:"growth_alerts"`
+		}
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+
+		for reminderType, channels := range request.Channels {
+			for _, channel := range channels {
+				if !services.IsKnownChannel(channel) {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown channel " + channel + " for " + reminderType})
+					return
+				}
+				if channel == models.DeliveryChannelWebhook && request.WebhookURL == "" {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "webhook_url is required for the webhook channel"})
+					return
+				}
+			}
+		}
+		if request.WebhookURL != "" {
+			if err := services.CheckWebhookURL(ctx, request.WebhookURL); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": services.ErrWebhookHost.Error()})
+				return
+			}
+		}
+
+		prefs := models.NotificationPreferences{
+			Channels:   request.Channels,
+			WebhookURL: request.WebhookURL,
+		}
+		// Keep the existing secret unless a new one (or "") is sent
+		if request.WebhookSecret != nil {
+			prefs.WebhookSecret = *request.WebhookSecret
+		} else if user.NotificationPreferences != nil {
+			prefs.WebhookSecret = user.NotificationPreferences.WebhookSecret
+		}
+
+		// Quiet hours and vacation are managed by their own endpoints and kept as they are
+		if user.NotificationPreferences != nil {
+			prefs.QuietHours = user.NotificationPreferences.QuietHours
+			prefs.Vacation = user.NotificationPreferences.Vacation
+			prefs.Digest = user.NotificationPreferences.Digest
+			prefs.GrowthAlerts = user.NotificationPreferences.GrowthAlerts
+		}
+		if request.Digest != nil {
+			prefs.Digest = *request.Digest
+		}
+		if request.GrowthAlerts != nil {
+			prefs.GrowthAlerts = *request.GrowthAlerts
+		}
+
+		_, err := userCollection.UpdateOne(ctx, bson.M{"user_id": user.User_id}, bson.M{
 			"$set": bson.M{
-				"fcm_token":  request.FCMToken,
+				"notification_preferences.channels":       prefs.Channels,
+				"notification_preferences.webhook_url":    prefs.WebhookURL,
+				"notification_preferences.webhook_secret": prefs.WebhookSecret,
+				"notification_preferences.digest":         prefs.Digest,
+				"notification_preferences.growth_alerts":  prefs.GrowthAlerts,
+				"updated_at":                              time.Now(),
+			},
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notification preferences"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"message":     "Notification preferences updated successfully",
+			"preferences": prefs,
+		})
+	}
+}
+
+// UpdateLanguage sets the language used for the authenticated user's notifications
+func UpdateLanguage() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		var request struct {
+			Language string `json:"language" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&request); err != nil || !services.IsSupportedLocale(request.Language) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Language must be th or en"})
+			return
+		}
+
+		user := c.MustGet("user").(*models.User)
+		_, err := userCollection.UpdateOne(ctx, bson.M{"user_id": user.User_id}, bson.M{
+			"$set": bson.M{
+				"language":   request.Language,
 				"updated_at": time.Now(),
 			},
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update language"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Language updated successfully", "language": request.Language})
+	}
+}
+
+// UpdateQuietHours sets the daily window in which the authenticated user's notifications are held back.
+// Sending an empty start and end turns quiet hours off.
+func UpdateQuietHours() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		var request models.QuietHours
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
 		}
 
-		_, err := userCollection.UpdateOne(ctx, filter, update)
+		user := c.MustGet("user").(*models.User)
+		update := bson.M{"$set": bson.M{"updated_at": time.Now()}}
+		if request.Start == "" && request.End == "" {
+			update["$unset"] = bson.M{"notification_preferences.quiet_hours": ""}
+		} else {
+			_, startErr := time.Parse("15:04", request.Start)
+			_, endErr := time.Parse("15:04", request.End)
+			if startErr != nil || endErr != nil || request.Start == request.End {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "start and end must be different times in HH:MM format"})
+				return
+			}
+			update["$set"].(bson.M)["notification_preferences.quiet_hours"] = request
+		}
+
+		_, err := userCollection.UpdateOne(ctx, bson.M{"user_id": user.User_id}, update)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update FCM token"})
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update quiet hours"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Quiet hours updated successfully", "quiet_hours": request})
+	}
+}
+
+// SetVacation pauses the authenticated user's reminders between two dates, or redirects them to a delegate
+func SetVacation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		var request struct {
+			StartDate     string `json:"start_date" binding:"required"` // "2006-01-02"
+			EndDate       string `json:"end_date" binding:"required"`   // Last day of the vacation
+			Mode          string `json:"mode" binding:"required"`
+			DelegateEmail string `json:"delegate_email"`
+		}
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "start_date, end_date and mode are required"})
+			return
+		}
+
+		loc, _ := time.LoadLocation("Asia/Bangkok")
+		start, startErr := time.ParseInLocation("2006-01-02", request.StartDate, loc)
+		end, endErr := time.ParseInLocation("2006-01-02", request.EndDate, loc)
+		if startErr != nil || endErr != nil || end.Before(start) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Dates must be YYYY-MM-DD with end_date not before start_date"})
+			return
+		}
+
+		user := c.MustGet("user").(*models.User)
+		vacation := models.Vacation{
+			Start: start,
+			End:   end.AddDate(0, 0, 1),
+			Mode:  request.Mode,
+		}
+
+		switch request.Mode {
+		case models.VacationModePause:
+		case models.VacationModeRedirect:
+			if request.DelegateEmail == "" {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "delegate_email is required to redirect reminders"})
+				return
+			}
+			// Only to someone who accepted to look after the plants; the answer does not tell
+			// whether the email has an account
+			delegate, err := caretakerService.AcceptedByEmail(ctx, user.User_id, request.DelegateEmail)
+			if errors.Is(err, services.ErrCaretakerNotFound) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "delegate_email must be a caretaker who accepted your invitation"})
+				return
+			}
+			if err != nil {
+				log.Printf("[ERROR] Error finding vacation delegate of user %s: %v", user.User_id, err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch delegate user"})
+				return
+			}
+			vacation.DelegateUserID = delegate.CaretakerID
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "mode must be pause or redirect"})
+			return
+		}
+
+		_, err := userCollection.UpdateOne(ctx, bson.M{"user_id": user.User_id}, bson.M{
+			"$set": bson.M{
+				"notification_preferences.vacation": vacation,
+				"updated_at":                        time.Now(),
+			},
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set vacation"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Vacation mode set successfully", "vacation": vacation})
+	}
+}
+
+// EndVacation resumes the authenticated user's reminders
+func EndVacation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		user := c.MustGet("user").(*models.User)
+		_, err := userCollection.UpdateOne(ctx, bson.M{"user_id": user.User_id}, bson.M{
+			"$unset": bson.M{"notification_preferences.vacation": ""},
+			"$set":   bson.M{"updated_at": time.Now()},
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to end vacation"})
 			return
 		}
 
-		c.JSON(http.StatusOK, gin.H{"message": "FCM token updated successfully"})
+		c.JSON(http.StatusOK, gin.H{"message": "Vacation mode ended, reminders resumed"})
 	}
 }
@@ -0,0 +1,86 @@
+package controllers
+
+import (
+	"authentication/models"
+	"authentication/services"
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var wateringService *services.WateringService
+
+// InitializeWateringService initializes the adaptive watering service with the database connection
+func InitializeWateringService(db *mongo.Database) {
+	wateringService = services.NewWateringService(db)
+}
+
+// GetWateringSuggestion suggests a watering interval for one of the user's watering reminders,
+// e.g. "water every 4 days instead of daily"
+func GetWateringSuggestion() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		reminder, ok := findUserReminder(c, ctx)
+		if !ok {
+			return
+		}
+		if reminder.Type != "watering" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Suggestions are only available for watering reminders"})
+			return
+		}
+
+		user := c.MustGet("user").(*models.User)
+		loc, _ := time.LoadLocation("Asia/Bangkok")
+		suggestion, err := wateringService.Suggest(ctx, *reminder, user.Language, reminderClock.Now().In(loc))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute watering suggestion"})
+			return
+		}
+
+		c.JSON(http.StatusOK, suggestion)
+	}
+}
+
+// AcceptWateringSuggestion switches a watering reminder to the suggested interval.
+// The client sends back the interval it showed, so the user gets what they accepted.
+func AcceptWateringSuggestion() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		reminder, ok := findUserReminder(c, ctx)
+		if !ok {
+			return
+		}
+		if reminder.Type != "watering" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Suggestions are only available for watering reminders"})
+			return
+		}
+
+		var req struct {
+			IntervalDays int `json:"intervalDays" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil ||
+			req.IntervalDays < services.MinWateringInterval || req.IntervalDays > services.MaxWateringInterval {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "intervalDays must be between 1 and 14"})
+			return
+		}
+
+		loc, _ := time.LoadLocation("Asia/Bangkok")
+		updated, err := wateringService.Accept(ctx, *reminder, req.IntervalDays, reminderClock.Now().In(loc))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update reminder"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"message":  "Watering schedule updated",
+			"reminder": updated,
+		})
+	}
+}
@@ -12,6 +12,7 @@ require (
 	github.com/joho/godotenv v1.5.1
 	go.mongodb.org/mongo-driver v1.17.3
 	golang.org/x/crypto v0.38.0
+	golang.org/x/image v0.27.0
 	google.golang.org/api v0.235.0
 )
 
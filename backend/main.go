@@ -61,7 +61,35 @@ func main() {
 	controllers.InitPlantCollection()
 	controllers.InitRecommendationCollection()
 	controllers.InitReminderCollection()
+	controllers.InitNotificationDeliveryCollection()
+
+	// The scheduler and the reminder controllers share one clock
+	clock := services.RealClock{}
+	controllers.InitializeClock(clock)
 	controllers.InitializeReminderService(db)
+	controllers.InitializeDeviceService(db)
+	controllers.InitializeTemplateService(db)
+	controllers.InitializeOccurrenceService(db)
+	controllers.InitializeInboxService(db)
+	controllers.InitializeCalendarService(db)
+	controllers.InitializeWateringService(db)
+	controllers.InitializePresetService(db)
+	controllers.InitializeCareEventService(db)
+	controllers.InitializeCaretakerService(db)
+	controllers.InitializeGrowthService(db)
+	controllers.InitializePhotoService(db)
+
+	// Uploaded images are checked, resized and kept in the store chosen by IMAGE_STORE
+	imageStore, err := services.NewImageStoreFromEnv()
+	if err != nil {
+		log.Fatal("Failed to configure the image store:", err)
+	}
+	imageService := services.NewImageService(imageStore)
+	controllers.InitializeImageService(imageService)
+
+	// Plant and reminder changes are pushed to open dashboards
+	eventBus := services.NewEventBus(clock)
+	controllers.InitializeEventBus(eventBus)
 
 	// Import plant data from JSON
 	if err := helpers.ImportPlantData(client); err != nil {
@@ -77,11 +105,108 @@ func main() {
 		log.Fatal("Failed to initialize auth service:", err)
 	}
 
-	notificationService, err := services.NewNotificationService(db)
+	// Device registry for push notifications
+	deviceService := services.NewDeviceService(db)
+	if err := deviceService.EnsureIndexes(context.Background()); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+	if migrated, err := deviceService.MigrateLegacyTokens(context.Background()); err != nil {
+		log.Printf("Warning: Failed to migrate legacy FCM tokens: %v", err)
+	} else if migrated > 0 {
+		log.Printf("Migrated %d legacy FCM token(s) to devices", migrated)
+	}
+
+	// Notification channels: push is required, email is enabled when SMTP is configured
+	fcmNotifier, err := services.NewFCMNotifier("serviceAccountKey.json", deviceService)
 	if err != nil {
-		log.Fatal("Failed to initialize notification service:", err)
+		log.Fatal("Failed to initialize FCM notifier:", err)
+	}
+	notifiers := []services.Notifier{fcmNotifier, services.NewWebhookNotifier()}
+	if emailNotifier := services.NewEmailNotifierFromEnv(); emailNotifier != nil {
+		notifiers = append(notifiers, emailNotifier)
+	} else {
+		log.Println("SMTP_HOST not set, email notifications are disabled")
+	}
+
+	// Notification text templates; old reminders carried client-supplied text that is no longer used
+	templateService := services.NewTemplateService(db)
+	if err := templateService.EnsureDefaults(context.Background()); err != nil {
+		log.Printf("Warning: Failed to seed notification templates: %v", err)
+	}
+	if cleared, err := services.NewReminderService(db).ClearLegacyNotificationData(context.Background()); err != nil {
+		log.Printf("Warning: %v", err)
+	} else if cleared > 0 {
+		log.Printf("Cleared legacy notification data from %d reminder(s)", cleared)
+	}
+
+	// Each firing of a reminder is tracked so it can be acknowledged, snoozed or skipped
+	occurrenceService := services.NewOccurrenceService(db)
+	if err := occurrenceService.EnsureIndexes(context.Background()); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+
+	if err := services.NewCalendarService(db).EnsureIndexes(context.Background()); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+	if err := services.NewCareEventService(db).EnsureIndexes(context.Background()); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+	if err := services.NewCaretakerService(db).EnsureIndexes(context.Background()); err != nil {
+		log.Printf("Warning: %v", err)
 	}
-	scheduler := services.NewScheduler(notificationService)
+
+	// Growth records moved out of the plant documents into their own collection
+	growthService := services.NewGrowthService(db)
+	if err := growthService.EnsureIndexes(context.Background()); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+	if migrated, err := growthService.MigrateEmbeddedRecords(context.Background()); err != nil {
+		log.Printf("Warning: %v", err)
+	} else if migrated > 0 {
+		log.Printf("Moved embedded growth records of %d plant(s) to their own collection", migrated)
+	}
+
+	// Plant galleries; plants created before them get their image as the first photo
+	photoService := services.NewPhotoService(db)
+	if err := photoService.EnsureIndexes(context.Background()); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+	if migrated, err := photoService.MigratePlantImages(context.Background(), imageService.Find); err != nil {
+		log.Printf("Warning: %v", err)
+	} else if migrated > 0 {
+		log.Printf("Added the image of %d plant(s) to their gallery", migrated)
+	}
+
+	// Uploaded images are tracked with their references; unused ones are swept on a schedule
+	assetService := services.NewAssetService(db, imageService)
+	if err := assetService.EnsureIndexes(context.Background()); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+	if migrated, err := assetService.MigrateUntrackedImages(context.Background(), time.Now()); err != nil {
+		log.Printf("Warning: %v", err)
+	} else if migrated > 0 {
+		log.Printf("Started tracking %d image(s) uploaded before assets", migrated)
+	}
+	controllers.InitializeAssetService(assetService)
+
+	// Every notification sent is also kept in the user's in-app inbox
+	inboxService := services.NewInboxService(db)
+	if err := inboxService.EnsureIndexes(context.Background()); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+
+	notificationService := services.NewNotificationService(db, clock, templateService, occurrenceService, inboxService, eventBus, notifiers...)
+	if err := notificationService.EnsureIndexes(context.Background()); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+
+	// Plants whose growth records show a problem are flagged after every change and on a schedule
+	growthAlertService := services.NewGrowthAlertService(db, clock, notificationService)
+	if err := growthAlertService.EnsureIndexes(context.Background()); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+	controllers.InitializeGrowthAlertService(growthAlertService)
+	scheduler := services.NewScheduler(notificationService, growthAlertService, assetService, clock)
 
 	// Initialize diagnosis data
 	if err := diagnosisController.InitializeDiagnosisData(); err != nil {
@@ -99,14 +224,16 @@ func main() {
 	router.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"http://localhost:3000"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS", "HEAD"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Requested-With"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Requested-With", "If-Match", "If-None-Match"},
+		ExposeHeaders:    []string{"Content-Length", "ETag"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
 
-	// Serve static files from the uploads directory
-	router.Static("/uploads", "./uploads")
+	// Images kept on local disk are served by the backend itself
+	if local, ok := imageStore.(*services.LocalImageStore); ok {
+		router.Static(services.LocalImagePath, local.Dir())
+	}
 
 	// Add request logging middleware
 	router.Use(gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
@@ -123,15 +250,10 @@ func main() {
 
 	// Setup routes
 	routes.SetupRoutes(router, authService)
-	routes.PlantRoutes(router, authService)
+	routes.PlantRoutes(router, authService, diagnosisController)
 	routes.SetupRecommendationRoutes(router.Group("/api"), authService)
 	routes.SetupDiagnosisRoutes(router, diagnosisController)
 
-	// Initialize Cloudinary
-	if err := config.InitCloudinary(); err != nil {
-		log.Fatal("Failed to initialize Cloudinary:", err)
-	}
-
 	// Start server
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"authentication/helpers"
+	"authentication/models"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -35,3 +36,17 @@ func AdminOnly() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// AdminUserOnly checks the Firebase-authenticated user set by GinAuthMiddleware
+func AdminUserOnly() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, ok := c.MustGet("user").(*models.User)
+		if !ok || user.Role != "admin" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Asset is an image in the image store. RefCount counts the photos and profiles using it; images
+// left unreferenced past a grace period are deleted by the sweeper.
+type Asset struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
+	UserID    string             `bson:"user_id" json:"user_id"` // Who uploaded it
+	Key       string             `bson:"key" json:"key"`         // Key of the full size in the image store
+	URLs      ImageURLs          `bson:"urls" json:"urls"`
+	RefCount  int                `bson:"ref_count" json:"ref_count"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"` // Last change of RefCount
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Care event types; reminders of other types record events of their own type
+const (
+	CareEventWatering      = "watering"
+	CareEventFertilizing   = "fertilizing"
+	CareEventMisting       = "misting"
+	CareEventRepotting     = "repotting"
+	CareEventPruning       = "pruning"
+	CareEventPestTreatment = "pest_treatment"
+	CareEventOther         = "other"
+)
+
+// CareEventTypes are the types a care event can be logged with by hand
+var CareEventTypes = []string{
+	CareEventWatering,
+	CareEventFertilizing,
+	CareEventMisting,
+	CareEventRepotting,
+	CareEventPruning,
+	CareEventPestTreatment,
+	CareEventOther,
+}
+
+// IsCareEventType reports whether t is one of CareEventTypes
+func IsCareEventType(t string) bool {
+	for _, known := range CareEventTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// CareEvent is a care action done on a plant, e.g. watering it
+type CareEvent struct {
+	ID           primitive.ObjectID  `bson:"_id,omitempty" json:"_id,omitempty"`
+	UserID       string              `bson:"user_id" json:"user_id"`
+	PlantID      primitive.ObjectID  `bson:"plant_id" json:"plant_id"`
+	Type         string              `bson:"type" json:"type"` // e.g., "watering", "fertilizing"
+	Date         time.Time           `bson:"date" json:"date"`
+	Notes        string              `bson:"notes,omitempty" json:"notes,omitempty"`
+	AmountML     float64             `bson:"amount_ml,omitempty" json:"amount_ml,omitempty"`         // Watering and misting
+	Product      string              `bson:"product,omitempty" json:"product,omitempty"`             // Fertilizing and pest treatment
+	NewContainer string              `bson:"new_container,omitempty" json:"new_container,omitempty"` // Repotting
+	PhotoURL     string              `bson:"photo_url,omitempty" json:"photo_url,omitempty"`
+	ReminderID   *primitive.ObjectID `bson:"reminder_id,omitempty" json:"reminder_id,omitempty"`     // Set when created by acknowledging a reminder
+	OccurrenceID *primitive.ObjectID `bson:"occurrence_id,omitempty" json:"occurrence_id,omitempty"` // The acknowledged occurrence
+	CreatedAt    time.Time           `bson:"created_at" json:"created_at"`
+	UpdatedAt    time.Time           `bson:"updated_at,omitempty" json:"updated_at,omitempty"`
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	CaretakerStatusPending  = "pending"  // Invited, not yet accepted
+	CaretakerStatusAccepted = "accepted" // May get the owner's reminders and act on them
+)
+
+// Caretaker links a plant owner to someone who agreed to look after their plants.
+// Only accepted caretakers can be a vacation or escalation delegate.
+type Caretaker struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	OwnerID     string             `bson:"owner_id" json:"owner_id"`
+	Email       string             `bson:"email" json:"email"`                                   // Invited address, lowercase
+	CaretakerID string             `bson:"caretaker_id,omitempty" json:"caretaker_id,omitempty"` // Set when accepted
+	Status      string             `bson:"status" json:"status"`
+	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
+	AcceptedAt  *time.Time         `bson:"accepted_at,omitempty" json:"accepted_at,omitempty"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Device is one app installation that can receive push notifications
+type Device struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	UserID     string             `bson:"user_id" json:"user_id"`
+	Token      string             `bson:"token" json:"token"`       // FCM registration token, unique
+	Platform   string             `bson:"platform" json:"platform"` // e.g., "android", "ios", "web"
+	AppVersion string             `bson:"app_version,omitempty" json:"app_version,omitempty"`
+	LastSeenAt time.Time          `bson:"last_seen_at" json:"last_seen_at"`
+	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+}
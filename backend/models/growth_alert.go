@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Growth alert types
+const (
+	GrowthAlertHeightDrop    = "height_drop"    // The latest record is markedly shorter than the one before
+	GrowthAlertStalled       = "stalled"        // No growth over several weeks
+	GrowthAlertDecliningMood = "declining_mood" // A run of poor moods, or moods getting worse record by record
+)
+
+// GrowthAlert flags a plant whose growth history looks like it has a problem.
+// A plant has at most one open alert of each type; it is resolved once the history no longer shows it.
+type GrowthAlert struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
+	UserID       string             `bson:"user_id" json:"user_id"`
+	PlantID      primitive.ObjectID `bson:"plant_id" json:"plant_id"`
+	PlantName    string             `bson:"plant_name" json:"plant_name"`
+	Type         string             `bson:"type" json:"type"`
+	Open         bool               `bson:"open" json:"open"`       // False once resolved
+	Change       float64            `bson:"change" json:"change"`   // Height change in cm over the anomaly, negative for a drop
+	Since        time.Time          `bson:"since" json:"since"`     // Date of the first record that shows the anomaly
+	Records      int                `bson:"records" json:"records"` // Number of records that show it
+	ProblemPart  string             `bson:"problem_part" json:"problem_part"`
+	Symptoms     []string           `bson:"symptoms" json:"symptoms"` // Diagnosis symptoms to start from, from plant_problem_data.json
+	DiagnosisURL string             `bson:"diagnosis_url" json:"diagnosis_url"`
+	DetectedAt   time.Time          `bson:"detected_at" json:"detected_at"`
+	UpdatedAt    time.Time          `bson:"updated_at" json:"updated_at"`
+	DismissedAt  *time.Time         `bson:"dismissed_at,omitempty" json:"dismissed_at,omitempty"` // Hidden from the attention list until resolved
+	ResolvedAt   *time.Time         `bson:"resolved_at,omitempty" json:"resolved_at,omitempty"`
+	NotifiedAt   *time.Time         `bson:"notified_at,omitempty" json:"notified_at,omitempty"`
+}
@@ -0,0 +1,13 @@
+package models
+
+// ImageURLs are the standard sizes an uploaded image is stored in
+type ImageURLs struct {
+	Thumbnail string `bson:"thumbnail" json:"thumbnail"`
+	Medium    string `bson:"medium" json:"medium"`
+	Full      string `bson:"full" json:"full"`
+}
+
+// SingleImageURLs stands in for the sizes of an image stored before they were made: every size is the image itself
+func SingleImageURLs(url string) ImageURLs {
+	return ImageURLs{Thumbnail: url, Medium: url, Full: url}
+}
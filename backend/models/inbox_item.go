@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// InboxItem is a notification kept in the user's in-app inbox
+type InboxItem struct {
+	ID        primitive.ObjectID  `bson:"_id,omitempty" json:"id,omitempty"`
+	UserID    string              `bson:"user_id" json:"userId"`
+	Type      string              `bson:"type" json:"type"` // Reminder type, or "digest"
+	PlantID   *primitive.ObjectID `bson:"plant_id,omitempty" json:"plantId,omitempty"`
+	Title     string              `bson:"title" json:"title"`
+	Body      string              `bson:"body" json:"body"`
+	Data      map[string]string   `bson:"data,omitempty" json:"data,omitempty"`
+	Read      bool                `bson:"read" json:"read"`
+	ReadAt    *time.Time          `bson:"read_at,omitempty" json:"readAt,omitempty"`
+	CreatedAt time.Time           `bson:"created_at" json:"createdAt"` // Items expire InboxRetention after this
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Delivery statuses
+const (
+	DeliveryStatusPending    = "pending"     // created, first attempt not finished yet
+	DeliveryStatusSent       = "sent"        // delivered to the provider
+	DeliveryStatusRetrying   = "retrying"    // transient failure, waiting for next_attempt_at
+	DeliveryStatusDeadLetter = "dead_letter" // permanent failure or out of attempts
+)
+
+// Delivery channels
+const (
+	DeliveryChannelFCM     = "fcm"
+	DeliveryChannelEmail   = "email"
+	DeliveryChannelWebhook = "webhook"
+)
+
+type NotificationPayload struct {
+	Title string            `bson:"title" json:"title"`
+	Body  string            `bson:"body" json:"body"`
+	Data  map[string]string `bson:"data,omitempty" json:"data,omitempty"`
+}
+
+// NotificationDelivery records every attempt to deliver one notification
+type NotificationDelivery struct {
+	ID            primitive.ObjectID   `bson:"_id,omitempty" json:"id,omitempty"`
+	UserID        string               `bson:"user_id" json:"userId"`
+	ReminderID    primitive.ObjectID   `bson:"reminder_id,omitempty" json:"reminderId,omitempty"`
+	ReminderIDs   []primitive.ObjectID `bson:"reminder_ids,omitempty" json:"reminderIds,omitempty"` // All reminders in a digest
+	Channel       string               `bson:"channel" json:"channel"`
+	Payload       NotificationPayload  `bson:"payload" json:"payload"`
+	Status        string               `bson:"status" json:"status"`
+	Error         string               `bson:"error,omitempty" json:"error,omitempty"`
+	Attempts      int                  `bson:"attempts" json:"attempts"`
+	NextAttemptAt *time.Time           `bson:"next_attempt_at,omitempty" json:"nextAttemptAt,omitempty"` // Only set while retrying
+	LastAttemptAt *time.Time           `bson:"last_attempt_at,omitempty" json:"lastAttemptAt,omitempty"`
+	SentAt        *time.Time           `bson:"sent_at,omitempty" json:"sentAt,omitempty"`
+	CreatedAt     time.Time            `bson:"created_at" json:"createdAt"`
+	UpdatedAt     time.Time            `bson:"updated_at" json:"updatedAt"`
+}
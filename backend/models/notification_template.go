@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Supported notification locales
+const (
+	LocaleThai    = "th"
+	LocaleEnglish = "en"
+)
+
+// NotificationTemplate is the text of a notification for one reminder type and locale.
+// Title and Body are Go text/template strings, e.g. "ถึงเวลารดน้ำ {{.PlantName}} แล้ว!"
+type NotificationTemplate struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	Type      string             `bson:"type" json:"type"`     // Reminder type, "default" for types without their own template
+	Locale    string             `bson:"locale" json:"locale"` // "th" or "en"
+	Title     string             `bson:"title" json:"title"`
+	Body      string             `bson:"body" json:"body"`
+	UpdatedAt time.Time          `bson:"updated_at" json:"updatedAt"`
+	UpdatedBy string             `bson:"updated_by,omitempty" json:"updatedBy,omitempty"`
+}
@@ -8,6 +8,8 @@ import (
 
 type GrowthRecord struct {
 	ID               primitive.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
+	PlantID          primitive.ObjectID `bson:"plant_id,omitempty" json:"plant_id,omitempty"`
+	UserID           string             `bson:"user_id,omitempty" json:"user_id,omitempty"`
 	Height           float64            `bson:"height" json:"height"`
 	HeightDifference float64            `bson:"height_difference" json:"height_difference"`
 	Mood             string             `bson:"mood" json:"mood"`
@@ -16,18 +18,38 @@ type GrowthRecord struct {
 	CreatedAt        time.Time          `bson:"created_at" json:"created_at"`
 }
 
+// GrowthSummary is kept on the plant so the dashboard doesn't need the growth history
+type GrowthSummary struct {
+	RecordCount  int64              `bson:"record_count" json:"record_count"`
+	LastRecordID primitive.ObjectID `bson:"last_record_id,omitempty" json:"last_record_id,omitempty"`
+	LastHeight   float64            `bson:"last_height" json:"last_height"`
+	LastMood     string             `bson:"last_mood,omitempty" json:"last_mood,omitempty"`
+	LastDate     time.Time          `bson:"last_date,omitempty" json:"last_date,omitempty"`
+}
+
 type Plant struct {
-	ID            primitive.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
-	UserID        string             `bson:"user_id" json:"user_id"`
-	Name          string             `bson:"name" json:"name"`
-	Type          string             `bson:"type" json:"type"`
-	Container     string             `bson:"container" json:"container"`
-	PlantHeight   float64            `bson:"plant_height" json:"plant_height"`
-	PlantDate     time.Time          `bson:"plant_date" json:"plant_date"`
-	ImageURL      string             `bson:"image_url" json:"image_url"`
-	CreatedAt     time.Time          `bson:"created_at" json:"created_at"`
-	UpdatedAt     time.Time          `bson:"updated_at" json:"updated_at"`
-	GrowthRecords []GrowthRecord     `bson:"growth_records,omitempty" json:"growth_records,omitempty"`
+	ID            primitive.ObjectID  `bson:"_id,omitempty" json:"_id,omitempty"`
+	UserID        string              `bson:"user_id" json:"user_id"`
+	Name          string              `bson:"name" json:"name"`
+	Type          string              `bson:"type" json:"type"`
+	Container     string              `bson:"container" json:"container"`
+	PlantHeight   float64             `bson:"plant_height" json:"plant_height"`
+	PlantDate     time.Time           `bson:"plant_date" json:"plant_date"`
+	ImageURL      string              `bson:"image_url" json:"image_url"`                       // URL of the cover photo
+	ImageURLs     *ImageURLs          `bson:"image_urls,omitempty" json:"image_urls,omitempty"` // Sizes of the cover photo
+	CoverPhotoID  *primitive.ObjectID `bson:"cover_photo_id,omitempty" json:"cover_photo_id,omitempty"`
+	CatalogID     int                 `bson:"catalog_id,omitempty" json:"catalog_id,omitempty"` // id in plant_recommendations, used for care defaults
+	CreatedAt     time.Time           `bson:"created_at" json:"created_at"`
+	UpdatedAt     time.Time           `bson:"updated_at" json:"updated_at"`
+	Version       int64               `bson:"version" json:"version"` // Incremented by every change, sent as the ETag
+	GrowthSummary *GrowthSummary      `bson:"growth_summary,omitempty" json:"growth_summary,omitempty"`
+	// Growth records live in the growth_records collection; only the most recent ones are filled in
+	// when a single plant is returned. Older documents still embed theirs until they are migrated.
+	GrowthRecords []GrowthRecord `bson:"growth_records,omitempty" json:"growth_records,omitempty"`
+	// GrowthBaseHeight is the height before the first growth record, the first height difference is taken from it
+	GrowthBaseHeight *float64 `bson:"growth_base_height,omitempty" json:"-"`
+	// GrowthVersion is bumped by every growth record change so concurrent recomputes can tell they are stale
+	GrowthVersion int64 `bson:"growth_version,omitempty" json:"-"`
 
 	// Legacy fields (will be removed after migration)
 	Plantheight float64   `bson:"plantheight,omitempty" json:"plantheight,omitempty"`
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PlantDiagnosis is a diagnosis the user ran for one of their plants
+type PlantDiagnosis struct {
+	ID                primitive.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
+	UserID            string             `bson:"user_id" json:"user_id"`
+	PlantID           primitive.ObjectID `bson:"plant_id" json:"plant_id"`
+	ProblemPart       string             `bson:"problem_part" json:"problem_part"`
+	Symptoms          []string           `bson:"symptoms" json:"symptoms"`
+	WateringFrequency string             `bson:"watering_frequency,omitempty" json:"watering_frequency,omitempty"`
+	ProblemID         int                `bson:"problem_id,omitempty" json:"problem_id,omitempty"` // Matched entry of plant_problems, 0 when none matched
+	Diagnosis         string             `bson:"diagnosis,omitempty" json:"diagnosis,omitempty"`
+	Solution          string             `bson:"solution,omitempty" json:"solution,omitempty"`
+	Severity          string             `bson:"severity,omitempty" json:"severity,omitempty"`
+	CreatedAt         time.Time          `bson:"created_at" json:"created_at"`
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PlantPhoto is one picture in a plant's gallery. It may show the plant at a growth record or a care event.
+type PlantPhoto struct {
+	ID             primitive.ObjectID  `bson:"_id,omitempty" json:"_id,omitempty"`
+	UserID         string              `bson:"user_id" json:"user_id"`
+	PlantID        primitive.ObjectID  `bson:"plant_id" json:"plant_id"`
+	URL            string              `bson:"url" json:"url"`
+	ImageURLs      *ImageURLs          `bson:"image_urls,omitempty" json:"image_urls,omitempty"` // Standard sizes, missing for photos uploaded before them
+	ImageKey       string              `bson:"image_key,omitempty" json:"-"`                     // Key in the image store, used to delete the image
+	Caption        string              `bson:"caption,omitempty" json:"caption,omitempty"`
+	TakenAt        time.Time           `bson:"taken_at" json:"taken_at"` // Defaults to the upload time
+	GrowthRecordID *primitive.ObjectID `bson:"growth_record_id,omitempty" json:"growth_record_id,omitempty"`
+	CareEventID    *primitive.ObjectID `bson:"care_event_id,omitempty" json:"care_event_id,omitempty"`
+	CreatedAt      time.Time           `bson:"created_at" json:"created_at"`
+	UpdatedAt      time.Time           `bson:"updated_at" json:"updated_at"`
+}
+
+// Sizes returns the standard sizes of the photo, falling back to the full image for photos without them
+func (p PlantPhoto) Sizes() ImageURLs {
+	if p.ImageURLs != nil {
+		return *p.ImageURLs
+	}
+	return SingleImageURLs(p.URL)
+}
@@ -10,13 +10,34 @@ type Reminder struct {
 	ID               primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
 	UserID           string             `bson:"user_id" json:"userId"`
 	PlantID          primitive.ObjectID `bson:"plant_id" json:"plantId"`
-	Type             string             `bson:"type" json:"type"`                                 // e.g., "watering", "fertilizing"
-	Frequency        string             `bson:"frequency" json:"frequency"`                       // e.g., "once", "daily", "weekly"
-	ScheduledTime    time.Time          `bson:"scheduled_time" json:"scheduledTime"`              // For "once" or first occurrence
-	DayOfWeek        string             `bson:"day_of_week,omitempty" json:"dayOfWeek,omitempty"` // For "weekly" (e.g., "Monday")
-	TimeOfDay        string             `bson:"time_of_day,omitempty" json:"timeOfDay,omitempty"` // For "daily" or "weekly" (e.g., "08:00")
+	Type             string             `bson:"type" json:"type"`                                      // e.g., "watering", "fertilizing"
+	Frequency        string             `bson:"frequency" json:"frequency"`                            // e.g., "once", "daily", "weekly", "interval"
+	ScheduledTime    time.Time          `bson:"scheduled_time" json:"scheduledTime"`                   // For "once" or first occurrence, the day "interval" counts from
+	IntervalDays     int                `bson:"interval_days,omitempty" json:"intervalDays,omitempty"` // For "interval", e.g. 4 for every 4 days
+	Adaptive         bool               `bson:"adaptive,omitempty" json:"adaptive,omitempty"`          // Watering interval is suggested from care history
+	DayOfWeek        string             `bson:"day_of_week,omitempty" json:"dayOfWeek,omitempty"`      // For "weekly" (e.g., "Monday")
+	TimeOfDay        string             `bson:"time_of_day,omitempty" json:"timeOfDay,omitempty"`      // For "daily", "weekly" or "interval" (e.g., "08:00")
 	CreatedAt        time.Time          `bson:"created_at" json:"createdAt"`
 	UpdatedAt        time.Time          `bson:"updated_at" json:"updatedAt"`
+	Version          int64              `bson:"version" json:"version"`                                        // Incremented by every change, sent as the ETag
 	IsActive         bool               `bson:"is_active" json:"isActive"`                                     // To enable/disable reminder
-	NotificationData string             `bson:"notification_data,omitempty" json:"notificationData,omitempty"` // JSON string containing notification data
+	Escalation       []EscalationStep   `bson:"escalation,omitempty" json:"escalation,omitempty"`              // Steps taken while an occurrence stays unacknowledged
+	NotificationData string             `bson:"notification_data,omitempty" json:"notificationData,omitempty"` // Deprecated: ignored, notifications are rendered from server-side templates
+}
+
+// Escalation actions
+const (
+	EscalationActionResend   = "resend"   // send the reminder again on the usual channels
+	EscalationActionChannel  = "channel"  // send it on another channel, e.g. email
+	EscalationActionDelegate = "delegate" // send it to a co-caretaker
+)
+
+// EscalationStep is one step of a reminder's escalation, taken AfterHours after the
+// occurrence was last sent or escalated
+type EscalationStep struct {
+	AfterHours     int    `bson:"after_hours" json:"afterHours"`
+	Action         string `bson:"action" json:"action"`
+	Channel        string `bson:"channel,omitempty" json:"channel,omitempty"`                 // For "channel"
+	DelegateUserID string `bson:"delegate_user_id,omitempty" json:"delegateUserId,omitempty"` // For "delegate"
+	DelegateEmail  string `bson:"-" json:"delegateEmail,omitempty"`                           // Set by the client, resolved to DelegateUserID
 }
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Occurrence statuses
+const (
+	OccurrenceStatusPending      = "pending"      // sent, waiting for the user
+	OccurrenceStatusAcknowledged = "acknowledged" // the user did the task
+	OccurrenceStatusSnoozed      = "snoozed"      // sent again at snooze_until
+	OccurrenceStatusSkipped      = "skipped"      // the user chose not to do it this time
+	OccurrenceStatusDeferred     = "deferred"     // held back by quiet hours until deferred_until
+)
+
+// ReminderOccurrence is one firing of a reminder, e.g. Monday 08:00 watering
+type ReminderOccurrence struct {
+	ID              primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	ReminderID      primitive.ObjectID `bson:"reminder_id" json:"reminderId"`
+	UserID          string             `bson:"user_id" json:"userId"`
+	PlantID         primitive.ObjectID `bson:"plant_id" json:"plantId"`
+	Type            string             `bson:"type" json:"type"`
+	ScheduledFor    time.Time          `bson:"scheduled_for" json:"scheduledFor"` // The slot it fired for, unique per reminder
+	SentAt          time.Time          `bson:"sent_at" json:"sentAt"`             // Last time it was sent (first send or after snooze)
+	Status          string             `bson:"status" json:"status"`
+	AcknowledgedAt  *time.Time         `bson:"acknowledged_at,omitempty" json:"acknowledgedAt,omitempty"`
+	SnoozeUntil     *time.Time         `bson:"snooze_until,omitempty" json:"snoozeUntil,omitempty"`
+	SnoozeCount     int                `bson:"snooze_count" json:"snoozeCount"`
+	ResentAt        *time.Time         `bson:"resent_at,omitempty" json:"resentAt,omitempty"` // Set once the unacknowledged resend went out
+	DeferredUntil   *time.Time         `bson:"deferred_until,omitempty" json:"deferredUntil,omitempty"`
+	DelegateUserID  string             `bson:"delegate_user_id,omitempty" json:"delegateUserId,omitempty"`  // Recipient while the owner is on vacation
+	Escalating      bool               `bson:"escalating,omitempty" json:"escalating,omitempty"`            // Escalation steps are still to be taken
+	EscalationLevel int                `bson:"escalation_level,omitempty" json:"escalationLevel,omitempty"` // Steps taken so far
+	EscalationLog   []EscalationEntry  `bson:"escalation_log,omitempty" json:"escalationLog,omitempty"`
+	CaretakerIDs    []string           `bson:"caretaker_ids,omitempty" json:"caretakerIds,omitempty"` // Delegates escalated to, who may also act on it
+	CreatedAt       time.Time          `bson:"created_at" json:"createdAt"`
+	UpdatedAt       time.Time          `bson:"updated_at" json:"updatedAt"`
+}
+
+// EscalationEntry logs one escalation step taken for an occurrence
+type EscalationEntry struct {
+	Step            int       `bson:"step" json:"step"` // 1-based index into the reminder's escalation
+	Action          string    `bson:"action" json:"action"`
+	Channels        []string  `bson:"channels,omitempty" json:"channels,omitempty"`
+	RecipientUserID string    `bson:"recipient_user_id" json:"recipientUserId"`
+	At              time.Time `bson:"at" json:"at"`
+}
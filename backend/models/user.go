@@ -13,7 +13,7 @@ type User struct {
 	Name         *string            `bson:"name" json:"name"`
 	Password     *string            `bson:"password" json:"-"`
 	Role         string             `bson:"role" json:"role"`
-	FCMToken     *string            `bson:"fcm_token,omitempty" json:"fcm_token,omitempty"`
+	FCMToken     *string            `bson:"fcm_token,omitempty" json:"fcm_token,omitempty"` // Legacy: moved to the devices collection on startup
 	Token        *string            `bson:"token,omitempty" json:"token,omitempty"`
 	RefreshToken *string            `bson:"refresh_token,omitempty" json:"refresh_token,omitempty"`
 	CreatedAt    time.Time          `bson:"created_at" json:"created_at"`
@@ -24,6 +24,123 @@ type User struct {
 	IsVerified      bool      `bson:"is_verified" json:"is_verified"`
 	FirebaseUID     string    `bson:"firebase_uid,omitempty" json:"firebase_uid,omitempty"`
 	ProfileImageURL *string   `bson:"profile_image_url,omitempty" json:"profile_image_url,omitempty"`
+	Language        string    `bson:"language,omitempty" json:"language,omitempty"` // "th" or "en", used for notifications
+	CalendarToken   string    `bson:"calendar_token,omitempty" json:"-"`            // Secret of the iCalendar feed URL, empty when off
+	// การตั้งค่าการแจ้งเตือน
+	NotificationPreferences *NotificationPreferences `bson:"notification_preferences,omitempty" json:"notification_preferences,omitempty"`
+}
+
+// NotificationPreferences chooses how a user is notified
+type NotificationPreferences struct {
+	// Channels per reminder type, e.g. {"watering": ["email"]}; "default" covers types not listed
+	Channels      map[string][]string `bson:"channels,omitempty" json:"channels,omitempty"`
+	WebhookURL    string              `bson:"webhook_url,omitempty" json:"webhook_url,omitempty"`
+	WebhookSecret string              `bson:"webhook_secret,omitempty" json:"-"`
+	QuietHours    *QuietHours         `bson:"quiet_hours,omitempty" json:"quiet_hours,omitempty"`
+	Vacation      *Vacation           `bson:"vacation,omitempty" json:"vacation,omitempty"`
+	Digest        bool                `bson:"digest,omitempty" json:"digest"`               // Merge reminders due together into one notification
+	GrowthAlerts  bool                `bson:"growth_alerts,omitempty" json:"growth_alerts"` // Notify when a plant's growth history is flagged
+}
+
+// DigestEnabled reports whether reminders due together are merged into one notification
+func (p *NotificationPreferences) DigestEnabled() bool {
+	return p != nil && p.Digest
+}
+
+// GrowthAlertsEnabled reports whether the user is notified of new growth alerts
+func (p *NotificationPreferences) GrowthAlertsEnabled() bool {
+	return p != nil && p.GrowthAlerts
+}
+
+// Vacation modes
+const (
+	VacationModePause    = "pause"    // no reminders are sent while away
+	VacationModeRedirect = "redirect" // reminders go to the delegate user's devices
+)
+
+// QuietHours is a daily window, e.g. 22:00 to 07:00, in which notifications are held back
+type QuietHours struct {
+	Start string `bson:"start" json:"start"` // "HH:MM"
+	End   string `bson:"end" json:"end"`     // "HH:MM", before Start for a window over midnight
+}
+
+// Vacation pauses or redirects all of a user's reminders between Start and End
+type Vacation struct {
+	Start          time.Time `bson:"start" json:"start"`
+	End            time.Time `bson:"end" json:"end"` // Exclusive
+	Mode           string    `bson:"mode" json:"mode"`
+	DelegateUserID string    `bson:"delegate_user_id,omitempty" json:"delegate_user_id,omitempty"`
+}
+
+// ActiveAt reports whether t falls within the vacation
+func (v *Vacation) ActiveAt(t time.Time) bool {
+	return v != nil && !t.Before(v.Start) && t.Before(v.End)
+}
+
+// DeferUntil returns the end of the quiet window when t falls inside it.
+// Times are read in t's location.
+func (q *QuietHours) DeferUntil(t time.Time) (time.Time, bool) {
+	if q == nil {
+		return time.Time{}, false
+	}
+	start, err1 := time.Parse("15:04", q.Start)
+	end, err2 := time.Parse("15:04", q.End)
+	if err1 != nil || err2 != nil {
+		return time.Time{}, false
+	}
+
+	startMin := start.Hour()*60 + start.Minute()
+	endMin := end.Hour()*60 + end.Minute()
+	nowMin := t.Hour()*60 + t.Minute()
+	windowEnd := time.Date(t.Year(), t.Month(), t.Day(), end.Hour(), end.Minute(), 0, 0, t.Location())
+
+	switch {
+	case startMin == endMin:
+		return time.Time{}, false
+	case startMin < endMin:
+		// Same-day window, e.g. 13:00-15:00
+		if nowMin >= startMin && nowMin < endMin {
+			return windowEnd, true
+		}
+	default:
+		// Window over midnight, e.g. 22:00-07:00
+		if nowMin >= startMin {
+			return windowEnd.AddDate(0, 0, 1), true
+		}
+		if nowMin < endMin {
+			return windowEnd, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// ActiveVacation returns the vacation in effect at t, if any
+func (p *NotificationPreferences) ActiveVacation(t time.Time) *Vacation {
+	if p != nil && p.Vacation.ActiveAt(t) {
+		return p.Vacation
+	}
+	return nil
+}
+
+// QuietUntil returns when notifications may be sent again if t is in quiet hours
+func (p *NotificationPreferences) QuietUntil(t time.Time) (time.Time, bool) {
+	if p == nil {
+		return time.Time{}, false
+	}
+	return p.QuietHours.DeferUntil(t)
+}
+
+// ChannelsFor returns the channels to use for a reminder type, push only when nothing is configured
+func (p *NotificationPreferences) ChannelsFor(reminderType string) []string {
+	if p != nil {
+		if channels, ok := p.Channels[reminderType]; ok {
+			return channels
+		}
+		if channels, ok := p.Channels["default"]; ok {
+			return channels
+		}
+	}
+	return []string{DeliveryChannelFCM}
 }
 
 // UserResponse สำหรับส่งข้อมูลกลับไปให้ frontend
@@ -0,0 +1,85 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestQuietHoursDeferUntil(t *testing.T) {
+	loc, err := time.LoadLocation("Asia/Bangkok")
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(day, hour, min int) time.Time {
+		return time.Date(2026, 3, day, hour, min, 0, 0, loc)
+	}
+	overnight := &QuietHours{Start: "22:00", End: "07:00"}
+	afternoon := &QuietHours{Start: "13:00", End: "15:00"}
+
+	tests := []struct {
+		name      string
+		quiet     *QuietHours
+		t         time.Time
+		wantUntil time.Time
+		wantQuiet bool
+	}{
+		{"before an overnight window", overnight, at(4, 21, 59), time.Time{}, false},
+		{"first minute of an overnight window", overnight, at(4, 22, 0), at(5, 7, 0), true},
+		{"overnight window before midnight", overnight, at(4, 23, 30), at(5, 7, 0), true},
+		{"overnight window at midnight", overnight, at(5, 0, 0), at(5, 7, 0), true},
+		{"overnight window after midnight", overnight, at(5, 6, 59), at(5, 7, 0), true},
+		{"end of an overnight window", overnight, at(5, 7, 0), time.Time{}, false},
+		{"overnight window over the end of the month", overnight, time.Date(2026, 3, 31, 23, 0, 0, 0, loc), time.Date(2026, 4, 1, 7, 0, 0, 0, loc), true},
+		{"before a same-day window", afternoon, at(4, 12, 59), time.Time{}, false},
+		{"first minute of a same-day window", afternoon, at(4, 13, 0), at(4, 15, 0), true},
+		{"last minute of a same-day window", afternoon, at(4, 14, 59), at(4, 15, 0), true},
+		{"end of a same-day window", afternoon, at(4, 15, 0), time.Time{}, false},
+		{"empty window", &QuietHours{Start: "08:00", End: "08:00"}, at(4, 8, 0), time.Time{}, false},
+		{"invalid window", &QuietHours{Start: "late", End: "07:00"}, at(4, 23, 0), time.Time{}, false},
+		{"no quiet hours", nil, at(4, 23, 0), time.Time{}, false},
+	}
+	for _, tt := range tests {
+		until, quiet := tt.quiet.DeferUntil(tt.t)
+		if quiet != tt.wantQuiet || !until.Equal(tt.wantUntil) {
+			t.Errorf("%s: DeferUntil(%s) = %v, %v; want %v, %v", tt.name, tt.t.Format("Jan 2 15:04"), until, quiet, tt.wantUntil, tt.wantQuiet)
+		}
+	}
+}
+
+func TestActiveVacation(t *testing.T) {
+	loc, err := time.LoadLocation("Asia/Bangkok")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Away from March 4 to March 6 inclusive, stored with the exclusive end the API sets
+	vacation := &Vacation{
+		Start: time.Date(2026, 3, 4, 0, 0, 0, 0, loc),
+		End:   time.Date(2026, 3, 7, 0, 0, 0, 0, loc),
+		Mode:  VacationModePause,
+	}
+	prefs := &NotificationPreferences{Vacation: vacation}
+
+	tests := []struct {
+		name string
+		t    time.Time
+		want bool
+	}{
+		{"day before", time.Date(2026, 3, 3, 23, 59, 0, 0, loc), false},
+		{"first minute", time.Date(2026, 3, 4, 0, 0, 0, 0, loc), true},
+		{"vacation ending today", time.Date(2026, 3, 6, 12, 0, 0, 0, loc), true},
+		{"last minute of a vacation ending today", time.Date(2026, 3, 6, 23, 59, 59, 0, loc), true},
+		{"day after", time.Date(2026, 3, 7, 0, 0, 0, 0, loc), false},
+		{"last day, read in UTC", time.Date(2026, 3, 6, 16, 30, 0, 0, time.UTC), true},
+		{"day after, read in UTC", time.Date(2026, 3, 6, 17, 0, 0, 0, time.UTC), false},
+	}
+	for _, tt := range tests {
+		if got := prefs.ActiveVacation(tt.t) != nil; got != tt.want {
+			t.Errorf("%s: active = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	var none *NotificationPreferences
+	if none.ActiveVacation(vacation.Start) != nil || (&NotificationPreferences{}).ActiveVacation(vacation.Start) != nil {
+		t.Error("vacation found without one set")
+	}
+}
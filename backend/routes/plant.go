@@ -10,7 +10,7 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func PlantRoutes(router *gin.Engine, authService *services.AuthService) {
+func PlantRoutes(router *gin.Engine, authService *services.AuthService, diagnosisController *controllers.DiagnosisController) {
 	// Create auth middleware
 	authMiddleware, err := middleware.NewAuthMiddleware(authService.GetDB())
 	if err != nil {
@@ -41,13 +41,26 @@ func PlantRoutes(router *gin.Engine, authService *services.AuthService) {
 	// More specific routes first
 	plantGroup.POST("/upload", controllers.UploadPlantImage())
 	plantGroup.POST("/new", controllers.CreatePlant())
+	plantGroup.POST("/attention/:alert_id/dismiss", controllers.DismissGrowthAlert())
 	plantGroup.PUT("/edit/:plant_id", controllers.UpdatePlant())
 	plantGroup.POST("/:plant_id/growth", controllers.AddGrowthRecord())
+	plantGroup.GET("/:plant_id/growth", controllers.GetGrowthHistory())
+	plantGroup.GET("/:plant_id/growth/analytics", controllers.GetPlantGrowthAnalytics())
+	plantGroup.POST("/:plant_id/diagnosis", diagnosisController.DiagnosePlant())
+	plantGroup.POST("/:plant_id/care-events", controllers.CreateCareEvent())
+	plantGroup.GET("/:plant_id/care-events", controllers.GetPlantCareEvents())
+	plantGroup.POST("/:plant_id/photos", controllers.UploadPlantPhoto())
+	plantGroup.GET("/:plant_id/photos", controllers.GetPhotoTimeline())
+	plantGroup.PUT("/:plant_id/photos/:photo_id", controllers.UpdatePlantPhoto())
+	plantGroup.PUT("/:plant_id/photos/:photo_id/cover", controllers.SetCoverPhoto())
+	plantGroup.DELETE("/:plant_id/photos/:photo_id", controllers.DeletePlantPhoto())
 	plantGroup.PUT("/:plant_id/growth/:record_id", controllers.UpdateGrowthRecord())
 	plantGroup.DELETE("/:plant_id/growth/:record_id", controllers.DeleteGrowthRecord())
 
 	// More general routes last
 	plantGroup.GET("/dashboard", controllers.GetUserPlants())
+	plantGroup.GET("/analytics", controllers.GetUserGrowthAnalytics())
+	plantGroup.GET("/attention", controllers.GetGrowthAlerts())
 	plantGroup.GET("/:plant_id", controllers.GetPlant())
 	plantGroup.DELETE("/:plant_id", controllers.DeletePlant())
 }
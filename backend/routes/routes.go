@@ -20,6 +20,7 @@ func SetupRoutes(router *gin.Engine, authService *services.AuthService) {
 	if err != nil {
 		panic(err)
 	}
+	deviceService := services.NewDeviceService(authService.GetDB())
 
 	// Public routes
 	auth := router.Group("/auth")
@@ -145,19 +146,23 @@ func SetupRoutes(router *gin.Engine, authService *services.AuthService) {
 						c.Set("user", user)
 
 						var input struct {
-							FCMToken string `json:"fcmToken" binding:"required"`
+							FCMToken   string `json:"fcmToken" binding:"required"`
+							Platform   string `json:"platform"`
+							AppVersion string `json:"appVersion"`
 						}
 						if err := c.ShouldBindJSON(&input); err != nil {
 							c.JSON(400, gin.H{"error": err.Error()})
 							return
 						}
 
-						if err := authService.UpdateFCMToken(user.User_id, input.FCMToken); err != nil {
+						// Register the token as one more device instead of replacing the previous one
+						device, err := deviceService.RegisterDevice(r.Context(), user.User_id, input.FCMToken, input.Platform, input.AppVersion)
+						if err != nil {
 							c.JSON(500, gin.H{"error": "Failed to update FCM token"})
 							return
 						}
 
-						c.JSON(200, gin.H{"message": "FCM token updated successfully"})
+						c.JSON(200, gin.H{"message": "FCM token updated successfully", "device": device})
 					} else {
 						c.JSON(401, gin.H{"error": "User not authenticated"})
 					}
@@ -254,6 +259,12 @@ func SetupRoutes(router *gin.Engine, authService *services.AuthService) {
 		}
 	}
 
+	// iCalendar feed for calendar clients, authenticated by the secret token in the URL
+	router.GET("/calendar/:token", controllers.ServeCalendarFeed())
+
+	// Event stream; EventSource cannot send headers, so it is opened with a ticket from POST /api/events/ticket
+	router.GET("/api/events", controllers.AuthenticateStream(authMiddleware.GinAuthMiddleware()), controllers.StreamEvents())
+
 	// Protected routes
 	api := router.Group("/api")
 	api.Use(authMiddleware.GinAuthMiddleware())
@@ -274,6 +285,8 @@ func SetupRoutes(router *gin.Engine, authService *services.AuthService) {
 			c.JSON(200, gin.H{"message": "Account deleted successfully"})
 		})
 
+		api.POST("/events/ticket", controllers.CreateStreamTicket())
+
 		users := api.Group("/users")
 		{
 			users.POST("/signup", controllers.Signup())
@@ -288,6 +301,18 @@ func SetupRoutes(router *gin.Engine, authService *services.AuthService) {
 				protectedUsers.POST("/upload-profile-image", controllers.UploadProfileImage())
 				// Route to save FCM token
 				protectedUsers.POST("/fcm-token", controllers.SaveFCMTokenHandler())
+				protectedUsers.GET("/devices", controllers.GetDevices())
+				protectedUsers.DELETE("/devices/:device_id", controllers.DeleteDevice())
+				// Notification channel preferences
+				protectedUsers.GET("/notification-preferences", controllers.GetNotificationPreferences())
+				protectedUsers.PUT("/notification-preferences", controllers.UpdateNotificationPreferences())
+				protectedUsers.PUT("/language", controllers.UpdateLanguage())
+				protectedUsers.PUT("/quiet-hours", controllers.UpdateQuietHours())
+				protectedUsers.PUT("/vacation", controllers.SetVacation())
+				protectedUsers.DELETE("/vacation", controllers.EndVacation())
+				protectedUsers.GET("/calendar-feed", controllers.GetCalendarFeed())
+				protectedUsers.POST("/calendar-feed", controllers.CreateCalendarFeed())
+				protectedUsers.DELETE("/calendar-feed", controllers.DeleteCalendarFeed())
 			}
 		}
 
@@ -298,8 +323,63 @@ func SetupRoutes(router *gin.Engine, authService *services.AuthService) {
 			reminders.POST("", controllers.CreateReminder())
 			reminders.GET("/", controllers.GetReminders())
 			reminders.GET("/plant/:plant_id", controllers.GetPlantReminders())
+			reminders.POST("/bulk", controllers.BulkCreateReminders())
+			reminders.GET("/presets/:plant_id", controllers.GetReminderPresets())
+			reminders.POST("/presets/:plant_id/apply", controllers.ApplyReminderPresets())
+			reminders.PUT("/bulk/active", controllers.BulkSetRemindersActive())
+			reminders.POST("/bulk/shift", controllers.BulkShiftReminders())
+			reminders.POST("/bulk/delete", controllers.BulkDeleteReminders())
+			reminders.GET("/:id", controllers.GetReminder())
 			reminders.PUT("/:id", controllers.UpdateReminder())
 			reminders.DELETE("/:id", controllers.DeleteReminder())
+			reminders.GET("/:id/occurrences", controllers.GetReminderOccurrences())
+			reminders.GET("/:id/stats", controllers.GetReminderStats())
+			reminders.PUT("/:id/escalation", controllers.UpdateReminderEscalation())
+			reminders.GET("/:id/watering-suggestion", controllers.GetWateringSuggestion())
+			reminders.POST("/:id/watering-suggestion/accept", controllers.AcceptWateringSuggestion())
+			reminders.POST("/occurrences/:occurrence_id/acknowledge", controllers.AcknowledgeOccurrence())
+			reminders.POST("/occurrences/:occurrence_id/snooze", controllers.SnoozeOccurrence())
+			reminders.POST("/occurrences/:occurrence_id/skip", controllers.SkipOccurrence())
+		}
+
+		// People looking after the user's plants; only accepted caretakers can be reminder delegates
+		caretakers := api.Group("/caretakers")
+		{
+			caretakers.GET("", controllers.GetCaretakers())
+			caretakers.POST("", controllers.InviteCaretaker())
+			caretakers.POST("/:caretaker_id/accept", controllers.AcceptCaretakerInvitation())
+			caretakers.DELETE("/:caretaker_id", controllers.RemoveCaretaker())
+		}
+
+		// Care activity log across all plants; events are logged per plant under /api/plants
+		careEvents := api.Group("/care-events")
+		{
+			careEvents.GET("", controllers.GetCareEvents())
+			careEvents.PUT("/:event_id", controllers.UpdateCareEvent())
+			careEvents.DELETE("/:event_id", controllers.DeleteCareEvent())
+		}
+
+		// Notification delivery history
+		notifications := api.Group("/notifications")
+		{
+			notifications.GET("/deliveries", controllers.GetMyNotificationDeliveries())
+			notifications.GET("/inbox", controllers.GetInbox())
+			notifications.GET("/inbox/unread-count", controllers.GetInboxUnreadCount())
+			notifications.PUT("/inbox/read-all", controllers.MarkAllInboxItemsRead())
+			notifications.PUT("/inbox/:item_id/read", controllers.MarkInboxItemRead())
+			notifications.DELETE("/inbox/:item_id", controllers.DeleteInboxItem())
+		}
+
+		// Admin routes
+		admin := api.Group("/admin")
+		admin.Use(middleware.AdminUserOnly())
+		{
+			admin.GET("/notifications/failures", controllers.GetFailedNotificationDeliveries())
+			admin.GET("/notification-templates", controllers.GetNotificationTemplates())
+			admin.PUT("/notification-templates/:type/:locale", controllers.UpdateNotificationTemplate())
+			admin.DELETE("/notification-templates/:type/:locale", controllers.ResetNotificationTemplate())
+			admin.GET("/assets/orphans", controllers.GetOrphanedAssets())
+			admin.POST("/assets/sweep", controllers.SweepAssets())
 		}
 	}
 }
@@ -0,0 +1,307 @@
+package services
+
+import (
+	"authentication/models"
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// assetGracePeriod is how long an unreferenced image is kept, e.g. an upload not yet attached to a plant
+	assetGracePeriod = 24 * time.Hour
+	// assetSweepInterval is how often the scheduler sweeps unreferenced images
+	assetSweepInterval = time.Hour
+	// maxAssetsPerSweep bounds the work of one sweep; the rest are left for the next one
+	maxAssetsPerSweep = 500
+)
+
+// AssetSweepReport lists the unreferenced images a sweep deleted, or would delete in a dry run
+type AssetSweepReport struct {
+	DryRun  bool           `json:"dry_run"`
+	Cutoff  time.Time      `json:"cutoff"` // Images unreferenced since before it are swept
+	Orphans []models.Asset `json:"orphans"`
+	Deleted int            `json:"deleted"`
+	Failed  int            `json:"failed"` // Kept for the next sweep
+}
+
+// AssetService tracks the images in the image store and deletes the ones nothing uses anymore.
+// Reference counts follow plant photos and care event photos, which PhotoService and CareEventService
+// keep up to date, and profile images.
+type AssetService struct {
+	db     *mongo.Database
+	images *ImageService
+}
+
+func NewAssetService(db *mongo.Database, images *ImageService) *AssetService {
+	return &AssetService{db: db, images: images}
+}
+
+func (s *AssetService) collection() *mongo.Collection {
+	return s.db.Collection("assets")
+}
+
+// EnsureIndexes creates the indexes used to find assets by URL and to sweep them
+func (s *AssetService) EnsureIndexes(ctx context.Context) error {
+	_, err := s.collection().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "key", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "urls.full", Value: 1}}},
+		{Keys: bson.D{{Key: "ref_count", Value: 1}, {Key: "updated_at", Value: 1}}},
+	})
+	if err != nil {
+		return fmt.Errorf("error creating asset indexes: %v", err)
+	}
+	return nil
+}
+
+// Track records a new upload. It starts unreferenced, so it is swept unless it is used within the grace period.
+func (s *AssetService) Track(ctx context.Context, userID string, image StoredImage, now time.Time) (*models.Asset, error) {
+	asset := models.Asset{UserID: userID, Key: image.Key, URLs: image.URLs, CreatedAt: now, UpdatedAt: now}
+	result, err := s.collection().InsertOne(ctx, asset)
+	if err != nil {
+		return nil, fmt.Errorf("error saving asset: %v", err)
+	}
+	asset.ID = result.InsertedID.(primitive.ObjectID)
+	return &asset, nil
+}
+
+// Find returns the image at url with the key stored when it was uploaded. Images that are not
+// tracked fall back to ImageService.Find.
+func (s *AssetService) Find(ctx context.Context, url string) StoredImage {
+	var asset models.Asset
+	if err := s.collection().FindOne(ctx, bson.M{"urls.full": url}).Decode(&asset); err != nil {
+		if err != mongo.ErrNoDocuments {
+			log.Printf("[ERROR] Error finding asset of %s: %v", url, err)
+		}
+		return s.images.Find(url)
+	}
+	return StoredImage{Key: asset.Key, URLs: asset.URLs}
+}
+
+// Retain counts one more use of the image at url. Images that are not tracked are ignored.
+func (s *AssetService) Retain(ctx context.Context, url string, now time.Time) error {
+	return adjustAssetRefs(ctx, s.db, []string{url}, 1, now)
+}
+
+// Release counts one use less of the image at url
+func (s *AssetService) Release(ctx context.Context, url string, now time.Time) error {
+	return adjustAssetRefs(ctx, s.db, []string{url}, -1, now)
+}
+
+// adjustAssetRefs changes the reference count of the images at urls by delta, once per URL listed
+func adjustAssetRefs(ctx context.Context, db *mongo.Database, urls []string, delta int, now time.Time) error {
+	for _, url := range urls {
+		if url == "" {
+			continue
+		}
+		_, err := db.Collection("assets").UpdateOne(ctx,
+			bson.M{"urls.full": url},
+			bson.M{"$inc": bson.M{"ref_count": delta}, "$set": bson.M{"updated_at": now}},
+		)
+		if err != nil {
+			return fmt.Errorf("error updating asset references: %v", err)
+		}
+	}
+	return nil
+}
+
+// references counts the photos, care events and profiles using the image at url, to check a reference
+// count before sweeping
+func (s *AssetService) references(ctx context.Context, url string) (int, error) {
+	photos, err := s.db.Collection("plant_photos").CountDocuments(ctx, bson.M{"url": url})
+	if err != nil {
+		return 0, fmt.Errorf("error counting photos: %v", err)
+	}
+	events, err := s.db.Collection("care_events").CountDocuments(ctx, bson.M{"photo_url": url})
+	if err != nil {
+		return 0, fmt.Errorf("error counting care events: %v", err)
+	}
+	profiles, err := s.db.Collection("users").CountDocuments(ctx, bson.M{"profileImageUrl": url})
+	if err != nil {
+		return 0, fmt.Errorf("error counting profiles: %v", err)
+	}
+	return int(photos + events + profiles), nil
+}
+
+// Sweep deletes the images unreferenced for longer than the grace period. With dryRun it only reports them.
+// The references of every candidate are counted again first, and a count that drifted is corrected
+// instead of the image being deleted.
+func (s *AssetService) Sweep(ctx context.Context, now time.Time, dryRun bool) (*AssetSweepReport, error) {
+	report := &AssetSweepReport{DryRun: dryRun, Cutoff: now.Add(-assetGracePeriod), Orphans: []models.Asset{}}
+	unreferenced := bson.M{"ref_count": bson.M{"$lte": 0}, "updated_at": bson.M{"$lt": report.Cutoff}}
+
+	cursor, err := s.collection().Find(ctx, unreferenced,
+		options.Find().SetSort(bson.D{{Key: "updated_at", Value: 1}}).SetLimit(maxAssetsPerSweep))
+	if err != nil {
+		return nil, fmt.Errorf("error finding unreferenced assets: %v", err)
+	}
+	var candidates []models.Asset
+	if err := cursor.All(ctx, &candidates); err != nil {
+		return nil, fmt.Errorf("error decoding assets: %v", err)
+	}
+
+	for _, asset := range candidates {
+		refs, err := s.references(ctx, asset.URLs.Full)
+		if err != nil {
+			return report, err
+		}
+		if refs > 0 {
+			log.Printf("Asset %s is used %d time(s) but counted %d, correcting", asset.Key, refs, asset.RefCount)
+			if !dryRun {
+				if _, err := s.collection().UpdateOne(ctx, bson.M{"_id": asset.ID},
+					bson.M{"$set": bson.M{"ref_count": refs, "updated_at": now}}); err != nil {
+					return report, fmt.Errorf("error correcting asset references: %v", err)
+				}
+			}
+			continue
+		}
+
+		report.Orphans = append(report.Orphans, asset)
+		if dryRun {
+			continue
+		}
+
+		// Removed first so an asset retained in the meantime is left alone
+		filter := bson.M{"_id": asset.ID}
+		for field, condition := range unreferenced {
+			filter[field] = condition
+		}
+		result, err := s.collection().DeleteOne(ctx, filter)
+		if err != nil {
+			return report, fmt.Errorf("error deleting asset: %v", err)
+		}
+		if result.DeletedCount == 0 {
+			report.Orphans = report.Orphans[:len(report.Orphans)-1]
+			continue
+		}
+
+		if err := s.images.Delete(ctx, asset.Key); err != nil {
+			log.Printf("[ERROR] Error deleting image %s: %v", asset.Key, err)
+			report.Failed++
+			if _, err := s.collection().InsertOne(ctx, asset); err != nil {
+				log.Printf("[ERROR] Error keeping asset %s for the next sweep: %v", asset.Key, err)
+			}
+			continue
+		}
+		report.Deleted++
+	}
+	return report, nil
+}
+
+// MigrateUntrackedImages starts tracking the gallery, care event and profile images uploaded before assets were,
+// using their stored keys or, for the oldest images, the key found from their URL.
+// Images kept outside the image store are left untracked.
+func (s *AssetService) MigrateUntrackedImages(ctx context.Context, now time.Time) (int, error) {
+	untracked := mongo.Pipeline{
+		{{Key: "$lookup", Value: bson.M{"from": "assets", "localField": "url", "foreignField": "urls.full", "as": "assets"}}},
+		{{Key: "$match", Value: bson.M{"assets": bson.M{"$size": 0}}}},
+	}
+	cursor, err := s.db.Collection("plant_photos").Aggregate(ctx, untracked)
+	if err != nil {
+		return 0, fmt.Errorf("error finding untracked photos: %v", err)
+	}
+	var photos []models.PlantPhoto
+	if err := cursor.All(ctx, &photos); err != nil {
+		return 0, fmt.Errorf("error decoding photos: %v", err)
+	}
+
+	migrated := 0
+	for _, photo := range photos {
+		key := photo.ImageKey
+		if key == "" {
+			key = s.images.KeyFromURL(photo.URL)
+		}
+		tracked, err := s.trackExisting(ctx, photo.UserID, key, photo.Sizes(), now)
+		if err != nil {
+			return migrated, err
+		}
+		if tracked {
+			migrated++
+		}
+	}
+
+	cursor, err = s.db.Collection("care_events").Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"photo_url": bson.M{"$nin": bson.A{"", nil}}}}},
+		{{Key: "$lookup", Value: bson.M{"from": "assets", "localField": "photo_url", "foreignField": "urls.full", "as": "assets"}}},
+		{{Key: "$match", Value: bson.M{"assets": bson.M{"$size": 0}}}},
+		{{Key: "$project", Value: bson.M{"user_id": 1, "photo_url": 1}}},
+	})
+	if err != nil {
+		return migrated, fmt.Errorf("error finding untracked care event photos: %v", err)
+	}
+	var events []models.CareEvent
+	if err := cursor.All(ctx, &events); err != nil {
+		return migrated, fmt.Errorf("error decoding care events: %v", err)
+	}
+	for _, event := range events {
+		image := s.images.Find(event.PhotoURL)
+		tracked, err := s.trackExisting(ctx, event.UserID, image.Key, image.URLs, now)
+		if err != nil {
+			return migrated, err
+		}
+		if tracked {
+			migrated++
+		}
+	}
+
+	cursor, err = s.db.Collection("users").Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"profileImageUrl": bson.M{"$nin": bson.A{"", nil}}}}},
+		{{Key: "$lookup", Value: bson.M{"from": "assets", "localField": "profileImageUrl", "foreignField": "urls.full", "as": "assets"}}},
+		{{Key: "$match", Value: bson.M{"assets": bson.M{"$size": 0}}}},
+		{{Key: "$project", Value: bson.M{"user_id": 1, "profileImageUrl": 1}}},
+	})
+	if err != nil {
+		return migrated, fmt.Errorf("error finding untracked profile images: %v", err)
+	}
+	var users []struct {
+		UserID          string `bson:"user_id"`
+		ProfileImageURL string `bson:"profileImageUrl"`
+	}
+	if err := cursor.All(ctx, &users); err != nil {
+		return migrated, fmt.Errorf("error decoding users: %v", err)
+	}
+	for _, user := range users {
+		image := s.images.Find(user.ProfileImageURL)
+		tracked, err := s.trackExisting(ctx, user.UserID, image.Key, image.URLs, now)
+		if err != nil {
+			return migrated, err
+		}
+		if tracked {
+			migrated++
+		}
+	}
+	return migrated, nil
+}
+
+// trackExisting adds an asset for an image already in use, counting its references
+func (s *AssetService) trackExisting(ctx context.Context, userID, key string, urls models.ImageURLs, now time.Time) (bool, error) {
+	if key == "" {
+		return false, nil
+	}
+	refs, err := s.references(ctx, urls.Full)
+	if err != nil {
+		return false, err
+	}
+	result, err := s.collection().UpdateOne(ctx,
+		bson.M{"key": key},
+		bson.M{"$setOnInsert": models.Asset{
+			UserID:    userID,
+			Key:       key,
+			URLs:      urls,
+			RefCount:  refs,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return false, fmt.Errorf("error tracking image %s: %v", key, err)
+	}
+	return result.UpsertedCount > 0, nil
+}
@@ -0,0 +1,261 @@
+package services
+
+import (
+	"authentication/models"
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// newTestAssetService returns an asset service on a test database storing images in a temporary directory
+func newTestAssetService(t *testing.T) (*AssetService, string) {
+	t.Helper()
+	db := newTestDatabase(t)
+	images, dir := newTestImageService(t)
+	service := NewAssetService(db, images)
+	if err := service.EnsureIndexes(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return service, dir
+}
+
+// uploadTestImage ingests and tracks a small PNG
+func uploadTestImage(t *testing.T, service *AssetService, userID string, now time.Time) StoredImage {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 8, 8))); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := service.images.Ingest(context.Background(), &buf, "plante/plants")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.Track(context.Background(), userID, *stored, now); err != nil {
+		t.Fatal(err)
+	}
+	return *stored
+}
+
+func imageExists(t *testing.T, dir string, stored StoredImage) bool {
+	t.Helper()
+	_, err := os.Stat(filepath.Join(dir, filepath.FromSlash(stored.Key)))
+	return err == nil
+}
+
+func TestSweepDeletesUnreferencedImagesAfterGracePeriod(t *testing.T) {
+	ctx := context.Background()
+	service, dir := newTestAssetService(t)
+	photos := NewPhotoService(service.db)
+	now := time.Date(2026, 3, 20, 9, 0, 0, 0, time.UTC)
+
+	orphan := uploadTestImage(t, service, "user-1", now.Add(-2*assetGracePeriod))
+	fresh := uploadTestImage(t, service, "user-1", now.Add(-time.Hour))
+	used := uploadTestImage(t, service, "user-1", now.Add(-2*assetGracePeriod))
+	if _, err := photos.Create(ctx, models.PlantPhoto{
+		UserID: "user-1", PlantID: primitive.NewObjectID(), URL: used.URLs.Full, CreatedAt: now.Add(-2 * assetGracePeriod),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := service.Sweep(ctx, now, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Orphans) != 1 || report.Orphans[0].Key != orphan.Key || report.Deleted != 0 {
+		t.Fatalf("dry run report = %+v, want only the old unreferenced image", report)
+	}
+	if !imageExists(t, dir, orphan) {
+		t.Fatal("dry run deleted the image")
+	}
+
+	if report, err = service.Sweep(ctx, now, false); err != nil {
+		t.Fatal(err)
+	}
+	if report.Deleted != 1 {
+		t.Errorf("deleted %d image(s), want 1", report.Deleted)
+	}
+	if imageExists(t, dir, orphan) {
+		t.Error("unreferenced image past the grace period was kept")
+	}
+	if !imageExists(t, dir, fresh) || !imageExists(t, dir, used) {
+		t.Error("a recent or used image was deleted")
+	}
+	if n, _ := service.collection().CountDocuments(ctx, bson.M{"key": orphan.Key}); n != 0 {
+		t.Error("asset of the deleted image was kept")
+	}
+}
+
+func TestDeletedPhotosReleaseTheirImage(t *testing.T) {
+	ctx := context.Background()
+	service, dir := newTestAssetService(t)
+	photos := NewPhotoService(service.db)
+	now := time.Date(2026, 3, 20, 9, 0, 0, 0, time.UTC)
+	plantID := primitive.NewObjectID()
+
+	stored := uploadTestImage(t, service, "user-1", now)
+	if _, err := photos.Create(ctx, models.PlantPhoto{UserID: "user-1", PlantID: plantID, URL: stored.URLs.Full, CreatedAt: now}); err != nil {
+		t.Fatal(err)
+	}
+	var asset models.Asset
+	if err := service.collection().FindOne(ctx, bson.M{"key": stored.Key}).Decode(&asset); err != nil || asset.RefCount != 1 {
+		t.Fatalf("asset = %+v, %v; want one reference", asset, err)
+	}
+
+	if _, err := photos.DeleteForPlant(ctx, plantID, now.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.collection().FindOne(ctx, bson.M{"key": stored.Key}).Decode(&asset); err != nil || asset.RefCount != 0 {
+		t.Fatalf("asset = %+v, %v; want it released", asset, err)
+	}
+
+	// Kept through the grace period after the release, swept after it
+	if _, err := service.Sweep(ctx, now.Add(assetGracePeriod), false); err != nil {
+		t.Fatal(err)
+	}
+	if !imageExists(t, dir, stored) {
+		t.Fatal("image deleted within the grace period")
+	}
+	if _, err := service.Sweep(ctx, now.Add(2*assetGracePeriod), false); err != nil {
+		t.Fatal(err)
+	}
+	if imageExists(t, dir, stored) {
+		t.Error("released image was not swept")
+	}
+}
+
+func TestSweepCorrectsReferenceCounts(t *testing.T) {
+	ctx := context.Background()
+	service, dir := newTestAssetService(t)
+	now := time.Date(2026, 3, 20, 9, 0, 0, 0, time.UTC)
+
+	// Used as a profile image, but the count was never raised
+	stored := uploadTestImage(t, service, "user-1", now.Add(-2*assetGracePeriod))
+	if _, err := service.db.Collection("users").InsertOne(ctx, bson.M{"user_id": "user-1", "profileImageUrl": stored.URLs.Full}); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := service.Sweep(ctx, now, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Orphans) != 0 || !imageExists(t, dir, stored) {
+		t.Fatalf("report = %+v, want the profile image kept", report)
+	}
+	var asset models.Asset
+	if err := service.collection().FindOne(ctx, bson.M{"key": stored.Key}).Decode(&asset); err != nil || asset.RefCount != 1 {
+		t.Errorf("asset = %+v, %v; want the count corrected to 1", asset, err)
+	}
+}
+
+func TestSweepKeepsCareEventPhotos(t *testing.T) {
+	ctx := context.Background()
+	service, dir := newTestAssetService(t)
+	events := NewCareEventService(service.db)
+	now := time.Date(2026, 3, 20, 9, 0, 0, 0, time.UTC)
+
+	stored := uploadTestImage(t, service, "user-1", now)
+	event, err := events.Create(ctx, models.CareEvent{
+		UserID: "user-1", PlantID: primitive.NewObjectID(), Type: models.CareEventWatering,
+		Date: now, PhotoURL: stored.URLs.Full, CreatedAt: now, UpdatedAt: now,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := service.Sweep(ctx, now.Add(2*assetGracePeriod), false); err != nil {
+		t.Fatal(err)
+	}
+	if !imageExists(t, dir, stored) {
+		t.Fatal("photo of a care event was swept")
+	}
+
+	// Replacing the photo releases the old one
+	replacement := uploadTestImage(t, service, "user-1", now)
+	event.PhotoURL = replacement.URLs.Full
+	event.UpdatedAt = now.Add(3 * assetGracePeriod)
+	if _, err := events.Update(ctx, *event); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.Sweep(ctx, now.Add(5*assetGracePeriod), false); err != nil {
+		t.Fatal(err)
+	}
+	if imageExists(t, dir, stored) || !imageExists(t, dir, replacement) {
+		t.Fatal("want the replaced photo swept and the new one kept")
+	}
+
+	if err := events.Delete(ctx, "user-1", event.ID, now.Add(5*assetGracePeriod)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.Sweep(ctx, now.Add(7*assetGracePeriod), false); err != nil {
+		t.Fatal(err)
+	}
+	if imageExists(t, dir, replacement) {
+		t.Error("photo of a deleted care event was not swept")
+	}
+}
+
+func TestSweepCountsCareEventPhotosNotRetained(t *testing.T) {
+	ctx := context.Background()
+	service, dir := newTestAssetService(t)
+	now := time.Date(2026, 3, 20, 9, 0, 0, 0, time.UTC)
+
+	// Saved before care events retained their photo
+	stored := uploadTestImage(t, service, "user-1", now.Add(-2*assetGracePeriod))
+	if _, err := service.db.Collection("care_events").InsertOne(ctx, bson.M{"user_id": "user-1", "photo_url": stored.URLs.Full}); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := service.Sweep(ctx, now, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Orphans) != 0 || !imageExists(t, dir, stored) {
+		t.Fatalf("report = %+v, want the care event photo kept", report)
+	}
+}
+
+func TestDeletedPlantsReleaseTheirCareEventPhotos(t *testing.T) {
+	ctx := context.Background()
+	service, dir := newTestAssetService(t)
+	events := NewCareEventService(service.db)
+	now := time.Date(2026, 3, 20, 9, 0, 0, 0, time.UTC)
+
+	deleted, kept := primitive.NewObjectID(), primitive.NewObjectID()
+	deletedPhoto := uploadTestImage(t, service, "user-1", now)
+	keptPhoto := uploadTestImage(t, service, "user-1", now)
+	for _, event := range []models.CareEvent{
+		{UserID: "user-1", PlantID: deleted, Type: models.CareEventWatering, Date: now, PhotoURL: deletedPhoto.URLs.Full},
+		{UserID: "user-1", PlantID: deleted, Type: models.CareEventWatering, Date: now},
+		{UserID: "user-1", PlantID: kept, Type: models.CareEventWatering, Date: now, PhotoURL: keptPhoto.URLs.Full},
+	} {
+		if _, err := events.Create(ctx, event); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := events.DeleteForPlant(ctx, deleted, now); err != nil {
+		t.Fatal(err)
+	}
+	left, total, err := events.List(ctx, "user-1", CareEventFilter{}, 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1 || left[0].PlantID != kept {
+		t.Fatalf("events left = %+v, want only the other plant's", left)
+	}
+
+	if _, err := service.Sweep(ctx, now.Add(2*assetGracePeriod), false); err != nil {
+		t.Fatal(err)
+	}
+	if imageExists(t, dir, deletedPhoto) || !imageExists(t, dir, keptPhoto) {
+		t.Error("want the deleted plant's care event photo swept and the other kept")
+	}
+}
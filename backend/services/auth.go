@@ -104,16 +104,6 @@ func (s *AuthService) CreateOrUpdateUser(firebaseUser *auth.UserRecord) (*models
 	return &user, nil
 }
 
-// UpdateFCMToken อัพเดท FCM token ของผู้ใช้
-func (s *AuthService) UpdateFCMToken(uid string, fcmToken string) error {
-	_, err := s.db.Collection("users").UpdateOne(
-		context.Background(),
-		bson.M{"user_id": uid},
-		bson.M{"$set": bson.M{"fcm_token": fcmToken, "updated_at": time.Now()}},
-	)
-	return err
-}
-
 // DeleteUser ลบผู้ใช้จากทั้ง Firebase และ MongoDB
 func (s *AuthService) DeleteUser(uid string) error {
 	// ลบจาก Firebase
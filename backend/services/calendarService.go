@@ -0,0 +1,286 @@
+package services
+
+import (
+	"authentication/models"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// CalendarTimezone is the timezone reminders are scheduled in
+const CalendarTimezone = "Asia/Bangkok"
+
+// calendarEventDuration is how long each reminder event lasts in the calendar
+const calendarEventDuration = "PT15M"
+
+var ErrCalendarFeedNotFound = errors.New("calendar feed not found")
+
+// CalendarService serves a user's reminders as an iCalendar (RFC 5545) feed behind a secret token
+type CalendarService struct {
+	db *mongo.Database
+}
+
+func NewCalendarService(db *mongo.Database) *CalendarService {
+	return &CalendarService{db: db}
+}
+
+// EnsureIndexes makes feed tokens unique and fast to look up
+func (s *CalendarService) EnsureIndexes(ctx context.Context) error {
+	_, err := s.db.Collection("users").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "calendar_token", Value: 1}},
+		Options: options.Index().SetUnique(true).SetSparse(true),
+	})
+	if err != nil {
+		return fmt.Errorf("error creating calendar token index: %v", err)
+	}
+	return nil
+}
+
+// GenerateToken gives the user a new feed token; the previous URL stops working
+func (s *CalendarService) GenerateToken(ctx context.Context, userID string) (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("error generating calendar token: %v", err)
+	}
+	token := hex.EncodeToString(buf)
+
+	_, err := s.db.Collection("users").UpdateOne(ctx, bson.M{"user_id": userID}, bson.M{
+		"$set": bson.M{"calendar_token": token, "updated_at": time.Now()},
+	})
+	if err != nil {
+		return "", fmt.Errorf("error saving calendar token: %v", err)
+	}
+	return token, nil
+}
+
+// RevokeToken turns the user's feed off
+func (s *CalendarService) RevokeToken(ctx context.Context, userID string) error {
+	_, err := s.db.Collection("users").UpdateOne(ctx, bson.M{"user_id": userID}, bson.M{
+		"$unset": bson.M{"calendar_token": ""},
+		"$set":   bson.M{"updated_at": time.Now()},
+	})
+	if err != nil {
+		return fmt.Errorf("error revoking calendar token: %v", err)
+	}
+	return nil
+}
+
+// Feed renders the calendar of the user owning the token
+func (s *CalendarService) Feed(ctx context.Context, token string) (string, error) {
+	if token == "" {
+		return "", ErrCalendarFeedNotFound
+	}
+
+	var user models.User
+	err := s.db.Collection("users").FindOne(ctx, bson.M{"calendar_token": token}).Decode(&user)
+	if err == mongo.ErrNoDocuments {
+		return "", ErrCalendarFeedNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("error fetching calendar owner: %v", err)
+	}
+
+	cursor, err := s.db.Collection("reminders").Find(ctx, bson.M{"user_id": user.User_id, "is_active": true})
+	if err != nil {
+		return "", fmt.Errorf("error fetching reminders: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	var reminders []models.Reminder
+	if err := cursor.All(ctx, &reminders); err != nil {
+		return "", fmt.Errorf("error decoding reminders: %v", err)
+	}
+
+	plantIDs := make([]primitive.ObjectID, 0, len(reminders))
+	for _, reminder := range reminders {
+		plantIDs = append(plantIDs, reminder.PlantID)
+	}
+	plantNames := make(map[primitive.ObjectID]string)
+	if len(plantIDs) > 0 {
+		plantCursor, err := s.db.Collection("plants").Find(ctx, bson.M{"_id": bson.M{"$in": plantIDs}})
+		if err != nil {
+			return "", fmt.Errorf("error fetching plants: %v", err)
+		}
+		defer plantCursor.Close(ctx)
+
+		var plants []models.Plant
+		if err := plantCursor.All(ctx, &plants); err != nil {
+			return "", fmt.Errorf("error decoding plants: %v", err)
+		}
+		for _, plant := range plants {
+			plantNames[plant.ID] = plant.Name
+		}
+	}
+
+	return BuildCalendar(reminders, plantNames, NormalizeLocale(user.Language), time.Now()), nil
+}
+
+// calendarTypeNames are the event titles per reminder type and locale
+var calendarTypeNames = map[string]map[string]string{
+	models.LocaleThai:    {"watering": "รดน้ำ", "fertilizing": "ใส่ปุ๋ย", "misting": "พ่นละอองน้ำ", "default": "ดูแล"},
+	models.LocaleEnglish: {"watering": "Water", "fertilizing": "Fertilize", "misting": "Mist", "default": "Care for"},
+}
+
+// BuildCalendar renders reminders as an RFC 5545 calendar. Daily, weekly and interval reminders
+// become recurring events, one-time reminders single events; unknown frequencies are left out.
+func BuildCalendar(reminders []models.Reminder, plantNames map[primitive.ObjectID]string, locale string, now time.Time) string {
+	loc, err := time.LoadLocation(CalendarTimezone)
+	if err != nil {
+		loc = time.FixedZone("ICT", 7*60*60)
+	}
+
+	var b strings.Builder
+	writeLine := func(line string) {
+		b.WriteString(foldICalLine(line))
+		b.WriteString("\r\n")
+	}
+
+	writeLine("BEGIN:VCALENDAR")
+	writeLine("VERSION:2.0")
+	writeLine("PRODID:-//Plante//Plant Reminders//EN")
+	writeLine("CALSCALE:GREGORIAN")
+	writeLine("METHOD:PUBLISH")
+	writeLine("X-WR-CALNAME:" + escapeICalText("Plante"))
+	writeLine("X-WR-TIMEZONE:" + CalendarTimezone)
+	// Thailand has no daylight saving, a single STANDARD component describes it
+	writeLine("BEGIN:VTIMEZONE")
+	writeLine("TZID:" + CalendarTimezone)
+	writeLine("BEGIN:STANDARD")
+	writeLine("DTSTART:19700101T000000")
+	writeLine("TZOFFSETFROM:+0700")
+	writeLine("TZOFFSETTO:+0700")
+	writeLine("TZNAME:ICT")
+	writeLine("END:STANDARD")
+	writeLine("END:VTIMEZONE")
+
+	names := calendarTypeNames[locale]
+	if names == nil {
+		names = calendarTypeNames[models.LocaleThai]
+	}
+
+	for _, reminder := range reminders {
+		start, rrule, ok := reminderSchedule(reminder, loc)
+		if !ok {
+			continue
+		}
+
+		plantName := plantNames[reminder.PlantID]
+		action, ok := names[reminder.Type]
+		if !ok {
+			action = names["default"]
+		}
+		summary := strings.TrimSpace(action + " " + plantName)
+
+		writeLine("BEGIN:VEVENT")
+		writeLine("UID:" + reminder.ID.Hex() + "@plante")
+		writeLine("DTSTAMP:" + now.UTC().Format("20060102T150405Z"))
+		writeLine("DTSTART;TZID=" + CalendarTimezone + ":" + start.Format("20060102T150405"))
+		writeLine("DURATION:" + calendarEventDuration)
+		if rrule != "" {
+			writeLine("RRULE:" + rrule)
+		}
+		writeLine("SUMMARY:" + escapeICalText(summary))
+		writeLine("DESCRIPTION:" + escapeICalText(fmt.Sprintf("%s (%s)\n%s", plantName, reminder.Type, reminder.Frequency)))
+		writeLine("CATEGORIES:" + escapeICalText(reminder.Type))
+		if !reminder.UpdatedAt.IsZero() {
+			writeLine("LAST-MODIFIED:" + reminder.UpdatedAt.UTC().Format("20060102T150405Z"))
+		}
+		writeLine("END:VEVENT")
+	}
+
+	writeLine("END:VCALENDAR")
+	return b.String()
+}
+
+// icalWeekdays maps DayOfWeek values to RFC 5545 BYDAY codes
+var icalWeekdays = map[string]string{
+	"Sunday": "SU", "Monday": "MO", "Tuesday": "TU", "Wednesday": "WE",
+	"Thursday": "TH", "Friday": "FR", "Saturday": "SA",
+}
+
+// reminderSchedule returns the first start (in loc) and the RRULE of a reminder
+func reminderSchedule(reminder models.Reminder, loc *time.Location) (time.Time, string, bool) {
+	switch reminder.Frequency {
+	case "once":
+		if reminder.ScheduledTime.IsZero() {
+			return time.Time{}, "", false
+		}
+		return reminder.ScheduledTime.In(loc), "", true
+	case "interval":
+		if reminder.IntervalDays < 1 {
+			return time.Time{}, "", false
+		}
+		clock, err := time.Parse("15:04", reminder.TimeOfDay)
+		if err != nil {
+			return time.Time{}, "", false
+		}
+		anchor := reminder.ScheduledTime.In(loc)
+		start := time.Date(anchor.Year(), anchor.Month(), anchor.Day(), clock.Hour(), clock.Minute(), 0, 0, loc)
+		return start, fmt.Sprintf("FREQ=DAILY;INTERVAL=%d", reminder.IntervalDays), true
+	case "daily", "weekly":
+		clock, err := time.Parse("15:04", reminder.TimeOfDay)
+		if err != nil {
+			return time.Time{}, "", false
+		}
+		from := reminder.CreatedAt
+		if from.IsZero() {
+			from = reminder.ScheduledTime
+		}
+		from = from.In(loc)
+		start := time.Date(from.Year(), from.Month(), from.Day(), clock.Hour(), clock.Minute(), 0, 0, loc)
+
+		if reminder.Frequency == "daily" {
+			return start, "FREQ=DAILY", true
+		}
+		byDay, ok := icalWeekdays[reminder.DayOfWeek]
+		if !ok {
+			return time.Time{}, "", false
+		}
+		// Move to the first matching weekday so DTSTART is itself an occurrence
+		for start.Weekday().String() != reminder.DayOfWeek {
+			start = start.AddDate(0, 0, 1)
+		}
+		return start, "FREQ=WEEKLY;BYDAY=" + byDay, true
+	}
+	return time.Time{}, "", false
+}
+
+// escapeICalText escapes a TEXT value as RFC 5545 section 3.3.11 requires
+func escapeICalText(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, ";", `\;`)
+	s = strings.ReplaceAll(s, ",", `\,`)
+	s = strings.ReplaceAll(s, "\r\n", `\n`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	return s
+}
+
+// foldICalLine splits lines longer than 75 octets, never inside a UTF-8 character (RFC 5545 section 3.1)
+func foldICalLine(line string) string {
+	const limit = 75
+	if len(line) <= limit {
+		return line
+	}
+
+	var b strings.Builder
+	width := 0
+	for _, r := range line {
+		size := len(string(r))
+		if width+size > limit {
+			b.WriteString("\r\n ")
+			width = 1 // The leading space counts towards the next line
+		}
+		b.WriteRune(r)
+		width += size
+	}
+	return b.String()
+}
@@ -0,0 +1,196 @@
+package services
+
+import (
+	"authentication/models"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestReminderSchedule(t *testing.T) {
+	loc := bangkok(t)
+	at := func(year int, month time.Month, day, hour, min int) time.Time {
+		return time.Date(year, month, day, hour, min, 0, 0, loc)
+	}
+	// Wednesday 2026-03-04 in Bangkok, stored in UTC as the API receives it
+	created := at(2026, 3, 4, 21, 30).UTC()
+
+	tests := []struct {
+		name      string
+		reminder  models.Reminder
+		wantStart time.Time
+		wantRRule string
+		wantOK    bool
+	}{
+		{
+			name:      "once keeps its time",
+			reminder:  models.Reminder{Frequency: "once", ScheduledTime: at(2026, 3, 5, 7, 15).UTC()},
+			wantStart: at(2026, 3, 5, 7, 15),
+			wantOK:    true,
+		},
+		{
+			name:     "once without a time",
+			reminder: models.Reminder{Frequency: "once"},
+		},
+		{
+			name:      "daily starts on the day it was created",
+			reminder:  models.Reminder{Frequency: "daily", TimeOfDay: "08:00", CreatedAt: created},
+			wantStart: at(2026, 3, 4, 8, 0),
+			wantRRule: "FREQ=DAILY",
+			wantOK:    true,
+		},
+		{
+			name:      "daily created late in the Bangkok day, early in UTC",
+			reminder:  models.Reminder{Frequency: "daily", TimeOfDay: "08:00", CreatedAt: at(2026, 3, 5, 1, 0).UTC()},
+			wantStart: at(2026, 3, 5, 8, 0),
+			wantRRule: "FREQ=DAILY",
+			wantOK:    true,
+		},
+		{
+			name:      "weekly moves to the next matching weekday",
+			reminder:  models.Reminder{Frequency: "weekly", DayOfWeek: "Monday", TimeOfDay: "09:30", CreatedAt: created},
+			wantStart: at(2026, 3, 9, 9, 30),
+			wantRRule: "FREQ=WEEKLY;BYDAY=MO",
+			wantOK:    true,
+		},
+		{
+			name:      "weekly on the day it was created",
+			reminder:  models.Reminder{Frequency: "weekly", DayOfWeek: "Wednesday", TimeOfDay: "09:30", CreatedAt: created},
+			wantStart: at(2026, 3, 4, 9, 30),
+			wantRRule: "FREQ=WEEKLY;BYDAY=WE",
+			wantOK:    true,
+		},
+		{
+			name:     "weekly on an unknown day",
+			reminder: models.Reminder{Frequency: "weekly", DayOfWeek: "Funday", TimeOfDay: "09:30", CreatedAt: created},
+		},
+		{
+			name:      "interval counts from the scheduled day",
+			reminder:  models.Reminder{Frequency: "interval", IntervalDays: 3, TimeOfDay: "18:00", ScheduledTime: at(2026, 2, 27, 6, 0).UTC()},
+			wantStart: at(2026, 2, 27, 18, 0),
+			wantRRule: "FREQ=DAILY;INTERVAL=3",
+			wantOK:    true,
+		},
+		{
+			name:     "interval without days",
+			reminder: models.Reminder{Frequency: "interval", TimeOfDay: "18:00", ScheduledTime: created},
+		},
+		{
+			name:     "bad time of day",
+			reminder: models.Reminder{Frequency: "daily", TimeOfDay: "8am", CreatedAt: created},
+		},
+		{
+			name:     "unknown frequency",
+			reminder: models.Reminder{Frequency: "monthly", TimeOfDay: "08:00", CreatedAt: created},
+		},
+	}
+
+	for _, tt := range tests {
+		start, rrule, ok := reminderSchedule(tt.reminder, loc)
+		if ok != tt.wantOK {
+			t.Errorf("%s: ok = %v, want %v", tt.name, ok, tt.wantOK)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if !start.Equal(tt.wantStart) || rrule != tt.wantRRule {
+			t.Errorf("%s: got %v %q, want %v %q", tt.name, start, rrule, tt.wantStart, tt.wantRRule)
+		}
+		if start.Location().String() != loc.String() {
+			t.Errorf("%s: start is in %v, want %v", tt.name, start.Location(), loc)
+		}
+	}
+}
+
+func TestEscapeICalText(t *testing.T) {
+	tests := map[string]string{
+		"Monstera":           "Monstera",
+		"a;b,c":              `a\;b\,c`,
+		`back\slash`:         `back\\slash`,
+		"two\nlines":         `two\nlines`,
+		"windows\r\nlines":   `windows\nlines`,
+		`already\; escaped;`: `already\\\; escaped\;`,
+		"รดน้ำ มอนสเตอร่า, ห้อง": `รดน้ำ มอนสเตอร่า\, ห้อง`,
+	}
+	for in, want := range tests {
+		if got := escapeICalText(in); got != want {
+			t.Errorf("escapeICalText(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestFoldICalLine(t *testing.T) {
+	tests := map[string]string{
+		"short":     "SUMMARY:Water Monstera",
+		"exactly":   "SUMMARY:" + strings.Repeat("a", 75-len("SUMMARY:")),
+		"ascii":     "DESCRIPTION:" + strings.Repeat("abcdefghij", 20),
+		"thai":      "SUMMARY:" + strings.Repeat("รดน้ำต้นไม้", 12),
+		"mixed":     "SUMMARY:" + strings.Repeat("a", 73) + "ก" + strings.Repeat("b", 80),
+		"emoji end": strings.Repeat("x", 74) + "🌱",
+	}
+	for name, line := range tests {
+		folded := foldICalLine(line)
+		parts := strings.Split(folded, "\r\n")
+		for i, part := range parts {
+			if len(part) > 75 {
+				t.Errorf("%s: line %d is %d octets, want at most 75", name, i, len(part))
+			}
+			if !utf8.ValidString(part) {
+				t.Errorf("%s: line %d splits a UTF-8 character: %q", name, i, part)
+			}
+			if i > 0 && !strings.HasPrefix(part, " ") {
+				t.Errorf("%s: continuation line %d does not start with a space", name, i)
+			}
+		}
+		if len(line) <= 75 && len(parts) != 1 {
+			t.Errorf("%s: a %d octet line was folded", name, len(line))
+		}
+		// Unfolding as RFC 5545 section 3.1 describes gives the line back
+		if unfolded := strings.ReplaceAll(folded, "\r\n ", ""); unfolded != line {
+			t.Errorf("%s: unfolded = %q, want %q", name, unfolded, line)
+		}
+	}
+}
+
+func TestBuildCalendar(t *testing.T) {
+	loc := bangkok(t)
+	plantID := primitive.NewObjectID()
+	daily := models.Reminder{
+		ID: primitive.NewObjectID(), PlantID: plantID, Type: "watering", Frequency: "daily", TimeOfDay: "08:00",
+		CreatedAt: time.Date(2026, 3, 4, 10, 0, 0, 0, loc),
+	}
+	unknown := models.Reminder{ID: primitive.NewObjectID(), PlantID: plantID, Type: "repotting", Frequency: "monthly", TimeOfDay: "08:00"}
+	now := time.Date(2026, 3, 20, 9, 0, 0, 0, time.UTC)
+
+	calendar := BuildCalendar([]models.Reminder{daily, unknown}, map[primitive.ObjectID]string{plantID: "Monstera, living room"}, models.LocaleEnglish, now)
+
+	if !strings.HasPrefix(calendar, "BEGIN:VCALENDAR\r\n") || !strings.HasSuffix(calendar, "END:VCALENDAR\r\n") {
+		t.Errorf("calendar is not wrapped in VCALENDAR with CRLF line endings")
+	}
+	if strings.Contains(strings.ReplaceAll(calendar, "\r\n", ""), "\n") {
+		t.Error("calendar has a bare LF")
+	}
+	if n := strings.Count(calendar, "BEGIN:VEVENT"); n != 1 {
+		t.Errorf("%d events, want 1 with the unknown frequency left out", n)
+	}
+	for _, want := range []string{
+		"UID:" + daily.ID.Hex() + "@plante\r\n",
+		"DTSTAMP:20260320T090000Z\r\n",
+		"DTSTART;TZID=Asia/Bangkok:20260304T080000\r\n",
+		"RRULE:FREQ=DAILY\r\n",
+		`SUMMARY:Water Monstera\, living room` + "\r\n",
+	} {
+		if !strings.Contains(calendar, want) {
+			t.Errorf("calendar is missing %q", strings.TrimSpace(want))
+		}
+	}
+
+	// Unknown locales fall back to Thai
+	if thai := BuildCalendar([]models.Reminder{daily}, nil, "fr", now); !strings.Contains(thai, "SUMMARY:รดน้ำ\r\n") {
+		t.Error("unknown locale did not fall back to Thai")
+	}
+}
@@ -0,0 +1,221 @@
+package services
+
+import (
+	"authentication/models"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrCareEventNotFound is returned when the event does not exist or belongs to another user
+var ErrCareEventNotFound = errors.New("care event not found")
+
+// CareEventFilter narrows a listing of a user's care events; zero fields match everything
+type CareEventFilter struct {
+	PlantID *primitive.ObjectID
+	Types   []string
+	From    time.Time // Inclusive
+	To      time.Time // Exclusive
+}
+
+// CareEventService keeps the log of care actions done on plants
+type CareEventService struct {
+	db *mongo.Database
+}
+
+func NewCareEventService(db *mongo.Database) *CareEventService {
+	return &CareEventService{db: db}
+}
+
+func (s *CareEventService) collection() *mongo.Collection {
+	return s.db.Collection("care_events")
+}
+
+// EnsureIndexes creates the indexes used to list events per plant and per type
+func (s *CareEventService) EnsureIndexes(ctx context.Context) error {
+	_, err := s.collection().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "plant_id", Value: 1}, {Key: "date", Value: -1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "type", Value: 1}, {Key: "date", Value: -1}}},
+	})
+	if err != nil {
+		return fmt.Errorf("error creating care event indexes: %v", err)
+	}
+	return nil
+}
+
+// Create stores the event. Repotting into a new container also updates the plant's container.
+func (s *CareEventService) Create(ctx context.Context, event models.CareEvent) (*models.CareEvent, error) {
+	if event.ID.IsZero() {
+		event.ID = primitive.NewObjectID()
+	}
+	if _, err := s.collection().InsertOne(ctx, event); err != nil {
+		return nil, fmt.Errorf("error saving care event: %v", err)
+	}
+	if err := adjustAssetRefs(ctx, s.db, []string{event.PhotoURL}, 1, event.CreatedAt); err != nil {
+		// The sweeper counts the references again before deleting anything
+		log.Printf("[ERROR] Error retaining photo of care event %s: %v", event.ID.Hex(), err)
+	}
+
+	if event.Type == models.CareEventRepotting && event.NewContainer != "" {
+		_, err := s.db.Collection("plants").UpdateOne(ctx,
+			bson.M{"_id": event.PlantID, "user_id": event.UserID},
+			bson.M{"$set": bson.M{"container": event.NewContainer, "updated_at": event.CreatedAt}, "$inc": bson.M{"version": 1}},
+		)
+		if err != nil {
+			log.Printf("[ERROR] Error updating container of plant %s after repotting: %v", event.PlantID.Hex(), err)
+		}
+	}
+	return &event, nil
+}
+
+// List returns one page of the user's events, most recent first, and the total number of matching events
+func (s *CareEventService) List(ctx context.Context, userID string, filter CareEventFilter, page, limit int64) ([]models.CareEvent, int64, error) {
+	query := bson.M{"user_id": userID}
+	if filter.PlantID != nil {
+		query["plant_id"] = *filter.PlantID
+	}
+	if len(filter.Types) > 0 {
+		query["type"] = bson.M{"$in": filter.Types}
+	}
+	date := bson.M{}
+	if !filter.From.IsZero() {
+		date["$gte"] = filter.From
+	}
+	if !filter.To.IsZero() {
+		date["$lt"] = filter.To
+	}
+	if len(date) > 0 {
+		query["date"] = date
+	}
+
+	total, err := s.collection().CountDocuments(ctx, query)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error counting care events: %v", err)
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "date", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip((page - 1) * limit).
+		SetLimit(limit)
+	cursor, err := s.collection().Find(ctx, query, opts)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error finding care events: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	events := []models.CareEvent{}
+	if err := cursor.All(ctx, &events); err != nil {
+		return nil, 0, fmt.Errorf("error decoding care events: %v", err)
+	}
+	return events, total, nil
+}
+
+// Get returns one of the user's events
+func (s *CareEventService) Get(ctx context.Context, userID string, eventID primitive.ObjectID) (*models.CareEvent, error) {
+	var event models.CareEvent
+	err := s.collection().FindOne(ctx, bson.M{"_id": eventID, "user_id": userID}).Decode(&event)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrCareEventNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error finding care event: %v", err)
+	}
+	return &event, nil
+}
+
+// Update replaces the editable fields of one of the user's events
+func (s *CareEventService) Update(ctx context.Context, event models.CareEvent) (*models.CareEvent, error) {
+	var previous models.CareEvent
+	err := s.collection().FindOneAndUpdate(ctx,
+		bson.M{"_id": event.ID, "user_id": event.UserID},
+		bson.M{"$set": bson.M{
+			"type":          event.Type,
+			"date":          event.Date,
+			"notes":         event.Notes,
+			"amount_ml":     event.AmountML,
+			"product":       event.Product,
+			"new_container": event.NewContainer,
+			"photo_url":     event.PhotoURL,
+			"updated_at":    event.UpdatedAt,
+		}},
+		options.FindOneAndUpdate().SetReturnDocument(options.Before),
+	).Decode(&previous)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrCareEventNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error updating care event: %v", err)
+	}
+
+	if previous.PhotoURL != event.PhotoURL {
+		if err := adjustAssetRefs(ctx, s.db, []string{event.PhotoURL}, 1, event.UpdatedAt); err != nil {
+			log.Printf("[ERROR] Error retaining photo of care event %s: %v", event.ID.Hex(), err)
+		}
+		if err := adjustAssetRefs(ctx, s.db, []string{previous.PhotoURL}, -1, event.UpdatedAt); err != nil {
+			log.Printf("[ERROR] Error releasing photo of care event %s: %v", event.ID.Hex(), err)
+		}
+	}
+
+	updated := previous
+	updated.Type = event.Type
+	updated.Date = event.Date
+	updated.Notes = event.Notes
+	updated.AmountML = event.AmountML
+	updated.Product = event.Product
+	updated.NewContainer = event.NewContainer
+	updated.PhotoURL = event.PhotoURL
+	updated.UpdatedAt = event.UpdatedAt
+	return &updated, nil
+}
+
+// Delete removes one of the user's events and releases its photo
+func (s *CareEventService) Delete(ctx context.Context, userID string, eventID primitive.ObjectID, now time.Time) error {
+	var event models.CareEvent
+	err := s.collection().FindOneAndDelete(ctx, bson.M{"_id": eventID, "user_id": userID}).Decode(&event)
+	if err == mongo.ErrNoDocuments {
+		return ErrCareEventNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("error deleting care event: %v", err)
+	}
+	if err := adjustAssetRefs(ctx, s.db, []string{event.PhotoURL}, -1, now); err != nil {
+		log.Printf("[ERROR] Error releasing photo of care event %s: %v", event.ID.Hex(), err)
+	}
+	return nil
+}
+
+// DeleteForPlant removes all events of a deleted plant and releases their photos
+func (s *CareEventService) DeleteForPlant(ctx context.Context, plantID primitive.ObjectID, now time.Time) error {
+	cursor, err := s.collection().Find(ctx, bson.M{"plant_id": plantID}, options.Find().SetProjection(bson.M{"photo_url": 1}))
+	if err != nil {
+		return fmt.Errorf("error finding care events: %v", err)
+	}
+	var events []models.CareEvent
+	if err := cursor.All(ctx, &events); err != nil {
+		return fmt.Errorf("error decoding care events: %v", err)
+	}
+	if len(events) == 0 {
+		return nil
+	}
+
+	ids := make([]primitive.ObjectID, len(events))
+	urls := make([]string, len(events))
+	for i, event := range events {
+		ids[i] = event.ID
+		urls[i] = event.PhotoURL
+	}
+	if _, err := s.collection().DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids}}); err != nil {
+		return fmt.Errorf("error deleting care events: %v", err)
+	}
+	if err := adjustAssetRefs(ctx, s.db, urls, -1, now); err != nil {
+		log.Printf("[ERROR] Error releasing photos of deleted care events: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"authentication/models"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestCareEventListFilters(t *testing.T) {
+	ctx := context.Background()
+	service := NewCareEventService(newTestDatabase(t))
+	if err := service.EnsureIndexes(ctx); err != nil {
+		t.Fatal(err)
+	}
+	monstera, fern := primitive.NewObjectID(), primitive.NewObjectID()
+	day := func(d int) time.Time { return time.Date(2026, 3, d, 8, 0, 0, 0, time.UTC) }
+
+	// Notes name each event so the listings are easy to compare
+	for _, event := range []models.CareEvent{
+		{UserID: "user-1", PlantID: monstera, Type: models.CareEventWatering, Date: day(1), Notes: "monstera watering 1"},
+		{UserID: "user-1", PlantID: monstera, Type: models.CareEventFertilizing, Date: day(2), Notes: "monstera fertilizing"},
+		{UserID: "user-1", PlantID: fern, Type: models.CareEventWatering, Date: day(3), Notes: "fern watering"},
+		{UserID: "user-1", PlantID: fern, Type: models.CareEventMisting, Date: day(4), Notes: "fern misting"},
+		{UserID: "user-1", PlantID: monstera, Type: models.CareEventWatering, Date: day(5), Notes: "monstera watering 2"},
+		{UserID: "user-2", PlantID: monstera, Type: models.CareEventWatering, Date: day(6), Notes: "someone else's"},
+	} {
+		event.CreatedAt = event.Date
+		if _, err := service.Create(ctx, event); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		filter CareEventFilter
+		want   string
+	}{
+		{"everything", CareEventFilter{}, "[monstera watering 2, fern misting, fern watering, monstera fertilizing, monstera watering 1]"},
+		{"one plant", CareEventFilter{PlantID: &fern}, "[fern misting, fern watering]"},
+		{"one type", CareEventFilter{Types: []string{models.CareEventWatering}}, "[monstera watering 2, fern watering, monstera watering 1]"},
+		{"several types", CareEventFilter{Types: []string{models.CareEventFertilizing, models.CareEventMisting}}, "[fern misting, monstera fertilizing]"},
+		{"plant and type", CareEventFilter{PlantID: &monstera, Types: []string{models.CareEventWatering}}, "[monstera watering 2, monstera watering 1]"},
+		{"type the plant never had", CareEventFilter{PlantID: &fern, Types: []string{models.CareEventFertilizing}}, "[]"},
+		{"date range", CareEventFilter{From: day(2), To: day(4)}, "[fern watering, monstera fertilizing]"},
+	}
+	for _, tt := range tests {
+		events, total, err := service.List(ctx, "user-1", tt.filter, 1, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		notes := make([]string, len(events))
+		for i, event := range events {
+			notes[i] = event.Notes
+		}
+		if got := "[" + strings.Join(notes, ", ") + "]"; got != tt.want || total != int64(len(events)) {
+			t.Errorf("%s: got %s of %d, want %s", tt.name, got, total, tt.want)
+		}
+	}
+
+	// Paging keeps the filter's total
+	events, total, err := service.List(ctx, "user-1", CareEventFilter{Types: []string{models.CareEventWatering}}, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Notes != "monstera watering 1" || total != 3 {
+		t.Errorf("second page of watering = %d event(s) of %d, want the oldest of 3", len(events), total)
+	}
+}
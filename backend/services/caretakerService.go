@@ -0,0 +1,193 @@
+package services
+
+import (
+	"authentication/models"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrCaretakerNotFound is returned when the invitation does not exist or is not the user's
+var ErrCaretakerNotFound = errors.New("caretaker not found")
+
+// ErrEmailNotVerified is returned when invitations are looked up for an account whose email is not verified
+var ErrEmailNotVerified = errors.New("email not verified")
+
+// CaretakerService keeps the invitations between plant owners and the people looking after their plants.
+// Invitations are addressed to an email and only the account signed in with it can accept them,
+// so nobody gets another user's reminders without agreeing to.
+type CaretakerService struct {
+	db *mongo.Database
+}
+
+func NewCaretakerService(db *mongo.Database) *CaretakerService {
+	return &CaretakerService{db: db}
+}
+
+func (s *CaretakerService) collection() *mongo.Collection {
+	return s.db.Collection("caretakers")
+}
+
+// EnsureIndexes allows one invitation per owner and email and indexes the lookups of both sides
+func (s *CaretakerService) EnsureIndexes(ctx context.Context) error {
+	_, err := s.collection().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "owner_id", Value: 1}, {Key: "email", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "email", Value: 1}, {Key: "status", Value: 1}}},
+		{Keys: bson.D{{Key: "caretaker_id", Value: 1}}},
+	})
+	if err != nil {
+		return fmt.Errorf("error creating caretaker indexes: %v", err)
+	}
+	return nil
+}
+
+// normalizeEmail compares addresses without case or surrounding spaces
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// SameEmail reports whether a and b are the same address
+func SameEmail(a, b string) bool {
+	return normalizeEmail(a) == normalizeEmail(b)
+}
+
+// InviteeEmail returns the address invitations to the user are matched on. Anyone can sign up
+// with an address they do not own, so it is only trusted once the user has verified it.
+func InviteeEmail(user *models.User) (string, error) {
+	if user.Email == nil || strings.TrimSpace(*user.Email) == "" || !user.IsVerified {
+		return "", ErrEmailNotVerified
+	}
+	return *user.Email, nil
+}
+
+// Invite asks the owner of email to look after the owner's plants. Inviting an address again
+// returns the existing invitation. Whether email has an account is not checked, so it is not revealed.
+func (s *CaretakerService) Invite(ctx context.Context, ownerID, email string, now time.Time) (*models.Caretaker, error) {
+	var caretaker models.Caretaker
+	err := s.collection().FindOneAndUpdate(ctx,
+		bson.M{"owner_id": ownerID, "email": normalizeEmail(email)},
+		bson.M{"$setOnInsert": bson.M{
+			"_id":        primitive.NewObjectID(),
+			"status":     models.CaretakerStatusPending,
+			"created_at": now,
+		}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&caretaker)
+	if err != nil {
+		return nil, fmt.Errorf("error saving caretaker invitation: %v", err)
+	}
+	return &caretaker, nil
+}
+
+// ListForOwner returns the owner's caretakers and pending invitations, newest first
+func (s *CaretakerService) ListForOwner(ctx context.Context, ownerID string) ([]models.Caretaker, error) {
+	return s.list(ctx, bson.M{"owner_id": ownerID})
+}
+
+// ListForCaretaker returns the invitations sent to email and the ones accepted by the user
+func (s *CaretakerService) ListForCaretaker(ctx context.Context, userID, email string) ([]models.Caretaker, error) {
+	return s.list(ctx, bson.M{"$or": bson.A{
+		bson.M{"caretaker_id": userID},
+		bson.M{"email": normalizeEmail(email), "status": models.CaretakerStatusPending},
+	}})
+}
+
+func (s *CaretakerService) list(ctx context.Context, filter bson.M) ([]models.Caretaker, error) {
+	cursor, err := s.collection().Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+	if err != nil {
+		return nil, fmt.Errorf("error finding caretakers: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	caretakers := []models.Caretaker{}
+	if err := cursor.All(ctx, &caretakers); err != nil {
+		return nil, fmt.Errorf("error decoding caretakers: %v", err)
+	}
+	return caretakers, nil
+}
+
+// Accept accepts an invitation sent to the user's email
+func (s *CaretakerService) Accept(ctx context.Context, invitationID primitive.ObjectID, userID, email string, now time.Time) (*models.Caretaker, error) {
+	var caretaker models.Caretaker
+	err := s.collection().FindOneAndUpdate(ctx,
+		bson.M{
+			"_id":      invitationID,
+			"email":    normalizeEmail(email),
+			"status":   models.CaretakerStatusPending,
+			"owner_id": bson.M{"$ne": userID},
+		},
+		bson.M{"$set": bson.M{"status": models.CaretakerStatusAccepted, "caretaker_id": userID, "accepted_at": now}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&caretaker)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrCaretakerNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error accepting caretaker invitation: %v", err)
+	}
+	return &caretaker, nil
+}
+
+// Remove ends a caretaker relationship or withdraws or declines an invitation. Either side may do it.
+func (s *CaretakerService) Remove(ctx context.Context, invitationID primitive.ObjectID, userID, email string) error {
+	parties := bson.A{bson.M{"owner_id": userID}, bson.M{"caretaker_id": userID}}
+	if email != "" {
+		parties = append(parties, bson.M{"email": normalizeEmail(email), "status": models.CaretakerStatusPending})
+	}
+	result, err := s.collection().DeleteOne(ctx, bson.M{"_id": invitationID, "$or": parties})
+	if err != nil {
+		return fmt.Errorf("error removing caretaker: %v", err)
+	}
+	if result.DeletedCount == 0 {
+		return ErrCaretakerNotFound
+	}
+	return nil
+}
+
+// AcceptedByEmail returns the owner's caretaker invited at email, or ErrCaretakerNotFound when
+// there is none or they have not accepted
+func (s *CaretakerService) AcceptedByEmail(ctx context.Context, ownerID, email string) (*models.Caretaker, error) {
+	return s.findAccepted(ctx, bson.M{"owner_id": ownerID, "email": normalizeEmail(email)})
+}
+
+// Accepted returns the owner's caretaker with the given user ID, or ErrCaretakerNotFound when
+// there is none or they have not accepted
+func (s *CaretakerService) Accepted(ctx context.Context, ownerID, caretakerID string) (*models.Caretaker, error) {
+	return s.findAccepted(ctx, bson.M{"owner_id": ownerID, "caretaker_id": caretakerID})
+}
+
+func (s *CaretakerService) findAccepted(ctx context.Context, filter bson.M) (*models.Caretaker, error) {
+	filter["status"] = models.CaretakerStatusAccepted
+	var caretaker models.Caretaker
+	err := s.collection().FindOne(ctx, filter).Decode(&caretaker)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrCaretakerNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error finding caretaker: %v", err)
+	}
+	return &caretaker, nil
+}
+
+// OwnersOf returns the IDs of the owners the user accepted to look after
+func (s *CaretakerService) OwnersOf(ctx context.Context, caretakerID string) ([]string, error) {
+	ownerIDs, err := s.collection().Distinct(ctx, "owner_id",
+		bson.M{"caretaker_id": caretakerID, "status": models.CaretakerStatusAccepted})
+	if err != nil {
+		return nil, fmt.Errorf("error finding owners of caretaker: %v", err)
+	}
+	owners := make([]string, 0, len(ownerIDs))
+	for _, id := range ownerIDs {
+		if owner, ok := id.(string); ok {
+			owners = append(owners, owner)
+		}
+	}
+	return owners, nil
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"authentication/models"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestInviteeEmailRequiresVerification(t *testing.T) {
+	email := func(address string) *string { return &address }
+	tests := []struct {
+		name    string
+		user    models.User
+		want    string
+		wantErr error
+	}{
+		{"verified", models.User{Email: email("friend@example.com"), IsVerified: true}, "friend@example.com", nil},
+		{"signed up with someone else's address", models.User{Email: email("friend@example.com")}, "", ErrEmailNotVerified},
+		{"no email", models.User{IsVerified: true}, "", ErrEmailNotVerified},
+		{"blank email", models.User{Email: email(" "), IsVerified: true}, "", ErrEmailNotVerified},
+	}
+	for _, tt := range tests {
+		got, err := InviteeEmail(&tt.user)
+		if got != tt.want || !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: InviteeEmail = %q, %v; want %q, %v", tt.name, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestCaretakerMustAcceptBeforeBeingDelegate(t *testing.T) {
+	ctx := context.Background()
+	service := NewCaretakerService(newTestDatabase(t))
+	if err := service.EnsureIndexes(ctx); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 3, 20, 9, 0, 0, 0, time.UTC)
+
+	invitation, err := service.Invite(ctx, "owner", " Friend@Example.com ", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, err := service.Invite(ctx, "owner", "friend@example.com", now.Add(time.Hour)); err != nil || again.ID != invitation.ID {
+		t.Fatalf("inviting again = %+v, %v; want the same invitation", again, err)
+	}
+	if _, err := service.AcceptedByEmail(ctx, "owner", "friend@example.com"); !errors.Is(err, ErrCaretakerNotFound) {
+		t.Fatalf("pending invitation found as a caretaker: %v", err)
+	}
+
+	// Only the account with the invited email can accept
+	if _, err := service.Accept(ctx, invitation.ID, "stranger", "stranger@example.com", now); !errors.Is(err, ErrCaretakerNotFound) {
+		t.Fatalf("accepted by another account: %v", err)
+	}
+	if _, err := service.Accept(ctx, invitation.ID, "friend", "FRIEND@example.com", now); err != nil {
+		t.Fatal(err)
+	}
+
+	caretaker, err := service.AcceptedByEmail(ctx, "owner", "friend@example.com")
+	if err != nil || caretaker.CaretakerID != "friend" {
+		t.Fatalf("AcceptedByEmail = %+v, %v; want friend", caretaker, err)
+	}
+	if _, err := service.Accepted(ctx, "owner", "friend"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The caretaker can leave, after which they are no longer a delegate
+	if err := service.Remove(ctx, invitation.ID, "friend", "friend@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.Accepted(ctx, "owner", "friend"); !errors.Is(err, ErrCaretakerNotFound) {
+		t.Errorf("removed caretaker still found: %v", err)
+	}
+}
+
+func TestOnlyCurrentCaretakersCloseDelegatedOccurrences(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDatabase(t)
+	caretakers := NewCaretakerService(db)
+	occurrences := NewOccurrenceService(db)
+	now := time.Date(2026, 3, 20, 9, 0, 0, 0, time.UTC)
+
+	reminder := models.Reminder{ID: primitive.NewObjectID(), UserID: "owner", PlantID: primitive.NewObjectID(), Type: "watering"}
+	first, _, err := occurrences.Create(ctx, reminder, now, now, "friend")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, _, err := occurrences.Create(ctx, reminder, now.Add(24*time.Hour), now, "friend")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Named as delegate without having accepted anything
+	if _, err := occurrences.Skip(ctx, "friend", first.ID, now); !errors.Is(err, ErrOccurrenceNotFound) {
+		t.Fatalf("skipped by a delegate who is not a caretaker: %v", err)
+	}
+
+	invitation, err := caretakers.Invite(ctx, "owner", "friend@example.com", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := caretakers.Accept(ctx, invitation.ID, "friend", "friend@example.com", now); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := occurrences.Skip(ctx, "friend", first.ID, now); err != nil {
+		t.Fatalf("skip by an accepted caretaker: %v", err)
+	}
+
+	if err := caretakers.Remove(ctx, invitation.ID, "owner", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := occurrences.Skip(ctx, "friend", second.ID, now); !errors.Is(err, ErrOccurrenceNotFound) {
+		t.Errorf("skipped by a removed caretaker: %v", err)
+	}
+	if _, err := occurrences.Skip(ctx, "owner", second.ID, now); err != nil {
+		t.Errorf("skip by the owner: %v", err)
+	}
+}
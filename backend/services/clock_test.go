@@ -0,0 +1,60 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFakeClockAdvance(t *testing.T) {
+	start := time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+
+	clock.Advance(90 * time.Second)
+	if got, want := clock.Now(), start.Add(90*time.Second); !got.Equal(want) {
+		t.Fatalf("Now() = %v, want %v", got, want)
+	}
+
+	clock.Set(start)
+	if got := clock.Now(); !got.Equal(start) {
+		t.Fatalf("Now() after Set = %v, want %v", got, start)
+	}
+}
+
+func TestFakeClockTicker(t *testing.T) {
+	clock := NewFakeClock(time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC))
+	ticker := clock.NewTicker(5 * time.Second)
+
+	clock.Advance(4 * time.Second)
+	select {
+	case <-ticker.C():
+		t.Fatal("ticker fired before its period")
+	default:
+	}
+
+	clock.Advance(time.Second)
+	select {
+	case tick := <-ticker.C():
+		if !tick.Equal(clock.Now()) {
+			t.Fatalf("tick = %v, want %v", tick, clock.Now())
+		}
+	default:
+		t.Fatal("ticker did not fire after its period")
+	}
+
+	// Skipped periods collapse into one tick, like time.Ticker
+	clock.Advance(time.Minute)
+	<-ticker.C()
+	select {
+	case <-ticker.C():
+		t.Fatal("ticker fired more than once for one Advance")
+	default:
+	}
+
+	ticker.Stop()
+	clock.Advance(time.Minute)
+	select {
+	case <-ticker.C():
+		t.Fatal("stopped ticker fired")
+	default:
+	}
+}
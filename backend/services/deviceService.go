@@ -0,0 +1,140 @@
+package services
+
+import (
+	"authentication/models"
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// DeviceService keeps the registry of push-enabled devices per user
+type DeviceService struct {
+	db *mongo.Database
+}
+
+func NewDeviceService(db *mongo.Database) *DeviceService {
+	return &DeviceService{db: db}
+}
+
+func (s *DeviceService) collection() *mongo.Collection {
+	return s.db.Collection("devices")
+}
+
+// EnsureIndexes makes tokens unique and user lookups fast
+func (s *DeviceService) EnsureIndexes(ctx context.Context) error {
+	_, err := s.collection().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "token", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "user_id", Value: 1}}},
+	})
+	if err != nil {
+		return fmt.Errorf("error creating device indexes: %v", err)
+	}
+	return nil
+}
+
+// RegisterDevice adds a token for the user or refreshes it if it is already known.
+// A token that moves to another account (shared device) is reassigned.
+func (s *DeviceService) RegisterDevice(ctx context.Context, userID, token, platform, appVersion string) (*models.Device, error) {
+	if platform == "" {
+		platform = "unknown"
+	}
+	now := time.Now()
+
+	update := bson.M{
+		"$set": bson.M{
+			"user_id":      userID,
+			"platform":     platform,
+			"app_version":  appVersion,
+			"last_seen_at": now,
+		},
+		"$setOnInsert": bson.M{
+			"_id":        primitive.NewObjectID(),
+			"created_at": now,
+		},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var device models.Device
+	err := s.collection().FindOneAndUpdate(ctx, bson.M{"token": token}, update, opts).Decode(&device)
+	if err != nil {
+		return nil, fmt.Errorf("error registering device: %v", err)
+	}
+	return &device, nil
+}
+
+// GetUserDevices returns all registered devices of a user, most recently seen first
+func (s *DeviceService) GetUserDevices(ctx context.Context, userID string) ([]models.Device, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "last_seen_at", Value: -1}})
+	cursor, err := s.collection().Find(ctx, bson.M{"user_id": userID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error finding devices: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	devices := []models.Device{}
+	if err := cursor.All(ctx, &devices); err != nil {
+		return nil, fmt.Errorf("error decoding devices: %v", err)
+	}
+	return devices, nil
+}
+
+// DeleteDevice removes one of the user's devices, returning false when it does not exist
+func (s *DeviceService) DeleteDevice(ctx context.Context, userID string, deviceID primitive.ObjectID) (bool, error) {
+	result, err := s.collection().DeleteOne(ctx, bson.M{"_id": deviceID, "user_id": userID})
+	if err != nil {
+		return false, fmt.Errorf("error deleting device: %v", err)
+	}
+	return result.DeletedCount > 0, nil
+}
+
+// RemoveTokens prunes tokens that FCM reported as dead
+func (s *DeviceService) RemoveTokens(ctx context.Context, tokens []string) (int64, error) {
+	if len(tokens) == 0 {
+		return 0, nil
+	}
+	result, err := s.collection().DeleteMany(ctx, bson.M{"token": bson.M{"$in": tokens}})
+	if err != nil {
+		return 0, fmt.Errorf("error removing device tokens: %v", err)
+	}
+	return result.DeletedCount, nil
+}
+
+// MigrateLegacyTokens moves users.fcm_token into the device registry
+func (s *DeviceService) MigrateLegacyTokens(ctx context.Context) (int, error) {
+	users := s.db.Collection("users")
+	cursor, err := users.Find(ctx, bson.M{"fcm_token": bson.M{"$exists": true}})
+	if err != nil {
+		return 0, fmt.Errorf("error finding users with legacy FCM tokens: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	migrated := 0
+	for cursor.Next(ctx) {
+		var user models.User
+		if err := cursor.Decode(&user); err != nil {
+			log.Printf("[ERROR] Error decoding user during FCM token migration: %v", err)
+			continue
+		}
+
+		if user.FCMToken != nil && *user.FCMToken != "" {
+			if _, err := s.RegisterDevice(ctx, user.User_id, *user.FCMToken, "", ""); err != nil {
+				log.Printf("[ERROR] Error migrating FCM token of user %s: %v", user.User_id, err)
+				continue
+			}
+			migrated++
+		}
+
+		_, err := users.UpdateOne(ctx, bson.M{"user_id": user.User_id}, bson.M{"$unset": bson.M{"fcm_token": ""}})
+		if err != nil {
+			log.Printf("[ERROR] Error clearing legacy FCM token of user %s: %v", user.User_id, err)
+		}
+	}
+
+	return migrated, cursor.Err()
+}
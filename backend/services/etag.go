@@ -0,0 +1,44 @@
+package services
+
+import (
+	"strconv"
+	"strings"
+)
+
+// MatchesIfNoneMatch reports whether an If-None-Match header matches etag. If-None-Match
+// compares weakly, so W/ prefixes on either side are ignored.
+func MatchesIfNoneMatch(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// IfMatchVersions reads the versions an If-Match header accepts, for ETags that are a quoted
+// version. conditional is false when the header is absent or "*". If-Match compares strongly,
+// so weak ETags and entries that don't name a version are left out; with none left the request
+// can never match.
+func IfMatchVersions(header string) (versions []int64, conditional bool) {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return nil, false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if len(candidate) < 2 || !strings.HasPrefix(candidate, `"`) || !strings.HasSuffix(candidate, `"`) {
+			continue
+		}
+		version, err := strconv.ParseInt(candidate[1:len(candidate)-1], 10, 64)
+		if err != nil || version < 0 {
+			continue
+		}
+		versions = append(versions, version)
+	}
+	return versions, true
+}
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMatchesIfNoneMatch(t *testing.T) {
+	tests := []struct {
+		name   string
+		etag   string
+		header string
+		want   bool
+	}{
+		{"no header", `"3"`, "", false},
+		{"same version", `"3"`, `"3"`, true},
+		{"older version", `"3"`, `"2"`, false},
+		{"weak client copy", `"3"`, `W/"3"`, true},
+		{"weak list etag", `W/"abc"`, `W/"abc"`, true},
+		{"weak list etag sent strong", `W/"abc"`, `"abc"`, true},
+		{"in a list", `"3"`, `"1", "3"`, true},
+		{"not in a list", `"3"`, `"1", "2"`, false},
+		{"any", `"3"`, "*", true},
+	}
+	for _, tt := range tests {
+		if got := MatchesIfNoneMatch(tt.header, tt.etag); got != tt.want {
+			t.Errorf("%s: MatchesIfNoneMatch(%q, %q) = %v, want %v", tt.name, tt.header, tt.etag, got, tt.want)
+		}
+	}
+}
+
+func TestIfMatchVersions(t *testing.T) {
+	tests := []struct {
+		name            string
+		header          string
+		wantVersions    []int64
+		wantConditional bool
+	}{
+		{"no header", "", nil, false},
+		{"any", "*", nil, false},
+		{"one version", `"4"`, []int64{4}, true},
+		{"list", `"2", "4"`, []int64{2, 4}, true},
+		{"weak is never a strong match", `W/"4"`, nil, true},
+		{"weak entries are left out of a list", `W/"2", "4"`, []int64{4}, true},
+		{"unquoted", `4`, nil, true},
+		{"not a version", `"abc"`, nil, true},
+		{"negative", `"-1"`, nil, true},
+	}
+	for _, tt := range tests {
+		versions, conditional := IfMatchVersions(tt.header)
+		if conditional != tt.wantConditional || !reflect.DeepEqual(versions, tt.wantVersions) {
+			t.Errorf("%s: IfMatchVersions(%q) = %v, %v; want %v, %v", tt.name, tt.header, versions, conditional, tt.wantVersions, tt.wantConditional)
+		}
+	}
+}
@@ -0,0 +1,238 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Event types pushed to the web dashboard
+const (
+	EventPlantCreated         = "plant.created"
+	EventPlantUpdated         = "plant.updated"
+	EventPlantDeleted         = "plant.deleted"
+	EventGrowthRecordAdded    = "growth_record.added"
+	EventGrowthRecordUpdated  = "growth_record.updated"
+	EventGrowthRecordDeleted  = "growth_record.deleted"
+	EventReminderFired        = "reminder.fired"
+	EventNotificationReceived = "notification.received"
+	// EventResync tells a resuming client that events were missed and it should refetch
+	EventResync = "resync"
+)
+
+const (
+	// eventHistorySize is how many recent events are kept per user for resuming
+	eventHistorySize = 100
+	// subscriberBuffer is how many events may wait for a slow subscriber before it is dropped
+	subscriberBuffer = 32
+	// eventResumeWindow is how long a user's events are kept once nobody is subscribed to them.
+	// A client reconnecting later gets a resync event instead of the events it missed.
+	eventResumeWindow = 10 * time.Minute
+	// StreamTicketTTL is how long a stream ticket can be used to open a stream
+	StreamTicketTTL = 30 * time.Second
+)
+
+// Event is one change pushed to a user's open streams
+type Event struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	Data      interface{} `json:"data,omitempty"`
+	CreatedAt time.Time   `json:"createdAt"`
+}
+
+// userEvents holds a user's recent events and open subscriptions
+type userEvents struct {
+	history     []Event // Oldest first, at most eventHistorySize
+	subscribers map[chan Event]struct{}
+	since       uint64    // Events up to this ID may have been evicted before the entry was created
+	lastEventAt time.Time // When the newest event was published, or when the entry was created
+}
+
+// streamTicket lets its user open one stream before it expires
+type streamTicket struct {
+	userID    string
+	expiresAt time.Time
+}
+
+// EventBus delivers per-user events to subscribers in this process.
+// Event IDs increase monotonically so a client can resume with the last ID it saw.
+type EventBus struct {
+	clock   Clock
+	mu      sync.Mutex
+	nextID  uint64
+	users   map[string]*userEvents
+	tickets map[string]streamTicket
+	// evictedThrough is the newest event ID dropped with an idle user's history
+	evictedThrough uint64
+	lastEviction   time.Time
+}
+
+func NewEventBus(clock Clock) *EventBus {
+	return &EventBus{clock: clock, users: make(map[string]*userEvents), tickets: make(map[string]streamTicket)}
+}
+
+// IssueStreamTicket returns a single-use ticket that opens a stream of the user's events.
+// Browsers cannot send headers with EventSource, so the stream URL carries this ticket
+// instead of the user's ID token, which would end up in access logs.
+func (b *EventBus) IssueStreamTicket(userID string) (string, time.Time, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", time.Time{}, err
+	}
+	ticket := hex.EncodeToString(raw)
+	now := b.clock.Now()
+	expiresAt := now.Add(StreamTicketTTL)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for t, issued := range b.tickets {
+		if !now.Before(issued.expiresAt) {
+			delete(b.tickets, t)
+		}
+	}
+	b.tickets[ticket] = streamTicket{userID: userID, expiresAt: expiresAt}
+	return ticket, expiresAt, nil
+}
+
+// RedeemStreamTicket returns the user a ticket was issued to and uses it up.
+// It returns false for unknown, used or expired tickets.
+func (b *EventBus) RedeemStreamTicket(ticket string) (string, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	issued, ok := b.tickets[ticket]
+	if !ok {
+		return "", false
+	}
+	delete(b.tickets, ticket)
+	if !b.clock.Now().Before(issued.expiresAt) {
+		return "", false
+	}
+	return issued.userID, true
+}
+
+func (b *EventBus) userLocked(userID string, now time.Time) *userEvents {
+	u, ok := b.users[userID]
+	if !ok {
+		u = &userEvents{subscribers: make(map[chan Event]struct{}), since: b.evictedThrough, lastEventAt: now}
+		b.users[userID] = u
+	}
+	return u
+}
+
+// evictIdleLocked drops the users nobody is subscribed to whose newest event is older than
+// the resume window, so memory follows connected users rather than everyone who ever got an event
+func (b *EventBus) evictIdleLocked(now time.Time) {
+	b.lastEviction = now
+	for userID, u := range b.users {
+		if len(u.subscribers) > 0 || now.Sub(u.lastEventAt) < eventResumeWindow {
+			continue
+		}
+		if n := len(u.history); n > 0 {
+			if id, _ := strconv.ParseUint(u.history[n-1].ID, 10, 64); id > b.evictedThrough {
+				b.evictedThrough = id
+			}
+		}
+		delete(b.users, userID)
+	}
+}
+
+// Publish records an event for the user and sends it to the user's open subscriptions.
+// A subscriber that has fallen too far behind is closed so it reconnects and resumes.
+// Publishing on a nil bus does nothing.
+func (b *EventBus) Publish(userID, eventType string, data interface{}) {
+	if b == nil || userID == "" {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.clock.Now()
+	if now.Sub(b.lastEviction) >= eventResumeWindow {
+		b.evictIdleLocked(now)
+	}
+
+	b.nextID++
+	event := Event{
+		ID:        strconv.FormatUint(b.nextID, 10),
+		Type:      eventType,
+		Data:      data,
+		CreatedAt: now,
+	}
+
+	u := b.userLocked(userID, now)
+	u.lastEventAt = now
+	u.history = append(u.history, event)
+	if len(u.history) > eventHistorySize {
+		u.history = u.history[len(u.history)-eventHistorySize:]
+	}
+
+	for ch := range u.subscribers {
+		select {
+		case ch <- event:
+		default:
+			delete(u.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// Subscribe opens a stream of the user's events. When lastEventID is set, the events
+// published after it are returned as backlog; if some of them are no longer kept the
+// backlog starts with a resync event. The returned function ends the subscription.
+func (b *EventBus) Subscribe(userID, lastEventID string) (<-chan Event, []Event, func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.clock.Now()
+	u := b.userLocked(userID, now)
+	backlog := eventsAfter(u.history, u.since, lastEventID, b.nextID, now)
+
+	ch := make(chan Event, subscriberBuffer)
+	u.subscribers[ch] = struct{}{}
+
+	unsubscribe := func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := u.subscribers[ch]; ok {
+			delete(u.subscribers, ch)
+			close(ch)
+		}
+		if now := b.clock.Now(); now.Sub(b.lastEviction) >= eventResumeWindow {
+			b.evictIdleLocked(now)
+		}
+	}
+	return ch, backlog, unsubscribe
+}
+
+// eventsAfter returns the events in history newer than lastEventID.
+// Events up to since may have been evicted, so resuming from before it needs a resync.
+func eventsAfter(history []Event, since uint64, lastEventID string, latestID uint64, now time.Time) []Event {
+	if lastEventID == "" {
+		return nil
+	}
+	last, err := strconv.ParseUint(lastEventID, 10, 64)
+	if err != nil || last > latestID {
+		// Not an ID from this process, e.g. after a restart
+		return []Event{{ID: strconv.FormatUint(latestID, 10), Type: EventResync, CreatedAt: now}}
+	}
+
+	var backlog []Event
+	if last < since {
+		backlog = append(backlog, Event{ID: strconv.FormatUint(last, 10), Type: EventResync, CreatedAt: now})
+	}
+	for i, event := range history {
+		id, _ := strconv.ParseUint(event.ID, 10, 64)
+		if id <= last {
+			continue
+		}
+		// IDs are shared by all users, so only a full history can have lost events
+		if i == 0 && len(history) == eventHistorySize && len(backlog) == 0 {
+			backlog = append(backlog, Event{ID: strconv.FormatUint(last, 10), Type: EventResync, CreatedAt: now})
+		}
+		backlog = append(backlog, event)
+	}
+	return backlog
+}
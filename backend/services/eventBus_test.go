@@ -0,0 +1,111 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStreamTicketsAreSingleUseAndExpire(t *testing.T) {
+	clock := NewFakeClock(time.Date(2026, 3, 20, 9, 0, 0, 0, time.UTC))
+	bus := NewEventBus(clock)
+	issuedAt := clock.Now()
+
+	ticket, expiresAt, err := bus.IssueStreamTicket("user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !expiresAt.Equal(issuedAt.Add(StreamTicketTTL)) {
+		t.Errorf("expires at %v, want %v", expiresAt, issuedAt.Add(StreamTicketTTL))
+	}
+	clock.Advance(time.Second)
+	if userID, ok := bus.RedeemStreamTicket(ticket); !ok || userID != "user-1" {
+		t.Fatalf("RedeemStreamTicket = %q, %v; want user-1", userID, ok)
+	}
+	if _, ok := bus.RedeemStreamTicket(ticket); ok {
+		t.Error("ticket redeemed twice")
+	}
+
+	lastMoment, _, err := bus.IssueStreamTicket("user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expired, _, err := bus.IssueStreamTicket("user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(StreamTicketTTL - time.Nanosecond)
+	if _, ok := bus.RedeemStreamTicket(lastMoment); !ok {
+		t.Error("ticket rejected just before it expires")
+	}
+	clock.Advance(time.Nanosecond)
+	if _, ok := bus.RedeemStreamTicket(expired); ok {
+		t.Error("expired ticket redeemed")
+	}
+	if _, ok := bus.RedeemStreamTicket(""); ok {
+		t.Error("empty ticket redeemed")
+	}
+
+	// Issuing a ticket drops the ones that expired unused
+	unused, _, err := bus.IssueStreamTicket("user-2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(StreamTicketTTL)
+	if _, _, err := bus.IssueStreamTicket("user-2"); err != nil {
+		t.Fatal(err)
+	}
+	bus.mu.Lock()
+	_, kept := bus.tickets[unused]
+	bus.mu.Unlock()
+	if kept {
+		t.Error("expired ticket kept after issuing a new one")
+	}
+}
+
+func TestIdleUsersAreEvicted(t *testing.T) {
+	clock := NewFakeClock(time.Date(2026, 3, 20, 9, 0, 0, 0, time.UTC))
+	bus := NewEventBus(clock)
+	bus.Publish("idle", EventPlantUpdated, nil)
+	bus.Publish("connected", EventPlantUpdated, nil)
+	_, _, unsubscribe := bus.Subscribe("connected", "")
+	defer unsubscribe()
+	kept := func(userID string) bool {
+		bus.mu.Lock()
+		defer bus.mu.Unlock()
+		_, ok := bus.users[userID]
+		return ok
+	}
+
+	clock.Advance(eventResumeWindow - time.Second)
+	bus.Publish("other", EventPlantUpdated, nil)
+	if !kept("idle") {
+		t.Fatal("idle user's history was evicted inside the resume window")
+	}
+
+	// The next publish after the resume window sweeps the idle users
+	clock.Advance(time.Second)
+	bus.Publish("other", EventPlantUpdated, nil)
+	if kept("idle") {
+		t.Error("idle user's history was kept past the resume window")
+	}
+	if !kept("connected") {
+		t.Error("subscribed user's history was evicted")
+	}
+	if !kept("other") {
+		t.Error("history of a user with a recent event was evicted")
+	}
+
+	// Resuming after the eviction cannot replay the event, so the client is told to refetch
+	_, backlog, unsubscribeIdle := bus.Subscribe("idle", "0")
+	defer unsubscribeIdle()
+	if len(backlog) != 1 || backlog[0].Type != EventResync || !backlog[0].CreatedAt.Equal(clock.Now()) {
+		t.Errorf("backlog = %+v, want a resync", backlog)
+	}
+
+	// A client that saw everything before the eviction has nothing to refetch
+	_, backlog, unsubscribeConnected := bus.Subscribe("connected", "2")
+	defer unsubscribeConnected()
+	if len(backlog) != 0 {
+		t.Errorf("backlog = %+v, want none", backlog)
+	}
+}
@@ -0,0 +1,345 @@
+package services
+
+import (
+	"authentication/models"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// heightDropThreshold is how many cm shorter than last time a plant must be, less is measuring noise
+	heightDropThreshold = 1.0
+	// StallWeeks is how long a plant may go without growing before it is flagged
+	StallWeeks = 3
+	// stallTolerance is the growth in cm still counted as none
+	stallTolerance = 0.2
+	// poorMoodRun is how many poor moods in a row flag a plant
+	poorMoodRun = 2
+	// growthScanInterval is how often the scheduler scans every plant, stalls show up with time alone
+	growthScanInterval = 6 * time.Hour
+)
+
+// ErrGrowthAlertNotFound is returned when the alert does not exist or belongs to another user
+var ErrGrowthAlertNotFound = errors.New("growth alert not found")
+
+// moodScores orders the moods of growth records, including the Thai ones of older records
+var moodScores = map[string]int{"happy": 1, "ดี": 1, "neutral": 0, "ปกติ": 0, "sad": -1, "ไม่ดี": -1}
+
+// growthAlertDiagnosis is where each alert type starts the diagnosis, in the wording of plant_problem_data.json
+var growthAlertDiagnosis = map[string]struct {
+	problemPart string
+	symptoms    []string
+}{
+	models.GrowthAlertHeightDrop:    {"ลำต้น", []string{"ใบเหี่ยว", "ลำต้นเน่า"}},
+	models.GrowthAlertStalled:       {"ทั้งต้น", []string{"ใบเหลือง"}},
+	models.GrowthAlertDecliningMood: {"ใบ", []string{"ใบเหี่ยว", "ใบเหลือง"}},
+}
+
+// GrowthAnomaly is a problem found in a plant's growth records
+type GrowthAnomaly struct {
+	Type    string
+	Change  float64 // Height change in cm over the records that show it
+	Since   time.Time
+	Records int
+}
+
+// DetectGrowthAnomalies looks for a height drop in the latest record, no growth over the last StallWeeks
+// and declining moods in records sorted oldest first. Stalls are judged at now, so a plant that has
+// not been measured since the start of the window is not flagged.
+func DetectGrowthAnomalies(records []models.GrowthRecord, now time.Time) []GrowthAnomaly {
+	anomalies := []GrowthAnomaly{}
+	if len(records) == 0 {
+		return anomalies
+	}
+	last := records[len(records)-1]
+
+	// The first record's difference is from the height the plant was added with
+	if last.HeightDifference < -heightDropThreshold {
+		anomalies = append(anomalies, GrowthAnomaly{
+			Type:    models.GrowthAlertHeightDrop,
+			Change:  roundGrowth(last.HeightDifference),
+			Since:   last.Date,
+			Records: 1,
+		})
+	}
+
+	if anomaly, ok := detectStall(records, now); ok {
+		anomalies = append(anomalies, anomaly)
+	}
+	if anomaly, ok := detectDecliningMood(records); ok {
+		anomalies = append(anomalies, anomaly)
+	}
+	return anomalies
+}
+
+// detectStall compares the heights measured in the last StallWeeks with the last height from before them
+func detectStall(records []models.GrowthRecord, now time.Time) (GrowthAnomaly, bool) {
+	cutoff := now.AddDate(0, 0, -7*StallWeeks)
+	base := -1
+	for i, record := range records {
+		if record.Date.After(cutoff) {
+			break
+		}
+		base = i
+	}
+	if base < 0 || base == len(records)-1 {
+		return GrowthAnomaly{}, false
+	}
+
+	tallest := records[base].Height
+	for _, record := range records[base+1:] {
+		if record.Height > tallest {
+			tallest = record.Height
+		}
+	}
+	if tallest-records[base].Height > stallTolerance {
+		return GrowthAnomaly{}, false
+	}
+	return GrowthAnomaly{
+		Type:    models.GrowthAlertStalled,
+		Change:  roundGrowth(records[len(records)-1].Height - records[base].Height),
+		Since:   records[base].Date,
+		Records: len(records) - base,
+	}, true
+}
+
+// detectDecliningMood flags a run of poorMoodRun poor moods, or three moods each worse than the one before
+// ending in a poor one. Records without a mood are skipped.
+func detectDecliningMood(records []models.GrowthRecord) (GrowthAnomaly, bool) {
+	var moods []models.GrowthRecord
+	for _, record := range records {
+		if _, ok := moodScores[record.Mood]; ok {
+			moods = append(moods, record)
+		}
+	}
+	if len(moods) == 0 || moodScores[moods[len(moods)-1].Mood] >= 0 {
+		return GrowthAnomaly{}, false
+	}
+
+	run := 0
+	for i := len(moods) - 1; i >= 0 && moodScores[moods[i].Mood] < 0; i-- {
+		run++
+	}
+	if run < poorMoodRun {
+		n := len(moods)
+		if n < 3 || moodScores[moods[n-3].Mood] <= moodScores[moods[n-2].Mood] || moodScores[moods[n-2].Mood] <= moodScores[moods[n-1].Mood] {
+			return GrowthAnomaly{}, false
+		}
+		run = 3
+	}
+
+	first := moods[len(moods)-run]
+	return GrowthAnomaly{
+		Type:    models.GrowthAlertDecliningMood,
+		Change:  roundGrowth(moods[len(moods)-1].Height - (first.Height - first.HeightDifference)),
+		Since:   first.Date,
+		Records: run,
+	}, true
+}
+
+// DiagnosisURL links to the diagnosis form prefilled for the alert type
+func DiagnosisURL(plantID primitive.ObjectID, alertType string) string {
+	diagnosis := growthAlertDiagnosis[alertType]
+	query := url.Values{}
+	query.Set("plantId", plantID.Hex())
+	query.Set("problemPart", diagnosis.problemPart)
+	query.Set("symptoms", strings.Join(diagnosis.symptoms, ","))
+	return "/diagnosis?" + query.Encode()
+}
+
+// GrowthAlertService keeps the alerts raised by scanning plants' growth records
+type GrowthAlertService struct {
+	db            *mongo.Database
+	clock         Clock
+	notifications *NotificationService // Nil to only record alerts
+}
+
+func NewGrowthAlertService(db *mongo.Database, clock Clock, notifications *NotificationService) *GrowthAlertService {
+	return &GrowthAlertService{db: db, clock: clock, notifications: notifications}
+}
+
+func (s *GrowthAlertService) collection() *mongo.Collection {
+	return s.db.Collection("growth_alerts")
+}
+
+// EnsureIndexes allows one open alert per plant and type, and indexes the user's attention list
+func (s *GrowthAlertService) EnsureIndexes(ctx context.Context) error {
+	_, err := s.collection().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "plant_id", Value: 1}, {Key: "type", Value: 1}},
+			Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{"open": true}),
+		},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "open", Value: 1}, {Key: "detected_at", Value: -1}}},
+	})
+	if err != nil {
+		return fmt.Errorf("error creating growth alert indexes: %v", err)
+	}
+	return nil
+}
+
+// Scan checks the plant's growth records, opens alerts for new anomalies and resolves the ones that are gone.
+// The user is notified of newly opened alerts.
+func (s *GrowthAlertService) Scan(ctx context.Context, plant models.Plant) error {
+	records, err := NewGrowthService(s.db).findAscending(ctx, bson.M{"plant_id": plant.ID}, GrowthFilter{})
+	if err != nil {
+		return err
+	}
+	now := s.clock.Now()
+
+	found := []string{}
+	for _, anomaly := range DetectGrowthAnomalies(records, now) {
+		found = append(found, anomaly.Type)
+		diagnosis := growthAlertDiagnosis[anomaly.Type]
+		alert := models.GrowthAlert{
+			ID:           primitive.NewObjectID(),
+			UserID:       plant.UserID,
+			PlantID:      plant.ID,
+			PlantName:    plant.Name,
+			Type:         anomaly.Type,
+			Open:         true,
+			Change:       anomaly.Change,
+			Since:        anomaly.Since,
+			Records:      anomaly.Records,
+			ProblemPart:  diagnosis.problemPart,
+			Symptoms:     diagnosis.symptoms,
+			DiagnosisURL: DiagnosisURL(plant.ID, anomaly.Type),
+			DetectedAt:   now,
+			UpdatedAt:    now,
+		}
+
+		result, err := s.collection().UpdateOne(ctx,
+			bson.M{"plant_id": plant.ID, "type": anomaly.Type, "open": true},
+			bson.M{
+				"$set": bson.M{
+					"plant_name":    alert.PlantName,
+					"change":        alert.Change,
+					"since":         alert.Since,
+					"records":       alert.Records,
+					"problem_part":  alert.ProblemPart,
+					"symptoms":      alert.Symptoms,
+					"diagnosis_url": alert.DiagnosisURL,
+					"updated_at":    now,
+				},
+				"$setOnInsert": bson.M{"_id": alert.ID, "user_id": alert.UserID, "detected_at": now},
+			},
+			options.Update().SetUpsert(true),
+		)
+		if mongo.IsDuplicateKeyError(err) {
+			// A concurrent scan opened it first
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("error saving growth alert: %v", err)
+		}
+		if result.UpsertedCount > 0 {
+			s.notify(ctx, alert)
+		}
+	}
+
+	_, err = s.collection().UpdateMany(ctx,
+		bson.M{"plant_id": plant.ID, "open": true, "type": bson.M{"$nin": found}},
+		bson.M{"$set": bson.M{"open": false, "resolved_at": now, "updated_at": now}},
+	)
+	if err != nil {
+		return fmt.Errorf("error resolving growth alerts: %v", err)
+	}
+	return nil
+}
+
+func (s *GrowthAlertService) notify(ctx context.Context, alert models.GrowthAlert) {
+	if s.notifications == nil {
+		return
+	}
+	sent, err := s.notifications.SendGrowthAlert(ctx, alert)
+	if err != nil {
+		log.Printf("[ERROR] Error sending growth alert %s: %v", alert.ID.Hex(), err)
+		return
+	}
+	if !sent {
+		return
+	}
+	now := s.clock.Now()
+	if _, err := s.collection().UpdateOne(ctx, bson.M{"_id": alert.ID}, bson.M{"$set": bson.M{"notified_at": now}}); err != nil {
+		log.Printf("[ERROR] Error marking growth alert %s notified: %v", alert.ID.Hex(), err)
+	}
+}
+
+// ScanAll scans every plant that has growth records
+func (s *GrowthAlertService) ScanAll(ctx context.Context) error {
+	cursor, err := s.db.Collection("plants").Find(ctx,
+		bson.M{"growth_summary.record_count": bson.M{"$gt": 0}},
+		options.Find().SetProjection(bson.M{"_id": 1, "user_id": 1, "name": 1}),
+	)
+	if err != nil {
+		return fmt.Errorf("error finding plants to scan: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var plant models.Plant
+		if err := cursor.Decode(&plant); err != nil {
+			log.Printf("[ERROR] Error decoding plant to scan: %v", err)
+			continue
+		}
+		if err := s.Scan(ctx, plant); err != nil {
+			log.Printf("[ERROR] Error scanning growth of plant %s: %v", plant.ID.Hex(), err)
+		}
+	}
+	return cursor.Err()
+}
+
+// List returns the user's open alerts, most recent first. Dismissed alerts are left out unless asked for.
+func (s *GrowthAlertService) List(ctx context.Context, userID string, includeDismissed bool) ([]models.GrowthAlert, error) {
+	query := bson.M{"user_id": userID, "open": true}
+	if !includeDismissed {
+		query["dismissed_at"] = bson.M{"$exists": false}
+	}
+	cursor, err := s.collection().Find(ctx, query, options.Find().SetSort(bson.D{{Key: "detected_at", Value: -1}, {Key: "_id", Value: -1}}))
+	if err != nil {
+		return nil, fmt.Errorf("error finding growth alerts: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	alerts := []models.GrowthAlert{}
+	if err := cursor.All(ctx, &alerts); err != nil {
+		return nil, fmt.Errorf("error decoding growth alerts: %v", err)
+	}
+	return alerts, nil
+}
+
+// Dismiss hides one of the user's open alerts from the attention list. It stays open, so the same
+// anomaly is not raised again until the plant recovers.
+func (s *GrowthAlertService) Dismiss(ctx context.Context, userID string, alertID primitive.ObjectID) (*models.GrowthAlert, error) {
+	now := s.clock.Now()
+	var alert models.GrowthAlert
+	err := s.collection().FindOneAndUpdate(ctx,
+		bson.M{"_id": alertID, "user_id": userID, "open": true},
+		bson.M{"$set": bson.M{"dismissed_at": now, "updated_at": now}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&alert)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrGrowthAlertNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error dismissing growth alert: %v", err)
+	}
+	return &alert, nil
+}
+
+// DeleteForPlant removes the alerts of a deleted plant
+func (s *GrowthAlertService) DeleteForPlant(ctx context.Context, plantID primitive.ObjectID) error {
+	if _, err := s.collection().DeleteMany(ctx, bson.M{"plant_id": plantID}); err != nil {
+		return fmt.Errorf("error deleting growth alerts: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package services
+
+import (
+	"authentication/models"
+	"net/url"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func anomalyTypes(anomalies []GrowthAnomaly) map[string]GrowthAnomaly {
+	byType := make(map[string]GrowthAnomaly)
+	for _, anomaly := range anomalies {
+		byType[anomaly.Type] = anomaly
+	}
+	return byType
+}
+
+func TestDetectHeightDrop(t *testing.T) {
+	now := time.Date(2026, 3, 20, 9, 0, 0, 0, time.UTC)
+	records := []models.GrowthRecord{
+		{Height: 20, HeightDifference: 2, Date: now.AddDate(0, 0, -7)},
+		{Height: 17, HeightDifference: -3, Date: now.AddDate(0, 0, -1)},
+	}
+	anomaly, ok := anomalyTypes(DetectGrowthAnomalies(records, now))[models.GrowthAlertHeightDrop]
+	if !ok {
+		t.Fatal("height drop not detected")
+	}
+	if anomaly.Change != -3 || !anomaly.Since.Equal(records[1].Date) {
+		t.Errorf("anomaly = %+v, want a 3 cm drop since the latest record", anomaly)
+	}
+
+	// Measuring noise
+	records[1].Height, records[1].HeightDifference = 19.5, -0.5
+	if _, ok := anomalyTypes(DetectGrowthAnomalies(records, now))[models.GrowthAlertHeightDrop]; ok {
+		t.Error("a 0.5 cm drop was flagged")
+	}
+}
+
+func TestDetectStall(t *testing.T) {
+	now := time.Date(2026, 3, 30, 9, 0, 0, 0, time.UTC)
+	weeksAgo := func(weeks int) time.Time { return now.AddDate(0, 0, -7*weeks) }
+
+	stalled := []models.GrowthRecord{
+		{Height: 10, Date: weeksAgo(6)},
+		{Height: 15, Date: weeksAgo(4)},
+		{Height: 15.1, Date: weeksAgo(2)},
+		{Height: 15, Date: weeksAgo(0)},
+	}
+	anomaly, ok := anomalyTypes(DetectGrowthAnomalies(stalled, now))[models.GrowthAlertStalled]
+	if !ok {
+		t.Fatal("stall not detected")
+	}
+	if anomaly.Records != 3 || !anomaly.Since.Equal(weeksAgo(4)) || anomaly.Change != 0 {
+		t.Errorf("anomaly = %+v, want 3 records since 4 weeks ago and no change", anomaly)
+	}
+
+	growing := append([]models.GrowthRecord{}, stalled...)
+	growing[3].Height = 16
+	if _, ok := anomalyTypes(DetectGrowthAnomalies(growing, now))[models.GrowthAlertStalled]; ok {
+		t.Error("a growing plant was flagged as stalled")
+	}
+
+	// Not measured since the window started, or no history from before it
+	if _, ok := anomalyTypes(DetectGrowthAnomalies(stalled[:2], now))[models.GrowthAlertStalled]; ok {
+		t.Error("a plant without recent records was flagged as stalled")
+	}
+	if _, ok := anomalyTypes(DetectGrowthAnomalies(stalled[2:], now))[models.GrowthAlertStalled]; ok {
+		t.Error("a plant with only recent records was flagged as stalled")
+	}
+}
+
+func TestDetectDecliningMood(t *testing.T) {
+	now := time.Date(2026, 3, 20, 9, 0, 0, 0, time.UTC)
+	withMoods := func(moods ...string) []models.GrowthRecord {
+		var records []models.GrowthRecord
+		for i, mood := range moods {
+			records = append(records, models.GrowthRecord{Height: 10, Mood: mood, Date: now.AddDate(0, 0, i-len(moods))})
+		}
+		return records
+	}
+
+	tests := []struct {
+		name    string
+		moods   []string
+		want    bool
+		records int
+	}{
+		{"run of sad moods", []string{"happy", "sad", "sad"}, true, 2},
+		{"older Thai moods", []string{"ดี", "ไม่ดี", "ไม่ดี"}, true, 2},
+		{"getting worse", []string{"happy", "neutral", "sad"}, true, 3},
+		{"records without a mood are skipped", []string{"sad", "", "sad"}, true, 2},
+		{"one sad mood", []string{"neutral", "happy", "sad"}, false, 0},
+		{"recovered", []string{"sad", "sad", "happy"}, false, 0},
+		{"worse but not poor", []string{"happy", "happy", "neutral"}, false, 0},
+	}
+	for _, tt := range tests {
+		anomaly, ok := anomalyTypes(DetectGrowthAnomalies(withMoods(tt.moods...), now))[models.GrowthAlertDecliningMood]
+		if ok != tt.want {
+			t.Errorf("%s: detected = %v, want %v", tt.name, ok, tt.want)
+			continue
+		}
+		if ok && anomaly.Records != tt.records {
+			t.Errorf("%s: records = %d, want %d", tt.name, anomaly.Records, tt.records)
+		}
+	}
+}
+
+func TestDiagnosisURL(t *testing.T) {
+	plantID := primitive.NewObjectID()
+	link, err := url.Parse(DiagnosisURL(plantID, models.GrowthAlertDecliningMood))
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := link.Query()
+	if link.Path != "/diagnosis" || query.Get("plantId") != plantID.Hex() {
+		t.Errorf("DiagnosisURL() = %v", link)
+	}
+	if query.Get("problemPart") != "ใบ" || query.Get("symptoms") != "ใบเหี่ยว,ใบเหลือง" {
+		t.Errorf("DiagnosisURL() query = %v, want the symptoms of a declining mood", query)
+	}
+}
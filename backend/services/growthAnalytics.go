@@ -0,0 +1,293 @@
+package services
+
+import (
+	"authentication/models"
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Periods growth series are bucketed by
+const (
+	GrowthPeriodWeek  = "week"
+	GrowthPeriodMonth = "month"
+)
+
+const (
+	// MaxProjectionWeeks bounds how far ahead heights are projected
+	MaxProjectionWeeks = 26
+	// fastestGrowingPlants is how many plants the user analytics rank
+	fastestGrowingPlants = 5
+)
+
+// GrowthBucket is the heights recorded in one week or month
+type GrowthBucket struct {
+	Start     time.Time `json:"start"`
+	Height    float64   `json:"height"` // The latest height recorded in the bucket
+	MinHeight float64   `json:"min_height"`
+	MaxHeight float64   `json:"max_height"`
+	Growth    float64   `json:"growth"` // Since the latest height of the bucket before
+	Records   int       `json:"records"`
+}
+
+// MoodBucket is how often each mood was recorded in one week or month
+type MoodBucket struct {
+	Start time.Time      `json:"start"`
+	Moods map[string]int `json:"moods"`
+}
+
+// HeightProjection is a projected future height
+type HeightProjection struct {
+	Date   time.Time `json:"date"`
+	Height float64   `json:"height"`
+}
+
+// GrowthAnalyticsOptions selects the records and how they are summarized
+type GrowthAnalyticsOptions struct {
+	Period          string
+	Filter          GrowthFilter
+	ProjectionWeeks int
+}
+
+// PlantGrowthAnalytics are the charts of one plant
+type PlantGrowthAnalytics struct {
+	PlantID    string             `json:"plant_id"`
+	Name       string             `json:"name"`
+	Period     string             `json:"period"`
+	Records    int                `json:"records"`
+	GrowthRate *float64           `json:"growth_rate"` // cm per week; null with too few records to tell
+	Series     []GrowthBucket     `json:"series"`
+	Moods      map[string]int     `json:"moods"`
+	MoodSeries []MoodBucket       `json:"mood_series"`
+	Projection []HeightProjection `json:"projection"` // Linear, from the growth rate
+}
+
+// PlantGrowthRate is one plant in the user's analytics
+type PlantGrowthRate struct {
+	PlantID    string   `json:"plant_id"`
+	Name       string   `json:"name"`
+	Height     float64  `json:"height"`
+	Records    int      `json:"records"`
+	GrowthRate *float64 `json:"growth_rate"`
+}
+
+// UserGrowthAnalytics compare all of a user's plants
+type UserGrowthAnalytics struct {
+	Period            string            `json:"period"`
+	Plants            []PlantGrowthRate `json:"plants"`
+	FastestGrowing    []PlantGrowthRate `json:"fastest_growing"`
+	AverageGrowthRate *float64          `json:"average_growth_rate"` // Over the plants that have a rate
+	Moods             map[string]int    `json:"moods"`
+	MoodSeries        []MoodBucket      `json:"mood_series"`
+}
+
+// PlantAnalytics computes the charts of one plant from its growth records
+func (s *GrowthService) PlantAnalytics(ctx context.Context, plant models.Plant, opts GrowthAnalyticsOptions, loc *time.Location) (*PlantGrowthAnalytics, error) {
+	records, err := s.findAscending(ctx, bson.M{"plant_id": plant.ID}, opts.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	analytics := &PlantGrowthAnalytics{
+		PlantID:    plant.ID.Hex(),
+		Name:       plant.Name,
+		Period:     opts.Period,
+		Records:    len(records),
+		Series:     BucketGrowth(records, opts.Period, loc),
+		Moods:      countMoods(records),
+		MoodSeries: BucketMoods(records, opts.Period, loc),
+		Projection: []HeightProjection{},
+	}
+	if slope, intercept, ok := growthTrend(records); ok {
+		rate := roundGrowth(slope)
+		analytics.GrowthRate = &rate
+		analytics.Projection = ProjectHeight(records, slope, intercept, opts.ProjectionWeeks)
+	}
+	return analytics, nil
+}
+
+// UserAnalytics ranks the user's plants by growth rate and summarizes their moods
+func (s *GrowthService) UserAnalytics(ctx context.Context, userID string, plants []models.Plant, opts GrowthAnalyticsOptions, loc *time.Location) (*UserGrowthAnalytics, error) {
+	records, err := s.findAscending(ctx, bson.M{"user_id": userID}, opts.Filter)
+	if err != nil {
+		return nil, err
+	}
+	byPlant := make(map[primitive.ObjectID][]models.GrowthRecord)
+	for _, record := range records {
+		byPlant[record.PlantID] = append(byPlant[record.PlantID], record)
+	}
+
+	analytics := &UserGrowthAnalytics{
+		Period:         opts.Period,
+		Plants:         []PlantGrowthRate{},
+		FastestGrowing: []PlantGrowthRate{},
+		Moods:          countMoods(records),
+		MoodSeries:     BucketMoods(records, opts.Period, loc),
+	}
+	var total float64
+	var rated int
+	for _, plant := range plants {
+		plantRecords := byPlant[plant.ID]
+		rate := PlantGrowthRate{PlantID: plant.ID.Hex(), Name: plant.Name, Height: plant.PlantHeight, Records: len(plantRecords)}
+		if slope, _, ok := growthTrend(plantRecords); ok {
+			value := roundGrowth(slope)
+			rate.GrowthRate = &value
+			total += slope
+			rated++
+			analytics.FastestGrowing = append(analytics.FastestGrowing, rate)
+		}
+		analytics.Plants = append(analytics.Plants, rate)
+	}
+	if rated > 0 {
+		average := roundGrowth(total / float64(rated))
+		analytics.AverageGrowthRate = &average
+	}
+
+	sort.SliceStable(analytics.FastestGrowing, func(i, j int) bool {
+		return *analytics.FastestGrowing[i].GrowthRate > *analytics.FastestGrowing[j].GrowthRate
+	})
+	if len(analytics.FastestGrowing) > fastestGrowingPlants {
+		analytics.FastestGrowing = analytics.FastestGrowing[:fastestGrowingPlants]
+	}
+	return analytics, nil
+}
+
+// findAscending returns the matching records oldest first
+func (s *GrowthService) findAscending(ctx context.Context, query bson.M, filter GrowthFilter) ([]models.GrowthRecord, error) {
+	date := bson.M{}
+	if !filter.From.IsZero() {
+		date["$gte"] = filter.From
+	}
+	if !filter.To.IsZero() {
+		date["$lt"] = filter.To
+	}
+	if len(date) > 0 {
+		query["date"] = date
+	}
+
+	cursor, err := s.collection().Find(ctx, query,
+		options.Find().SetSort(bson.D{{Key: "date", Value: 1}, {Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("error finding growth records: %v", err)
+	}
+	var records []models.GrowthRecord
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, fmt.Errorf("error decoding growth records: %v", err)
+	}
+	return records, nil
+}
+
+// bucketStart is the start of the week (Monday) or month t falls in
+func bucketStart(t time.Time, period string, loc *time.Location) time.Time {
+	t = t.In(loc)
+	if period == GrowthPeriodMonth {
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, loc)
+	}
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+	return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+}
+
+// BucketGrowth groups records sorted oldest first into weeks or months. Buckets without records are left out.
+func BucketGrowth(records []models.GrowthRecord, period string, loc *time.Location) []GrowthBucket {
+	buckets := []GrowthBucket{}
+	if len(records) == 0 {
+		return buckets
+	}
+
+	// The first bucket's growth is counted from the height before the first record
+	previous := records[0].Height - records[0].HeightDifference
+	for _, record := range records {
+		start := bucketStart(record.Date, period, loc)
+		if len(buckets) == 0 || !buckets[len(buckets)-1].Start.Equal(start) {
+			if len(buckets) > 0 {
+				previous = buckets[len(buckets)-1].Height
+			}
+			buckets = append(buckets, GrowthBucket{Start: start, MinHeight: record.Height, MaxHeight: record.Height})
+		}
+		bucket := &buckets[len(buckets)-1]
+		bucket.Height = record.Height
+		bucket.MinHeight = math.Min(bucket.MinHeight, record.Height)
+		bucket.MaxHeight = math.Max(bucket.MaxHeight, record.Height)
+		bucket.Growth = roundGrowth(record.Height - previous)
+		bucket.Records++
+	}
+	return buckets
+}
+
+// BucketMoods counts the recorded moods per week or month of records sorted oldest first
+func BucketMoods(records []models.GrowthRecord, period string, loc *time.Location) []MoodBucket {
+	buckets := []MoodBucket{}
+	for _, record := range records {
+		if record.Mood == "" {
+			continue
+		}
+		start := bucketStart(record.Date, period, loc)
+		if len(buckets) == 0 || !buckets[len(buckets)-1].Start.Equal(start) {
+			buckets = append(buckets, MoodBucket{Start: start, Moods: make(map[string]int)})
+		}
+		buckets[len(buckets)-1].Moods[record.Mood]++
+	}
+	return buckets
+}
+
+func countMoods(records []models.GrowthRecord) map[string]int {
+	moods := make(map[string]int)
+	for _, record := range records {
+		if record.Mood != "" {
+			moods[record.Mood]++
+		}
+	}
+	return moods
+}
+
+// growthTrend fits height = intercept + slope*weeks by least squares, with weeks counted from
+// the first record. It needs records at least a day apart.
+func growthTrend(records []models.GrowthRecord) (slope, intercept float64, ok bool) {
+	if len(records) < 2 || records[len(records)-1].Date.Sub(records[0].Date) < 24*time.Hour {
+		return 0, 0, false
+	}
+	first := records[0].Date
+	n := float64(len(records))
+	var sumX, sumY float64
+	for _, record := range records {
+		sumX += record.Date.Sub(first).Hours() / (24 * 7)
+		sumY += record.Height
+	}
+	meanX, meanY := sumX/n, sumY/n
+
+	var covariance, variance float64
+	for _, record := range records {
+		dx := record.Date.Sub(first).Hours()/(24*7) - meanX
+		covariance += dx * (record.Height - meanY)
+		variance += dx * dx
+	}
+	slope = covariance / variance
+	return slope, meanY - slope*meanX, true
+}
+
+// ProjectHeight extends the trend weekly for the given number of weeks after the latest record.
+// Heights never go below zero.
+func ProjectHeight(records []models.GrowthRecord, slope, intercept float64, weeks int) []HeightProjection {
+	projection := []HeightProjection{}
+	if len(records) == 0 {
+		return projection
+	}
+	first, last := records[0].Date, records[len(records)-1].Date
+	for week := 1; week <= weeks; week++ {
+		date := last.AddDate(0, 0, 7*week)
+		height := intercept + slope*date.Sub(first).Hours()/(24*7)
+		projection = append(projection, HeightProjection{Date: date, Height: roundGrowth(math.Max(height, 0))})
+	}
+	return projection
+}
+
+// roundGrowth rounds to millimetres
+func roundGrowth(cm float64) float64 {
+	return math.Round(cm*10) / 10
+}
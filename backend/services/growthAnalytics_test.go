@@ -0,0 +1,100 @@
+package services
+
+import (
+	"authentication/models"
+	"testing"
+	"time"
+)
+
+func TestBucketGrowth(t *testing.T) {
+	loc, _ := time.LoadLocation("Asia/Bangkok")
+	at := func(month time.Month, day int) time.Time { return time.Date(2026, month, day, 9, 0, 0, 0, loc) }
+	records := []models.GrowthRecord{
+		{Height: 12, HeightDifference: 2, Date: at(3, 2)}, // Monday
+		{Height: 13, HeightDifference: 1, Date: at(3, 8)}, // Sunday, same week
+		{Height: 15, HeightDifference: 2, Date: at(3, 9)}, // Next Monday
+		{Height: 20, HeightDifference: 5, Date: at(4, 1)},
+	}
+
+	weeks := BucketGrowth(records, GrowthPeriodWeek, loc)
+	if len(weeks) != 3 {
+		t.Fatalf("got %d weekly buckets, want 3: %+v", len(weeks), weeks)
+	}
+	if !weeks[0].Start.Equal(time.Date(2026, 3, 2, 0, 0, 0, 0, loc)) {
+		t.Errorf("first week starts %v, want Monday 2 March", weeks[0].Start)
+	}
+	if weeks[0].Height != 13 || weeks[0].MinHeight != 12 || weeks[0].Records != 2 {
+		t.Errorf("first week = %+v, want latest 13, min 12, 2 records", weeks[0])
+	}
+	// Counted from the height before the first record
+	if weeks[0].Growth != 3 {
+		t.Errorf("first week growth = %v, want 3", weeks[0].Growth)
+	}
+	if weeks[1].Growth != 2 || weeks[2].Growth != 5 {
+		t.Errorf("growth = %v, %v, want 2, 5", weeks[1].Growth, weeks[2].Growth)
+	}
+
+	months := BucketGrowth(records, GrowthPeriodMonth, loc)
+	if len(months) != 2 || months[0].Height != 15 || months[1].Growth != 5 {
+		t.Errorf("monthly buckets = %+v", months)
+	}
+}
+
+func TestBucketMoods(t *testing.T) {
+	loc, _ := time.LoadLocation("Asia/Bangkok")
+	records := []models.GrowthRecord{
+		{Mood: "happy", Date: time.Date(2026, 3, 2, 9, 0, 0, 0, loc)},
+		{Mood: "happy", Date: time.Date(2026, 3, 3, 9, 0, 0, 0, loc)},
+		{Mood: "", Date: time.Date(2026, 3, 4, 9, 0, 0, 0, loc)},
+		{Mood: "sad", Date: time.Date(2026, 3, 10, 9, 0, 0, 0, loc)},
+	}
+
+	buckets := BucketMoods(records, GrowthPeriodWeek, loc)
+	if len(buckets) != 2 {
+		t.Fatalf("got %d buckets, want 2", len(buckets))
+	}
+	if buckets[0].Moods["happy"] != 2 || len(buckets[0].Moods) != 1 {
+		t.Errorf("first week moods = %v, want happy: 2", buckets[0].Moods)
+	}
+	if buckets[1].Moods["sad"] != 1 {
+		t.Errorf("second week moods = %v, want sad: 1", buckets[1].Moods)
+	}
+}
+
+func TestGrowthTrendAndProjection(t *testing.T) {
+	start := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+
+	// 2 cm a week
+	var records []models.GrowthRecord
+	for week := 0; week < 4; week++ {
+		records = append(records, models.GrowthRecord{Height: 10 + 2*float64(week), Date: start.AddDate(0, 0, 7*week)})
+	}
+	slope, intercept, ok := growthTrend(records)
+	if !ok {
+		t.Fatal("growthTrend() found no trend")
+	}
+	if roundGrowth(slope) != 2 || roundGrowth(intercept) != 10 {
+		t.Fatalf("growthTrend() = %v, %v, want 2, 10", slope, intercept)
+	}
+
+	projection := ProjectHeight(records, slope, intercept, 2)
+	if len(projection) != 2 || projection[0].Height != 18 || projection[1].Height != 20 {
+		t.Fatalf("ProjectHeight() = %+v, want 18 and 20", projection)
+	}
+	if !projection[0].Date.Equal(start.AddDate(0, 0, 28)) {
+		t.Errorf("first projection on %v, want a week after the last record", projection[0].Date)
+	}
+
+	// A shrinking plant is never projected below zero
+	shrinking := []models.GrowthRecord{{Height: 4, Date: start}, {Height: 1, Date: start.AddDate(0, 0, 7)}}
+	slope, intercept, _ = growthTrend(shrinking)
+	if got := ProjectHeight(shrinking, slope, intercept, 2); got[1].Height != 0 {
+		t.Errorf("ProjectHeight() = %+v, want 0 at the end", got)
+	}
+
+	// Records on the same day don't make a trend
+	sameDay := []models.GrowthRecord{{Height: 4, Date: start}, {Height: 6, Date: start.Add(time.Hour)}}
+	if _, _, ok := growthTrend(sameDay); ok {
+		t.Error("growthTrend() found a trend within one day")
+	}
+}
@@ -0,0 +1,397 @@
+package services
+
+import (
+	"authentication/models"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// RecentGrowthRecords is how many records are returned with a single plant
+const RecentGrowthRecords = 10
+
+var (
+	// ErrGrowthRecordNotFound is returned when the record does not exist on the plant
+	ErrGrowthRecordNotFound = errors.New("growth record not found")
+	// ErrInvalidGrowthCursor is returned when a history cursor can't be decoded
+	ErrInvalidGrowthCursor = errors.New("invalid growth history cursor")
+)
+
+// GrowthFilter narrows a plant's growth history by record date; zero bounds are open
+type GrowthFilter struct {
+	From time.Time // Inclusive
+	To   time.Time // Exclusive
+}
+
+// GrowthService keeps plant growth records in their own collection and the summary on the plant
+type GrowthService struct {
+	db *mongo.Database
+}
+
+func NewGrowthService(db *mongo.Database) *GrowthService {
+	return &GrowthService{db: db}
+}
+
+func (s *GrowthService) collection() *mongo.Collection {
+	return s.db.Collection("growth_records")
+}
+
+// EnsureIndexes creates the index the history is paged through
+func (s *GrowthService) EnsureIndexes(ctx context.Context) error {
+	_, err := s.collection().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "plant_id", Value: 1}, {Key: "date", Value: -1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "date", Value: -1}}},
+	})
+	if err != nil {
+		return fmt.Errorf("error creating growth record indexes: %v", err)
+	}
+	return nil
+}
+
+// Add stores a record for the plant and recomputes the height differences around it
+func (s *GrowthService) Add(ctx context.Context, plant models.Plant, record models.GrowthRecord, now time.Time) (*models.GrowthRecord, error) {
+	// The plant's height before its first record is the base of the first height difference
+	_, err := s.db.Collection("plants").UpdateOne(ctx,
+		bson.M{
+			"_id":                         plant.ID,
+			"growth_base_height":          bson.M{"$exists": false},
+			"growth_summary.record_count": bson.M{"$not": bson.M{"$gt": 0}},
+		},
+		bson.M{"$set": bson.M{"growth_base_height": plant.PlantHeight}},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error setting growth base height: %v", err)
+	}
+
+	record.ID = primitive.NewObjectID()
+	record.PlantID = plant.ID
+	record.UserID = plant.UserID
+	record.HeightDifference = 0 // Set by settle
+	record.CreatedAt = now
+	if _, err := s.collection().InsertOne(ctx, record); err != nil {
+		return nil, fmt.Errorf("error saving growth record: %v", err)
+	}
+	if err := s.settle(ctx, plant.ID, now); err != nil {
+		return nil, err
+	}
+	return s.Get(ctx, plant.ID, record.ID)
+}
+
+// Update edits the height, mood, notes and date of one of the plant's records
+func (s *GrowthService) Update(ctx context.Context, plantID, recordID primitive.ObjectID, update models.GrowthRecord, now time.Time) (*models.GrowthRecord, error) {
+	result, err := s.collection().UpdateOne(ctx,
+		bson.M{"_id": recordID, "plant_id": plantID},
+		bson.M{"$set": bson.M{
+			"height": update.Height,
+			"mood":   update.Mood,
+			"notes":  update.Notes,
+			"date":   update.Date,
+		}},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error updating growth record: %v", err)
+	}
+	if result.MatchedCount == 0 {
+		return nil, ErrGrowthRecordNotFound
+	}
+	if err := s.settle(ctx, plantID, now); err != nil {
+		return nil, err
+	}
+	return s.Get(ctx, plantID, recordID)
+}
+
+// Delete removes one of the plant's records; the next record's difference is then taken from the previous one
+func (s *GrowthService) Delete(ctx context.Context, plantID, recordID primitive.ObjectID, now time.Time) error {
+	result, err := s.collection().DeleteOne(ctx, bson.M{"_id": recordID, "plant_id": plantID})
+	if err != nil {
+		return fmt.Errorf("error deleting growth record: %v", err)
+	}
+	if result.DeletedCount == 0 {
+		return ErrGrowthRecordNotFound
+	}
+	return s.settle(ctx, plantID, now)
+}
+
+// Get returns one of the plant's records
+func (s *GrowthService) Get(ctx context.Context, plantID, recordID primitive.ObjectID) (*models.GrowthRecord, error) {
+	var record models.GrowthRecord
+	err := s.collection().FindOne(ctx, bson.M{"_id": recordID, "plant_id": plantID}).Decode(&record)
+	if err == mongo.ErrNoDocuments {
+		// Not on this plant, or deleted by a concurrent request
+		return nil, ErrGrowthRecordNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error finding growth record: %v", err)
+	}
+	return &record, nil
+}
+
+// DeleteForPlant removes all records of a deleted plant
+func (s *GrowthService) DeleteForPlant(ctx context.Context, plantID primitive.ObjectID) error {
+	if _, err := s.collection().DeleteMany(ctx, bson.M{"plant_id": plantID}); err != nil {
+		return fmt.Errorf("error deleting growth records: %v", err)
+	}
+	return nil
+}
+
+// List returns up to limit of the plant's records, latest first, starting after cursor
+// (empty for the first page). The returned cursor is empty on the last page.
+func (s *GrowthService) List(ctx context.Context, plantID primitive.ObjectID, filter GrowthFilter, cursor string, limit int64) ([]models.GrowthRecord, string, error) {
+	query := bson.M{"plant_id": plantID}
+	date := bson.M{}
+	if !filter.From.IsZero() {
+		date["$gte"] = filter.From
+	}
+	if !filter.To.IsZero() {
+		date["$lt"] = filter.To
+	}
+	if len(date) > 0 {
+		query["date"] = date
+	}
+	if cursor != "" {
+		afterDate, afterID, err := decodeGrowthCursor(cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		query["$or"] = bson.A{
+			bson.M{"date": bson.M{"$lt": afterDate}},
+			bson.M{"date": afterDate, "_id": bson.M{"$lt": afterID}},
+		}
+	}
+
+	// One extra record tells whether there is a next page
+	opts := options.Find().
+		SetSort(bson.D{{Key: "date", Value: -1}, {Key: "_id", Value: -1}}).
+		SetLimit(limit + 1)
+	found, err := s.collection().Find(ctx, query, opts)
+	if err != nil {
+		return nil, "", fmt.Errorf("error finding growth records: %v", err)
+	}
+	defer found.Close(ctx)
+
+	records := []models.GrowthRecord{}
+	if err := found.All(ctx, &records); err != nil {
+		return nil, "", fmt.Errorf("error decoding growth records: %v", err)
+	}
+	if int64(len(records)) <= limit {
+		return records, "", nil
+	}
+	records = records[:limit]
+	last := records[len(records)-1]
+	return records, encodeGrowthCursor(last.Date, last.ID), nil
+}
+
+// Since returns the plant's records dated from since on, for signals that look back over a period
+func (s *GrowthService) Since(ctx context.Context, plantID primitive.ObjectID, since time.Time) ([]models.GrowthRecord, error) {
+	cursor, err := s.collection().Find(ctx, bson.M{"plant_id": plantID, "date": bson.M{"$gte": since}})
+	if err != nil {
+		return nil, fmt.Errorf("error finding growth records: %v", err)
+	}
+	var records []models.GrowthRecord
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, fmt.Errorf("error decoding growth records: %v", err)
+	}
+	return records, nil
+}
+
+// growthSettleAttempts bounds how often settle starts over when other changes keep coming in
+const growthSettleAttempts = 5
+
+// settle recomputes the height differences of all the plant's records in date order and its growth
+// summary. It is run after every change. The change bumps the plant's growth version first; if the
+// version moved on while settling, another change may have been written over, so it starts over.
+// Whichever change comes last therefore settles on a snapshot that includes all of them.
+func (s *GrowthService) settle(ctx context.Context, plantID primitive.ObjectID, now time.Time) error {
+	var plant models.Plant
+	err := s.db.Collection("plants").FindOneAndUpdate(ctx,
+		bson.M{"_id": plantID},
+		bson.M{"$inc": bson.M{"growth_version": 1}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&plant)
+	if err == mongo.ErrNoDocuments {
+		// The plant was deleted together with its records
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error bumping growth version: %v", err)
+	}
+
+	for attempt := 0; attempt < growthSettleAttempts; attempt++ {
+		if err := s.writeDifferences(ctx, plant, now); err != nil {
+			return err
+		}
+
+		var current models.Plant
+		err := s.db.Collection("plants").FindOne(ctx, bson.M{"_id": plantID}).Decode(&current)
+		if err == mongo.ErrNoDocuments {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error checking growth version: %v", err)
+		}
+		if current.GrowthVersion == plant.GrowthVersion {
+			return nil
+		}
+		plant = current
+	}
+	log.Printf("[ERROR] Growth records of plant %s kept changing, leaving the last settle to the latest change", plantID.Hex())
+	return nil
+}
+
+// writeDifferences recomputes and stores the height differences and the summary from the current records
+func (s *GrowthService) writeDifferences(ctx context.Context, plant models.Plant, now time.Time) error {
+	cursor, err := s.collection().Find(ctx, bson.M{"plant_id": plant.ID},
+		options.Find().SetSort(bson.D{{Key: "date", Value: 1}, {Key: "_id", Value: 1}}))
+	if err != nil {
+		return fmt.Errorf("error finding growth records: %v", err)
+	}
+	var records []models.GrowthRecord
+	if err := cursor.All(ctx, &records); err != nil {
+		return fmt.Errorf("error decoding growth records: %v", err)
+	}
+
+	set := bson.M{"updated_at": now}
+	base := plant.GrowthBaseHeight
+	if base == nil && len(records) > 0 {
+		// Records from before the base height was kept: the earliest one still has its original difference
+		derived := records[0].Height - records[0].HeightDifference
+		base = &derived
+		set["growth_base_height"] = derived
+	}
+
+	var writes []mongo.WriteModel
+	for i, difference := range HeightDifferences(records, base) {
+		if records[i].HeightDifference == difference {
+			continue
+		}
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": records[i].ID}).
+			SetUpdate(bson.M{"$set": bson.M{"height_difference": difference}}))
+	}
+	if len(writes) > 0 {
+		if _, err := s.collection().BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false)); err != nil {
+			return fmt.Errorf("error updating height differences: %v", err)
+		}
+	}
+
+	if len(records) == 0 {
+		set["growth_summary"] = models.GrowthSummary{}
+		if base != nil {
+			set["plant_height"] = *base
+		}
+	} else {
+		latest := records[len(records)-1]
+		set["growth_summary"] = models.GrowthSummary{
+			RecordCount:  int64(len(records)),
+			LastRecordID: latest.ID,
+			LastHeight:   latest.Height,
+			LastMood:     latest.Mood,
+			LastDate:     latest.Date,
+		}
+		set["plant_height"] = latest.Height
+	}
+	update := bson.M{"$set": set, "$inc": bson.M{"version": 1}}
+	if _, err := s.db.Collection("plants").UpdateOne(ctx, bson.M{"_id": plant.ID}, update); err != nil {
+		return fmt.Errorf("error updating growth summary: %v", err)
+	}
+	return nil
+}
+
+// HeightDifferences returns each record's height difference from the record before it. records must be
+// sorted by date, then ID. The first record is compared with base, or keeps its own difference without one.
+func HeightDifferences(records []models.GrowthRecord, base *float64) []float64 {
+	differences := make([]float64, len(records))
+	for i, record := range records {
+		switch {
+		case i > 0:
+			differences[i] = record.Height - records[i-1].Height
+		case base != nil:
+			differences[i] = record.Height - *base
+		default:
+			differences[i] = record.HeightDifference
+		}
+	}
+	return differences
+}
+
+// MigrateEmbeddedRecords moves growth records still embedded in plant documents into the
+// growth_records collection. Records keep their IDs, so running it again is harmless.
+func (s *GrowthService) MigrateEmbeddedRecords(ctx context.Context) (int, error) {
+	cursor, err := s.db.Collection("plants").Find(ctx, bson.M{"growth_records.0": bson.M{"$exists": true}})
+	if err != nil {
+		return 0, fmt.Errorf("error finding plants with embedded growth records: %v", err)
+	}
+	var plants []models.Plant
+	if err := cursor.All(ctx, &plants); err != nil {
+		return 0, fmt.Errorf("error decoding plants: %v", err)
+	}
+
+	migrated := 0
+	now := time.Now()
+	for _, plant := range plants {
+		var writes []mongo.WriteModel
+		for _, record := range plant.GrowthRecords {
+			if record.ID.IsZero() {
+				record.ID = primitive.NewObjectID()
+			}
+			record.PlantID = plant.ID
+			record.UserID = plant.UserID
+			writes = append(writes, mongo.NewReplaceOneModel().
+				SetFilter(bson.M{"_id": record.ID}).
+				SetReplacement(record).
+				SetUpsert(true))
+		}
+		if _, err := s.collection().BulkWrite(ctx, writes); err != nil {
+			log.Printf("[ERROR] Error migrating growth records of plant %s: %v", plant.ID.Hex(), err)
+			continue
+		}
+
+		_, err := s.db.Collection("plants").UpdateOne(ctx,
+			bson.M{"_id": plant.ID},
+			bson.M{"$unset": bson.M{"growth_records": ""}},
+		)
+		if err != nil {
+			log.Printf("[ERROR] Error removing embedded growth records of plant %s: %v", plant.ID.Hex(), err)
+			continue
+		}
+		if err := s.settle(ctx, plant.ID, now); err != nil {
+			log.Printf("[ERROR] %v", err)
+		}
+		migrated++
+	}
+	return migrated, nil
+}
+
+// encodeGrowthCursor makes the opaque cursor pointing after the given record
+func encodeGrowthCursor(date time.Time, id primitive.ObjectID) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(date.UTC().Format(time.RFC3339Nano) + "|" + id.Hex()))
+}
+
+func decodeGrowthCursor(cursor string) (time.Time, primitive.ObjectID, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, primitive.NilObjectID, ErrInvalidGrowthCursor
+	}
+	dateText, idText, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return time.Time{}, primitive.NilObjectID, ErrInvalidGrowthCursor
+	}
+	date, err := time.Parse(time.RFC3339Nano, dateText)
+	if err != nil {
+		return time.Time{}, primitive.NilObjectID, ErrInvalidGrowthCursor
+	}
+	id, err := primitive.ObjectIDFromHex(idText)
+	if err != nil {
+		return time.Time{}, primitive.NilObjectID, ErrInvalidGrowthCursor
+	}
+	return date, id, nil
+}
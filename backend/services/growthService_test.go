@@ -0,0 +1,213 @@
+package services
+
+import (
+	"authentication/models"
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func TestHeightDifferences(t *testing.T) {
+	base := 10.0
+	day := func(d int) time.Time { return time.Date(2026, 3, d, 8, 0, 0, 0, time.UTC) }
+	records := func(heights ...float64) []models.GrowthRecord {
+		var out []models.GrowthRecord
+		for i, height := range heights {
+			out = append(out, models.GrowthRecord{Height: height, Date: day(i + 1), HeightDifference: 99})
+		}
+		return out
+	}
+
+	tests := []struct {
+		name    string
+		records []models.GrowthRecord
+		base    *float64
+		want    []float64
+	}{
+		{"empty", nil, &base, []float64{}},
+		{"from base", records(12, 15, 14), &base, []float64{2, 3, -1}},
+		// Deleting the middle record of 12, 15, 14 compares 14 with 12
+		{"middle removed", records(12, 14), &base, []float64{2, 2}},
+		// Without a base the first record keeps the difference it was stored with
+		{"no base", records(12, 15), nil, []float64{99, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := HeightDifferences(tt.records, tt.base)
+			if len(got) != len(tt.want) {
+				t.Fatalf("HeightDifferences() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("HeightDifferences() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+// growthFixture is a plant of 10 cm with no records yet
+type growthFixture struct {
+	db      *mongo.Database
+	service *GrowthService
+	plant   models.Plant
+	now     time.Time
+}
+
+func newGrowthFixture(t *testing.T) *growthFixture {
+	t.Helper()
+	db := newTestDatabase(t)
+	f := &growthFixture{
+		db:      db,
+		service: NewGrowthService(db),
+		plant:   models.Plant{ID: primitive.NewObjectID(), UserID: "user-1", Name: "Monstera", PlantHeight: 10},
+		now:     time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC),
+	}
+	if _, err := db.Collection("plants").InsertOne(context.Background(), f.plant); err != nil {
+		t.Fatalf("inserting plant: %v", err)
+	}
+	return f
+}
+
+// checkConsistent asserts that every difference follows date order and the summary matches the records
+func (f *growthFixture) checkConsistent(t *testing.T, wantCount int) []models.GrowthRecord {
+	t.Helper()
+	ctx := context.Background()
+	cursor, err := f.db.Collection("growth_records").Find(ctx, bson.M{"plant_id": f.plant.ID},
+		options.Find().SetSort(bson.D{{Key: "date", Value: 1}, {Key: "_id", Value: 1}}))
+	if err != nil {
+		t.Fatalf("finding records: %v", err)
+	}
+	var records []models.GrowthRecord
+	if err := cursor.All(ctx, &records); err != nil {
+		t.Fatalf("decoding records: %v", err)
+	}
+	if len(records) != wantCount {
+		t.Fatalf("got %d records, want %d", len(records), wantCount)
+	}
+
+	previous := f.plant.PlantHeight
+	for _, record := range records {
+		if record.HeightDifference != record.Height-previous {
+			t.Fatalf("record %v has difference %v, want %v", record.Height, record.HeightDifference, record.Height-previous)
+		}
+		previous = record.Height
+	}
+
+	var plant models.Plant
+	if err := f.db.Collection("plants").FindOne(ctx, bson.M{"_id": f.plant.ID}).Decode(&plant); err != nil {
+		t.Fatalf("finding plant: %v", err)
+	}
+	if plant.GrowthSummary == nil || plant.GrowthSummary.RecordCount != int64(wantCount) {
+		t.Fatalf("summary = %+v, want %d records", plant.GrowthSummary, wantCount)
+	}
+	if plant.PlantHeight != previous {
+		t.Fatalf("plant height = %v, want %v", plant.PlantHeight, previous)
+	}
+	return records
+}
+
+func TestGrowthConcurrentAdds(t *testing.T) {
+	f := newGrowthFixture(t)
+	const n = 20
+
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			record := models.GrowthRecord{Height: float64(11 + i), Date: f.now.AddDate(0, 0, i)}
+			_, err := f.service.Add(context.Background(), f.plant, record, f.now)
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+	}
+
+	f.checkConsistent(t, n)
+}
+
+func TestGrowthConcurrentDeletesAndAdds(t *testing.T) {
+	f := newGrowthFixture(t)
+	ctx := context.Background()
+
+	var added []*models.GrowthRecord
+	for i := 0; i < 10; i++ {
+		record, err := f.service.Add(ctx, f.plant, models.GrowthRecord{Height: float64(11 + i), Date: f.now.AddDate(0, 0, 2*i)}, f.now)
+		if err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+		added = append(added, record)
+	}
+
+	// Delete every other record while new ones land between the remaining ones
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i += 2 {
+		wg.Add(2)
+		go func(record *models.GrowthRecord) {
+			defer wg.Done()
+			errs <- f.service.Delete(ctx, f.plant.ID, record.ID, f.now)
+		}(added[i])
+		go func(i int) {
+			defer wg.Done()
+			record := models.GrowthRecord{Height: float64(30 + i), Date: f.now.AddDate(0, 0, 2*i+1)}
+			_, err := f.service.Add(ctx, f.plant, record, f.now)
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("concurrent change: %v", err)
+		}
+	}
+
+	f.checkConsistent(t, 10)
+}
+
+func TestGrowthUpdateMovesRecord(t *testing.T) {
+	f := newGrowthFixture(t)
+	ctx := context.Background()
+
+	var added []*models.GrowthRecord
+	for i, height := range []float64{12, 15, 18} {
+		record, err := f.service.Add(ctx, f.plant, models.GrowthRecord{Height: height, Date: f.now.AddDate(0, 0, i)}, f.now)
+		if err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+		added = append(added, record)
+	}
+
+	// Moving the latest record before the others changes every difference
+	moved := models.GrowthRecord{Height: 11, Date: f.now.AddDate(0, 0, -1)}
+	if _, err := f.service.Update(ctx, f.plant.ID, added[2].ID, moved, f.now); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	records := f.checkConsistent(t, 3)
+	if records[0].ID != added[2].ID {
+		t.Fatalf("first record = %v, want the moved one", records[0].ID)
+	}
+
+	if err := f.service.Delete(ctx, f.plant.ID, added[0].ID, f.now); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	f.checkConsistent(t, 2)
+
+	if err := f.service.Delete(ctx, f.plant.ID, added[0].ID, f.now); err != ErrGrowthRecordNotFound {
+		t.Fatalf("Delete again = %v, want ErrGrowthRecordNotFound", err)
+	}
+}
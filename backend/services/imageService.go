@@ -0,0 +1,333 @@
+package services
+
+import (
+	"authentication/models"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // registers the WebP decoder
+)
+
+const (
+	// maxImageSize is the largest upload accepted
+	maxImageSize = 10 << 20
+	// maxImagePixels bounds the decoded image, so a small file cannot expand into gigabytes of memory
+	maxImagePixels = 50_000_000
+	jpegQuality    = 85
+)
+
+// ErrInvalidImage is returned for uploads that are not a supported image or are too large
+var ErrInvalidImage = errors.New("invalid image")
+
+// acceptedImageTypes are the upload types accepted, by the content type sniffed from the data
+var acceptedImageTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/webp": true,
+}
+
+// imageSizes are the standard sizes stored for every upload, by their longest side in pixels.
+// Full comes first, the smaller sizes are made from it. Images are never enlarged.
+var imageSizes = []struct {
+	name    string
+	maxSide int
+	url     func(*models.ImageURLs) *string
+}{
+	{"full", 2560, func(urls *models.ImageURLs) *string { return &urls.Full }},
+	{"medium", 1200, func(urls *models.ImageURLs) *string { return &urls.Medium }},
+	{"thumbnail", 400, func(urls *models.ImageURLs) *string { return &urls.Thumbnail }},
+}
+
+// StoredImage is an image in the image store with its standard sizes
+type StoredImage struct {
+	Key  string // Key of the full size; deleting it deletes the other sizes too
+	URLs models.ImageURLs
+}
+
+// ImageService checks uploaded images and stores them in the standard sizes. Every upload is decoded
+// and encoded again, which leaves out EXIF data such as the GPS position the photo was taken at.
+type ImageService struct {
+	store ImageStore
+}
+
+func NewImageService(store ImageStore) *ImageService {
+	return &ImageService{store: store}
+}
+
+// Ingest stores an uploaded JPEG, PNG or WebP image under folder, e.g. "plante/photos".
+// Images with transparency are stored as PNG, the others as JPEG.
+// It returns an error wrapping ErrInvalidImage when the file is not an accepted image.
+func (s *ImageService) Ingest(ctx context.Context, file io.Reader, folder string) (*StoredImage, error) {
+	img, orientation, err := decodeImage(file)
+	if err != nil {
+		return nil, err
+	}
+
+	full := orientImage(resizeImage(img, imageSizes[0].maxSide), orientation)
+	contentType, ext := "image/jpeg", ".jpg"
+	if !full.Opaque() {
+		contentType, ext = "image/png", ".png"
+	}
+
+	stored := &StoredImage{Key: path.Join(strings.Trim(folder, "/"), primitive.NewObjectID().Hex()+ext)}
+	for i, size := range imageSizes {
+		resized := full
+		if i > 0 {
+			resized = resizeImage(full, size.maxSide)
+		}
+		data, err := encodeImage(resized, contentType)
+		if err != nil {
+			return nil, err
+		}
+		url, err := s.store.Put(ctx, sizeKey(stored.Key, size.name), data, contentType)
+		if err != nil {
+			if i > 0 {
+				// Don't leave the sizes already stored behind
+				s.Delete(ctx, stored.Key)
+			}
+			return nil, err
+		}
+		*size.url(&stored.URLs) = url
+	}
+	return stored, nil
+}
+
+// Find returns the stored image at url. Images stored before the standard sizes were made, or
+// elsewhere, have a single size and may have no key.
+func (s *ImageService) Find(url string) StoredImage {
+	key := s.store.KeyFromURL(url)
+	if !isIngestedKey(key) {
+		return StoredImage{Key: key, URLs: models.SingleImageURLs(url)}
+	}
+
+	stored := StoredImage{Key: key}
+	for _, size := range imageSizes {
+		*size.url(&stored.URLs) = s.store.URL(sizeKey(key, size.name))
+	}
+	stored.URLs.Full = url
+	return stored
+}
+
+// KeyFromURL returns the key of an image from its URL, or "" for images kept elsewhere
+func (s *ImageService) KeyFromURL(url string) string {
+	return s.store.KeyFromURL(url)
+}
+
+// Delete removes the image at key in every size it was stored in
+func (s *ImageService) Delete(ctx context.Context, key string) error {
+	keys := []string{key}
+	if isIngestedKey(key) {
+		keys = keys[:0]
+		for _, size := range imageSizes {
+			keys = append(keys, sizeKey(key, size.name))
+		}
+	}
+
+	var firstErr error
+	for _, key := range keys {
+		if err := s.store.Delete(ctx, key); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// sizeKey returns the key of one size of the image at key, e.g. "plante/photos/<id>_thumbnail.jpg"
+func sizeKey(key, size string) string {
+	if size == imageSizes[0].name {
+		return key
+	}
+	ext := path.Ext(key)
+	return strings.TrimSuffix(key, ext) + "_" + size + ext
+}
+
+// isIngestedKey reports whether key names the full size of an image stored by Ingest
+func isIngestedKey(key string) bool {
+	name := path.Base(key)
+	ext := path.Ext(name)
+	return (ext == ".jpg" || ext == ".png") && primitive.IsValidObjectID(strings.TrimSuffix(name, ext))
+}
+
+// decodeImage reads an upload, checking its real type and size, and returns the image
+// with the EXIF orientation it is to be shown in
+func decodeImage(file io.Reader) (image.Image, int, error) {
+	data, err := io.ReadAll(io.LimitReader(file, maxImageSize+1))
+	if err != nil {
+		return nil, 0, fmt.Errorf("error reading image: %v", err)
+	}
+	if len(data) > maxImageSize {
+		return nil, 0, fmt.Errorf("%w: larger than %d MB", ErrInvalidImage, maxImageSize>>20)
+	}
+	contentType := http.DetectContentType(data)
+	if !acceptedImageTypes[contentType] {
+		return nil, 0, fmt.Errorf("%w: %s is not a supported image type", ErrInvalidImage, contentType)
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, 0, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > maxImagePixels {
+		return nil, 0, fmt.Errorf("%w: %dx%d pixels is too large", ErrInvalidImage, config.Width, config.Height)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, 0, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+
+	orientation := 1
+	if contentType == "image/jpeg" {
+		orientation = jpegOrientation(data)
+	}
+	return img, orientation, nil
+}
+
+// resizeImage scales img down to fit maxSide, keeping its aspect ratio
+func resizeImage(img image.Image, maxSide int) *image.RGBA {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if longest := max(width, height); longest > maxSide {
+		width = max(1, (width*maxSide+longest/2)/longest)
+		height = max(1, (height*maxSide+longest/2)/longest)
+	}
+
+	resized := image.NewRGBA(image.Rect(0, 0, width, height))
+	if width == bounds.Dx() && height == bounds.Dy() {
+		draw.Draw(resized, resized.Bounds(), img, bounds.Min, draw.Src)
+	} else {
+		draw.CatmullRom.Scale(resized, resized.Bounds(), img, bounds, draw.Src, nil)
+	}
+	return resized
+}
+
+// orientImage turns img upright according to its EXIF orientation, 1 to 8
+func orientImage(img *image.RGBA, orientation int) *image.RGBA {
+	if orientation < 2 || orientation > 8 {
+		return img
+	}
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+	outWidth, outHeight := width, height
+	if orientation >= 5 {
+		outWidth, outHeight = height, width
+	}
+
+	oriented := image.NewRGBA(image.Rect(0, 0, outWidth, outHeight))
+	for y := 0; y < outHeight; y++ {
+		for x := 0; x < outWidth; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2: // mirrored
+				sx, sy = width-1-x, y
+			case 3: // upside down
+				sx, sy = width-1-x, height-1-y
+			case 4: // mirrored upside down
+				sx, sy = x, height-1-y
+			case 5: // transposed
+				sx, sy = y, x
+			case 6: // needs a quarter turn clockwise
+				sx, sy = y, height-1-x
+			case 7: // transversed
+				sx, sy = width-1-y, height-1-x
+			case 8: // needs a quarter turn counterclockwise
+				sx, sy = width-1-y, x
+			}
+			oriented.SetRGBA(x, y, img.RGBAAt(sx, sy))
+		}
+	}
+	return oriented
+}
+
+func encodeImage(img image.Image, contentType string) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	if contentType == "image/png" {
+		err = png.Encode(&buf, img)
+	} else {
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error encoding image: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// jpegOrientation returns the EXIF orientation of a JPEG, or 1 (upright) when it has none
+func jpegOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			return 1
+		}
+		marker := data[i+1]
+		switch {
+		case marker == 0xFF: // fill byte
+			i++
+			continue
+		case marker == 0x01 || (marker >= 0xD0 && marker <= 0xD8): // markers without a length
+			i += 2
+			continue
+		case marker == 0xDA || marker == 0xD9: // the image data starts, EXIF comes before it
+			return 1
+		}
+
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		if length < 2 || i+2+length > len(data) {
+			return 1
+		}
+		segment := data[i+4 : i+2+length]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return exifOrientation(segment[6:])
+		}
+		i += 2 + length
+	}
+	return 1
+}
+
+// exifOrientation reads the orientation tag from the first IFD of EXIF data in TIFF layout
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	offset := int(order.Uint32(tiff[4:]))
+	if offset < 8 || offset+2 > len(tiff) {
+		return 1
+	}
+	count := int(order.Uint16(tiff[offset:]))
+	for i := 0; i < count; i++ {
+		entry := offset + 2 + 12*i
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:]) == 0x0112 {
+			if orientation := int(order.Uint16(tiff[entry+8:])); orientation >= 1 && orientation <= 8 {
+				return orientation
+			}
+			return 1
+		}
+	}
+	return 1
+}
@@ -0,0 +1,198 @@
+package services
+
+import (
+	"authentication/models"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newTestImageService(t *testing.T) (*ImageService, string) {
+	t.Helper()
+	dir := t.TempDir()
+	store, err := NewLocalImageStore(dir, "http://localhost:8080/uploads")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewImageService(store), dir
+}
+
+// storedImage decodes the stored file behind url
+func storedImage(t *testing.T, dir, url string) (image.Image, string, []byte) {
+	t.Helper()
+	key := strings.TrimPrefix(url, "http://localhost:8080/uploads/")
+	data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(key)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return img, format, data
+}
+
+// withEXIF inserts an EXIF segment holding orientation and a GPS marker right after the start of a JPEG
+func withEXIF(jpegData []byte, orientation byte) []byte {
+	tiff := []byte{
+		'M', 'M', 0, 42, 0, 0, 0, 8, // big endian, first IFD at 8
+		0, 1, // one entry
+		0x01, 0x12, 0, 3, 0, 0, 0, 1, 0, orientation, 0, 0, // orientation, SHORT
+		0, 0, 0, 0, // no next IFD
+	}
+	tiff = append(tiff, "GPS 13.7563N 100.5018E"...)
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	segment := []byte{0xFF, 0xE1, byte((len(payload) + 2) >> 8), byte(len(payload) + 2)}
+	segment = append(segment, payload...)
+
+	out := append([]byte{}, jpegData[:2]...)
+	out = append(out, segment...)
+	return append(out, jpegData[2:]...)
+}
+
+func TestIngestResizesAndStripsMetadata(t *testing.T) {
+	service, dir := newTestImageService(t)
+
+	// Landscape on the sensor, red on the left and blue on the right, to be turned a quarter clockwise
+	src := image.NewRGBA(image.Rect(0, 0, 3000, 1000))
+	for y := 0; y < 1000; y++ {
+		for x := 0; x < 3000; x++ {
+			c := color.RGBA{R: 255, A: 255}
+			if x >= 1500 {
+				c = color.RGBA{B: 255, A: 255}
+			}
+			src.SetRGBA(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, src, nil); err != nil {
+		t.Fatal(err)
+	}
+	upload := withEXIF(buf.Bytes(), 6)
+	if got := jpegOrientation(upload); got != 6 {
+		t.Fatalf("jpegOrientation = %d, want 6", got)
+	}
+
+	stored, err := service.Ingest(context.Background(), bytes.NewReader(upload), "plante/photos")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(stored.Key, "plante/photos/") || !strings.HasSuffix(stored.Key, ".jpg") {
+		t.Errorf("key = %q, want a .jpg under plante/photos", stored.Key)
+	}
+
+	sizes := map[string]struct {
+		url           string
+		width, height int
+	}{
+		"full":      {stored.URLs.Full, 853, 2560},
+		"medium":    {stored.URLs.Medium, 400, 1200},
+		"thumbnail": {stored.URLs.Thumbnail, 133, 400},
+	}
+	for name, want := range sizes {
+		img, format, data := storedImage(t, dir, want.url)
+		if format != "jpeg" {
+			t.Errorf("%s is %s, want jpeg", name, format)
+		}
+		if got := img.Bounds().Size(); got.X != want.width || got.Y != want.height {
+			t.Errorf("%s is %v, want %dx%d", name, got, want.width, want.height)
+		}
+		if bytes.Contains(data, []byte("Exif")) || bytes.Contains(data, []byte("GPS")) {
+			t.Errorf("%s still has the EXIF data", name)
+		}
+		// Turned upright: red at the top, blue at the bottom
+		top, bottom := img.At(want.width/2, 5), img.At(want.width/2, want.height-5)
+		if r, _, b, _ := top.RGBA(); r < b {
+			t.Errorf("%s: top is %v, want red", name, top)
+		}
+		if r, _, b, _ := bottom.RGBA(); b < r {
+			t.Errorf("%s: bottom is %v, want blue", name, bottom)
+		}
+	}
+
+	if found := service.Find(stored.URLs.Full); found != *stored {
+		t.Errorf("Find = %+v, want %+v", found, *stored)
+	}
+	if err := service.Delete(context.Background(), stored.Key); err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ := os.ReadDir(filepath.Join(dir, "plante", "photos")); len(entries) != 0 {
+		t.Errorf("%d file(s) left after Delete, want every size deleted", len(entries))
+	}
+}
+
+func TestIngestKeepsTransparencyAndSmallImages(t *testing.T) {
+	service, dir := newTestImageService(t)
+
+	src := image.NewNRGBA(image.Rect(0, 0, 300, 200))
+	src.SetNRGBA(10, 10, color.NRGBA{G: 255, A: 128})
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, src); err != nil {
+		t.Fatal(err)
+	}
+
+	stored, err := service.Ingest(context.Background(), &buf, "plante/plants")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, url := range []string{stored.URLs.Full, stored.URLs.Medium, stored.URLs.Thumbnail} {
+		img, format, _ := storedImage(t, dir, url)
+		if format != "png" {
+			t.Errorf("%s is %s, want png", url, format)
+		}
+		if got := img.Bounds().Size(); got != (image.Point{X: 300, Y: 200}) {
+			t.Errorf("%s is %v, want it left at 300x200", url, got)
+		}
+	}
+}
+
+func TestIngestRejectsInvalidImages(t *testing.T) {
+	service, dir := newTestImageService(t)
+
+	// A PNG header claiming 100000x100000 pixels
+	var huge bytes.Buffer
+	png.Encode(&huge, image.NewGray(image.Rect(0, 0, 1, 1)))
+	header := huge.Bytes()
+	copy(header[16:24], []byte{0, 1, 0x86, 0xA0, 0, 1, 0x86, 0xA0})
+	binary.BigEndian.PutUint32(header[29:33], crc32.ChecksumIEEE(header[12:29]))
+
+	tests := map[string][]byte{
+		"html":      []byte("<html><body>not an image</body></html>"),
+		"gif":       []byte("GIF89a\x01\x00\x01\x00\x00\x00\x00;"),
+		"truncated": []byte("\xFF\xD8\xFF\xE0\x00\x10JFIF\x00"),
+		"too large": bytes.Repeat([]byte{0xFF}, maxImageSize+1),
+		"too many":  header,
+	}
+	for name, data := range tests {
+		if _, err := service.Ingest(context.Background(), bytes.NewReader(data), "plante/photos"); !errors.Is(err, ErrInvalidImage) {
+			t.Errorf("%s: err = %v, want ErrInvalidImage", name, err)
+		}
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("rejected uploads left %d file(s) behind", len(entries))
+	}
+}
+
+func TestFindImagesStoredBeforeSizes(t *testing.T) {
+	service, _ := newTestImageService(t)
+
+	for url, key := range map[string]string{
+		"http://localhost:8080/uploads/1748191291653496000.jpg": "1748191291653496000.jpg",
+		"https://res.cloudinary.com/demo/image/upload/v1/a.jpg": "",
+	} {
+		found := service.Find(url)
+		if found.Key != key || found.URLs != models.SingleImageURLs(url) {
+			t.Errorf("Find(%q) = %+v, want key %q and the image itself in every size", url, found, key)
+		}
+	}
+}
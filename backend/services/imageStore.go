@@ -0,0 +1,80 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ImageStore keeps image files and serves them at public URLs. Uploads go through ImageService,
+// which checks and resizes them before they are put here.
+type ImageStore interface {
+	// Put stores the image under key, e.g. "plante/photos/<id>.jpg", and returns its URL
+	Put(ctx context.Context, key string, data []byte, contentType string) (string, error)
+	// Delete removes a stored image; deleting an image that is already gone is not an error
+	Delete(ctx context.Context, key string) error
+	// URL returns the public URL of the image at key
+	URL(key string) string
+	// KeyFromURL returns the key of an image stored here from its URL, or "" for other URLs.
+	// Images stored before keys were kept are deleted through it.
+	KeyFromURL(url string) string
+}
+
+// NewImageStoreFromEnv configures the store named by IMAGE_STORE: "cloudinary", "local" or "s3".
+// Without IMAGE_STORE, Cloudinary is used when CLOUDINARY_CLOUD_NAME is set and the local disk otherwise.
+func NewImageStoreFromEnv() (ImageStore, error) {
+	kind := os.Getenv("IMAGE_STORE")
+	if kind == "" {
+		kind = "local"
+		if os.Getenv("CLOUDINARY_CLOUD_NAME") != "" {
+			kind = "cloudinary"
+		}
+	}
+
+	switch kind {
+	case "cloudinary":
+		return NewCloudinaryImageStore(
+			os.Getenv("CLOUDINARY_CLOUD_NAME"),
+			os.Getenv("CLOUDINARY_API_KEY"),
+			os.Getenv("CLOUDINARY_API_SECRET"),
+		)
+	case "local":
+		dir := os.Getenv("LOCAL_IMAGE_DIR")
+		if dir == "" {
+			dir = "./uploads"
+		}
+		baseURL := os.Getenv("LOCAL_IMAGE_BASE_URL")
+		if baseURL == "" {
+			port := os.Getenv("PORT")
+			if port == "" {
+				port = "8080"
+			}
+			baseURL = "http://localhost:" + port + LocalImagePath
+		}
+		return NewLocalImageStore(dir, baseURL)
+	case "s3":
+		region := os.Getenv("S3_REGION")
+		if region == "" {
+			region = "us-east-1"
+		}
+		return NewS3ImageStore(S3Config{
+			Endpoint:        os.Getenv("S3_ENDPOINT"),
+			Region:          region,
+			Bucket:          os.Getenv("S3_BUCKET"),
+			AccessKeyID:     os.Getenv("S3_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+			PublicURL:       os.Getenv("S3_PUBLIC_URL"),
+		})
+	}
+	return nil, fmt.Errorf("unknown IMAGE_STORE %q, expected cloudinary, local or s3", kind)
+}
+
+// keyUnder returns the part of url after the base URL prefix, or "" if url does not start with it
+func keyUnder(baseURL, url string) string {
+	prefix := strings.TrimSuffix(baseURL, "/") + "/"
+	if !strings.HasPrefix(url, prefix) {
+		return ""
+	}
+	return strings.TrimPrefix(url, prefix)
+}
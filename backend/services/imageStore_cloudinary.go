@@ -0,0 +1,91 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/cloudinary/cloudinary-go/v2"
+	"github.com/cloudinary/cloudinary-go/v2/api/uploader"
+)
+
+// CloudinaryImageStore keeps images in Cloudinary; keys are public IDs with the file extension
+type CloudinaryImageStore struct {
+	cld *cloudinary.Cloudinary
+}
+
+func NewCloudinaryImageStore(cloudName, apiKey, apiSecret string) (*CloudinaryImageStore, error) {
+	cld, err := cloudinary.NewFromParams(cloudName, apiKey, apiSecret)
+	if err != nil {
+		return nil, fmt.Errorf("error configuring Cloudinary: %v", err)
+	}
+	return &CloudinaryImageStore{cld: cld}, nil
+}
+
+// Put uploads the image with the key, without its extension, as public ID
+func (s *CloudinaryImageStore) Put(ctx context.Context, key string, data []byte, contentType string) (string, error) {
+	result, err := s.cld.Upload.Upload(ctx, bytes.NewReader(data), uploader.UploadParams{
+		PublicID:     cloudinaryPublicID(key),
+		ResourceType: "image",
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to upload image: %v", err)
+	}
+	if result.Error.Message != "" {
+		return "", fmt.Errorf("failed to upload image: %s", result.Error.Message)
+	}
+	return result.SecureURL, nil
+}
+
+func (s *CloudinaryImageStore) Delete(ctx context.Context, key string) error {
+	result, err := s.cld.Upload.Destroy(ctx, uploader.DestroyParams{PublicID: cloudinaryPublicID(key)})
+	if err != nil {
+		return fmt.Errorf("failed to delete image: %v", err)
+	}
+	// "not found" means it is already gone
+	if result.Error.Message != "" {
+		return fmt.Errorf("failed to delete image: %s", result.Error.Message)
+	}
+	return nil
+}
+
+func (s *CloudinaryImageStore) URL(key string) string {
+	return "https://res.cloudinary.com/" + s.cld.Config.Cloud.CloudName + "/image/upload/" + key
+}
+
+// KeyFromURL extracts the key from a Cloudinary URL, e.g.
+// https://res.cloudinary.com/cloud-name/image/upload/v1234567890/folder/image.jpg gives folder/image.jpg
+func (s *CloudinaryImageStore) KeyFromURL(url string) string {
+	if !strings.Contains(url, "res.cloudinary.com/") {
+		return ""
+	}
+	parts := strings.Split(url, "/upload/")
+	if len(parts) != 2 {
+		return ""
+	}
+
+	// Remove version
+	path := parts[1]
+	if slash := strings.Index(path, "/"); slash > 1 && path[0] == 'v' && isDigits(path[1:slash]) {
+		path = path[slash+1:]
+	}
+	return path
+}
+
+// cloudinaryPublicID is the public ID of a key: the key without its file extension
+func cloudinaryPublicID(key string) string {
+	if dot := strings.LastIndex(key, "."); dot > strings.LastIndex(key, "/") {
+		return key[:dot]
+	}
+	return key
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
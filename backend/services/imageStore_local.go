@@ -0,0 +1,74 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LocalImagePath is where the server serves the local image directory
+const LocalImagePath = "/uploads"
+
+// LocalImageStore keeps images in a directory on disk, served by the backend itself under LocalImagePath
+type LocalImageStore struct {
+	dir     string
+	baseURL string
+}
+
+// NewLocalImageStore stores images in dir, creating it if needed. baseURL is the public URL of dir,
+// e.g. "http://localhost:8080/uploads".
+func NewLocalImageStore(dir, baseURL string) (*LocalImageStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("error creating image directory: %v", err)
+	}
+	return &LocalImageStore{dir: dir, baseURL: strings.TrimSuffix(baseURL, "/")}, nil
+}
+
+// Dir is the directory the images are stored in
+func (s *LocalImageStore) Dir() string {
+	return s.dir
+}
+
+func (s *LocalImageStore) Put(ctx context.Context, key string, data []byte, contentType string) (string, error) {
+	name, err := s.path(key)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return "", fmt.Errorf("error creating image directory: %v", err)
+	}
+	if err := os.WriteFile(name, data, 0o644); err != nil {
+		return "", fmt.Errorf("error saving image: %v", err)
+	}
+	return s.URL(key), nil
+}
+
+func (s *LocalImageStore) Delete(ctx context.Context, key string) error {
+	name, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(name); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error deleting image: %v", err)
+	}
+	return nil
+}
+
+func (s *LocalImageStore) URL(key string) string {
+	return s.baseURL + "/" + key
+}
+
+func (s *LocalImageStore) KeyFromURL(url string) string {
+	return keyUnder(s.baseURL, url)
+}
+
+// path returns the file of a key, refusing keys that lead out of the directory
+func (s *LocalImageStore) path(key string) (string, error) {
+	clean := filepath.Clean(filepath.FromSlash(key))
+	if key == "" || filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid image key %q", key)
+	}
+	return filepath.Join(s.dir, clean), nil
+}
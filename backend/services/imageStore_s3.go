@@ -0,0 +1,166 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// S3Config locates a bucket on S3 or an S3-compatible server such as MinIO
+type S3Config struct {
+	Endpoint        string // e.g. "https://s3.ap-southeast-1.amazonaws.com" or "http://localhost:9000"
+	Region          string
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+	// PublicURL is where the bucket's objects are read from, e.g. a CDN; Endpoint/Bucket by default.
+	// The bucket (or CDN) must allow anonymous reads for the images to show.
+	PublicURL string
+}
+
+// S3ImageStore keeps images in an S3 bucket, addressed path-style so it also works with MinIO.
+// Requests are signed with AWS Signature Version 4.
+type S3ImageStore struct {
+	config S3Config
+	client *http.Client
+	now    func() time.Time
+}
+
+func NewS3ImageStore(config S3Config) (*S3ImageStore, error) {
+	if config.Endpoint == "" || config.Bucket == "" || config.AccessKeyID == "" || config.SecretAccessKey == "" {
+		return nil, fmt.Errorf("S3_ENDPOINT, S3_BUCKET, S3_ACCESS_KEY_ID and S3_SECRET_ACCESS_KEY are required for the s3 image store")
+	}
+	if _, err := url.Parse(config.Endpoint); err != nil {
+		return nil, fmt.Errorf("invalid S3 endpoint: %v", err)
+	}
+	config.Endpoint = strings.TrimSuffix(config.Endpoint, "/")
+	if config.PublicURL == "" {
+		config.PublicURL = config.Endpoint + "/" + config.Bucket
+	}
+	config.PublicURL = strings.TrimSuffix(config.PublicURL, "/")
+
+	return &S3ImageStore{
+		config: config,
+		client: &http.Client{Timeout: 30 * time.Second},
+		now:    time.Now,
+	}, nil
+}
+
+func (s *S3ImageStore) Put(ctx context.Context, key string, data []byte, contentType string) (string, error) {
+	if err := s.do(ctx, http.MethodPut, key, data, contentType); err != nil {
+		return "", fmt.Errorf("failed to upload image: %v", err)
+	}
+	return s.URL(key), nil
+}
+
+// Delete removes the object; S3 answers 204 for objects that don't exist too
+func (s *S3ImageStore) Delete(ctx context.Context, key string) error {
+	if err := s.do(ctx, http.MethodDelete, key, nil, ""); err != nil {
+		return fmt.Errorf("failed to delete image: %v", err)
+	}
+	return nil
+}
+
+func (s *S3ImageStore) URL(key string) string {
+	return s.config.PublicURL + "/" + key
+}
+
+func (s *S3ImageStore) KeyFromURL(url string) string {
+	return keyUnder(s.config.PublicURL, url)
+}
+
+// do sends a signed request for one object and fails on any status but 2xx
+func (s *S3ImageStore) do(ctx context.Context, method, key string, body []byte, contentType string) error {
+	req, err := http.NewRequestWithContext(ctx, method, s.objectURL(key), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	signS3Request(req, body, s.config, s.now())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s %s returned %s: %s", method, key, resp.Status, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
+
+func (s *S3ImageStore) objectURL(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return s.config.Endpoint + "/" + url.PathEscape(s.config.Bucket) + "/" + strings.Join(segments, "/")
+}
+
+// signS3Request adds the AWS Signature Version 4 headers, signing the host and every header already set
+func signS3Request(req *http.Request, body []byte, config S3Config, now time.Time) {
+	payloadHash := sha256Hex(body)
+	amzDate := now.UTC().Format("20060102T150405Z")
+	day := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		strings.ReplaceAll(req.URL.Query().Encode(), "+", "%20"),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := day + "/" + config.Region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+config.SecretAccessKey), day)
+	key = hmacSHA256(key, config.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+config.AccessKeyID+"/"+scope+
+		",SignedHeaders="+signedHeaders+",Signature="+signature)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"authentication/models"
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// InboxRetention is how long inbox items are kept before the TTL index removes them
+const InboxRetention = 90 * 24 * time.Hour
+
+// InboxService keeps the history of notifications sent to each user
+type InboxService struct {
+	db *mongo.Database
+}
+
+func NewInboxService(db *mongo.Database) *InboxService {
+	return &InboxService{db: db}
+}
+
+func (s *InboxService) collection() *mongo.Collection {
+	return s.db.Collection("notification_inbox")
+}
+
+// EnsureIndexes creates the listing index and the TTL index that expires old items
+func (s *InboxService) EnsureIndexes(ctx context.Context) error {
+	_, err := s.collection().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "read", Value: 1}}},
+		{
+			Keys:    bson.D{{Key: "created_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(int32(InboxRetention.Seconds())),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("error creating notification inbox indexes: %v", err)
+	}
+	return nil
+}
+
+// Add stores a sent notification in the user's inbox
+func (s *InboxService) Add(ctx context.Context, userID, itemType string, plantID *primitive.ObjectID, payload models.NotificationPayload) (*models.InboxItem, error) {
+	item := models.InboxItem{
+		ID:        primitive.NewObjectID(),
+		UserID:    userID,
+		Type:      itemType,
+		PlantID:   plantID,
+		Title:     payload.Title,
+		Body:      payload.Body,
+		Data:      payload.Data,
+		CreatedAt: time.Now(),
+	}
+	if _, err := s.collection().InsertOne(ctx, item); err != nil {
+		return nil, fmt.Errorf("error saving inbox item: %v", err)
+	}
+	return &item, nil
+}
+
+// List returns one page of the user's inbox, newest first, and the total number of matching items
+func (s *InboxService) List(ctx context.Context, userID string, unreadOnly bool, page, limit int64) ([]models.InboxItem, int64, error) {
+	filter := bson.M{"user_id": userID}
+	if unreadOnly {
+		filter["read"] = false
+	}
+
+	total, err := s.collection().CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error counting inbox items: %v", err)
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip((page - 1) * limit).
+		SetLimit(limit)
+	cursor, err := s.collection().Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error finding inbox items: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	items := []models.InboxItem{}
+	if err := cursor.All(ctx, &items); err != nil {
+		return nil, 0, fmt.Errorf("error decoding inbox items: %v", err)
+	}
+	return items, total, nil
+}
+
+// UnreadCount returns how many of the user's items are unread
+func (s *InboxService) UnreadCount(ctx context.Context, userID string) (int64, error) {
+	count, err := s.collection().CountDocuments(ctx, bson.M{"user_id": userID, "read": false})
+	if err != nil {
+		return 0, fmt.Errorf("error counting unread inbox items: %v", err)
+	}
+	return count, nil
+}
+
+// MarkRead marks one of the user's items read. It returns false when the item does not exist.
+func (s *InboxService) MarkRead(ctx context.Context, userID string, itemID primitive.ObjectID) (bool, error) {
+	result, err := s.collection().UpdateOne(ctx,
+		bson.M{"_id": itemID, "user_id": userID},
+		bson.M{"$set": bson.M{"read": true, "read_at": time.Now()}},
+	)
+	if err != nil {
+		return false, fmt.Errorf("error marking inbox item read: %v", err)
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// MarkAllRead marks every unread item of the user read and returns how many changed
+func (s *InboxService) MarkAllRead(ctx context.Context, userID string) (int64, error) {
+	result, err := s.collection().UpdateMany(ctx,
+		bson.M{"user_id": userID, "read": false},
+		bson.M{"$set": bson.M{"read": true, "read_at": time.Now()}},
+	)
+	if err != nil {
+		return 0, fmt.Errorf("error marking inbox items read: %v", err)
+	}
+	return result.ModifiedCount, nil
+}
+
+// Delete removes one of the user's items. It returns false when the item does not exist.
+func (s *InboxService) Delete(ctx context.Context, userID string, itemID primitive.ObjectID) (bool, error) {
+	result, err := s.collection().DeleteOne(ctx, bson.M{"_id": itemID, "user_id": userID})
+	if err != nil {
+		return false, fmt.Errorf("error deleting inbox item: %v", err)
+	}
+	return result.DeletedCount > 0, nil
+}
@@ -0,0 +1,160 @@
+package services
+
+import (
+	"authentication/models"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// addInboxItems stores n items for the user, titled "<prefix> 1" to "<prefix> n" from oldest to newest
+func addInboxItems(t *testing.T, inbox *InboxService, userID, prefix string, n int) []*models.InboxItem {
+	t.Helper()
+	var items []*models.InboxItem
+	for i := 1; i <= n; i++ {
+		item, err := inbox.Add(context.Background(), userID, "watering", nil, models.NotificationPayload{Title: fmt.Sprintf("%s %d", prefix, i)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+func inboxTitles(items []models.InboxItem) []string {
+	var titles []string
+	for _, item := range items {
+		titles = append(titles, item.Title)
+	}
+	return titles
+}
+
+func TestInboxListPagesNewestFirst(t *testing.T) {
+	ctx := context.Background()
+	inbox := NewInboxService(newTestDatabase(t))
+	if err := inbox.EnsureIndexes(ctx); err != nil {
+		t.Fatal(err)
+	}
+	items := addInboxItems(t, inbox, "user-1", "mine", 5)
+	addInboxItems(t, inbox, "user-2", "theirs", 2)
+	for _, item := range items[:2] {
+		if _, err := inbox.MarkRead(ctx, "user-1", item.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name        string
+		unreadOnly  bool
+		page, limit int64
+		want        string
+		wantTotal   int64
+	}{
+		{"first page", false, 1, 2, "[mine 5 mine 4]", 5},
+		{"middle page", false, 2, 2, "[mine 3 mine 2]", 5},
+		{"last page", false, 3, 2, "[mine 1]", 5},
+		{"past the end", false, 4, 2, "[]", 5},
+		{"unread", true, 1, 10, "[mine 5 mine 4 mine 3]", 3},
+		{"unread second page", true, 2, 2, "[mine 3]", 3},
+	}
+	for _, tt := range tests {
+		got, total, err := inbox.List(ctx, "user-1", tt.unreadOnly, tt.page, tt.limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fmt.Sprint(inboxTitles(got)) != tt.want || total != tt.wantTotal {
+			t.Errorf("%s: got %v of %d, want %s of %d", tt.name, inboxTitles(got), total, tt.want, tt.wantTotal)
+		}
+	}
+}
+
+func TestInboxMarkReadOnlyTouchesTheUsersItems(t *testing.T) {
+	ctx := context.Background()
+	inbox := NewInboxService(newTestDatabase(t))
+	mine := addInboxItems(t, inbox, "user-1", "mine", 3)
+	theirs := addInboxItems(t, inbox, "user-2", "theirs", 2)
+	unread := func(userID string) int64 {
+		t.Helper()
+		count, err := inbox.UnreadCount(ctx, userID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return count
+	}
+
+	if found, err := inbox.MarkRead(ctx, "user-1", theirs[0].ID); err != nil || found {
+		t.Errorf("marking another user's item read = %v, %v, want not found", found, err)
+	}
+	if found, err := inbox.MarkRead(ctx, "user-1", primitive.NewObjectID()); err != nil || found {
+		t.Errorf("marking a missing item read = %v, %v, want not found", found, err)
+	}
+	if found, err := inbox.MarkRead(ctx, "user-1", mine[0].ID); err != nil || !found {
+		t.Errorf("marking an own item read = %v, %v, want found", found, err)
+	}
+	if got := unread("user-2"); got != 2 {
+		t.Errorf("user-2 has %d unread after user-1 marked items read, want 2", got)
+	}
+
+	changed, err := inbox.MarkAllRead(ctx, "user-1")
+	if err != nil || changed != 2 {
+		t.Errorf("MarkAllRead = %d, %v, want the 2 items still unread", changed, err)
+	}
+	if got := unread("user-1"); got != 0 {
+		t.Errorf("user-1 has %d unread after MarkAllRead", got)
+	}
+	if got := unread("user-2"); got != 2 {
+		t.Errorf("user-2 has %d unread after user-1's MarkAllRead, want 2", got)
+	}
+}
+
+func TestSchedulerAddsOneInboxItemPerSend(t *testing.T) {
+	loc := bangkok(t)
+	f := newSchedulerFixture(t, time.Date(2026, 3, 2, 7, 59, 58, 0, loc))
+	f.addReminder(t, "daily", models.Reminder{Frequency: "daily", TimeOfDay: "08:00"})
+	scheduler := NewScheduler(f.service, nil, nil, f.clock)
+	inboxItems := func() []models.InboxItem {
+		t.Helper()
+		cursor, err := f.db.Collection("notification_inbox").Find(context.Background(), bson.M{"user_id": f.userID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var items []models.InboxItem
+		if err := cursor.All(context.Background(), &items); err != nil {
+			t.Fatal(err)
+		}
+		return items
+	}
+
+	// The first push fails and is retried; the retry is the same send
+	f.push.Err = errors.New("service unavailable")
+	f.clock.Advance(schedulerInterval)
+	scheduler.Tick()
+	f.push.Err = nil
+	f.clock.Advance(baseDeliveryBackoff)
+	scheduler.Tick()
+	if got := f.sent(t); fmt.Sprint(got) != "[daily]" {
+		t.Fatalf("after the retry sent %v, want [daily]", got)
+	}
+	items := inboxItems()
+	if len(items) != 1 {
+		t.Fatalf("%d inbox items after one send, want 1", len(items))
+	}
+	if items[0].Type != "watering" || items[0].PlantID == nil || *items[0].PlantID != f.plantID || items[0].Read {
+		t.Errorf("inbox item = %+v, want an unread watering item for the plant", items[0])
+	}
+
+	// The unacknowledged resend is a second send
+	f.clock.Advance(AcknowledgementWindow)
+	scheduler.Tick()
+	if got := f.sent(t); fmt.Sprint(got) != "[daily]" {
+		t.Fatalf("after the acknowledgement window sent %v, want the resend", got)
+	}
+	if n := len(inboxItems()); n != 2 {
+		t.Errorf("%d inbox items after the resend, want 2", n)
+	}
+}
@@ -3,75 +3,180 @@ package services
 import (
 	"authentication/models"
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"strconv"
+	"strings"
 	"time"
 
-	firebase "firebase.google.com/go/v4"
-	"firebase.google.com/go/v4/messaging"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
-	"google.golang.org/api/option"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// maxDeliveryAttempts is the number of attempts before a delivery goes to dead letter
+	maxDeliveryAttempts = 5
+	// baseDeliveryBackoff is the wait after the first failed attempt, doubled on every retry
+	baseDeliveryBackoff = 30 * time.Second
+	maxDeliveryBackoff  = time.Hour
 )
 
 type NotificationService struct {
-	db                    *mongo.Database
-	client                *messaging.Client
-	lastNotificationTimes map[string]time.Time
+	db          *mongo.Database
+	clock       Clock
+	templates   *TemplateService
+	occurrences *OccurrenceService
+	inbox       *InboxService
+	events      *EventBus
+	notifiers   map[string]Notifier
+}
+
+// NewNotificationService creates the service with one notifier per channel
+func NewNotificationService(db *mongo.Database, clock Clock, templates *TemplateService, occurrences *OccurrenceService, inbox *InboxService, events *EventBus, notifiers ...Notifier) *NotificationService {
+	byChannel := make(map[string]Notifier, len(notifiers))
+	for _, notifier := range notifiers {
+		byChannel[notifier.Channel()] = notifier
+	}
+
+	return &NotificationService{
+		db:          db,
+		clock:       clock,
+		templates:   templates,
+		occurrences: occurrences,
+		inbox:       inbox,
+		events:      events,
+		notifiers:   byChannel,
+	}
 }
 
-func NewNotificationService(db *mongo.Database) (*NotificationService, error) {
-	// Initialize Firebase Admin SDK
-	opt := option.WithCredentialsFile("serviceAccountKey.json")
-	app, err := firebase.NewApp(context.Background(), nil, opt)
+// deliveryBackoff returns how long to wait before the next attempt after the given number of attempts
+func deliveryBackoff(attempts int) time.Duration {
+	backoff := baseDeliveryBackoff
+	for i := 1; i < attempts; i++ {
+		backoff *= 2
+		if backoff >= maxDeliveryBackoff {
+			return maxDeliveryBackoff
+		}
+	}
+	return backoff
+}
+
+// EnsureIndexes creates the indexes used by the delivery queries
+func (s *NotificationService) EnsureIndexes(ctx context.Context) error {
+	_, err := s.db.Collection("notification_deliveries").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "next_attempt_at", Value: 1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	})
 	if err != nil {
-		return nil, fmt.Errorf("error initializing firebase app: %v", err)
+		return fmt.Errorf("error creating notification delivery indexes: %v", err)
+	}
+	return nil
+}
+
+// enqueueDelivery stores a new delivery record and makes the first attempt.
+// A digest passes all of its reminders, a single reminder notification passes one.
+func (s *NotificationService) enqueueDelivery(ctx context.Context, userID string, reminderIDs []primitive.ObjectID, channel string, payload models.NotificationPayload) (*models.NotificationDelivery, error) {
+	now := s.clock.Now()
+	delivery := models.NotificationDelivery{
+		ID:        primitive.NewObjectID(),
+		UserID:    userID,
+		Channel:   channel,
+		Payload:   payload,
+		Status:    models.DeliveryStatusPending,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if len(reminderIDs) > 0 {
+		delivery.ReminderID = reminderIDs[0]
+	}
+	if len(reminderIDs) > 1 {
+		delivery.ReminderIDs = reminderIDs
+	}
+	if _, err := s.db.Collection("notification_deliveries").InsertOne(ctx, delivery); err != nil {
+		return nil, fmt.Errorf("error saving notification delivery: %v", err)
+	}
+
+	s.attemptDelivery(ctx, &delivery)
+	return &delivery, nil
+}
+
+// attemptDelivery sends the delivery once and records the outcome.
+// Transient failures are rescheduled with exponential backoff, permanent ones go to dead letter.
+func (s *NotificationService) attemptDelivery(ctx context.Context, delivery *models.NotificationDelivery) {
+	now := s.clock.Now()
+	delivery.Attempts++
+	delivery.LastAttemptAt = &now
+	delivery.UpdatedAt = now
+
+	sendErr := s.sendDelivery(ctx, delivery)
+	switch {
+	case sendErr == nil:
+		delivery.Status = models.DeliveryStatusSent
+		delivery.SentAt = &now
+		delivery.NextAttemptAt = nil
+		delivery.Error = ""
+	case errors.Is(sendErr, ErrPermanentDelivery) || delivery.Attempts >= maxDeliveryAttempts:
+		delivery.Status = models.DeliveryStatusDeadLetter
+		delivery.NextAttemptAt = nil
+		delivery.Error = sendErr.Error()
+		log.Printf("[ERROR] Delivery %s moved to dead letter after %d attempt(s): %v", delivery.ID.Hex(), delivery.Attempts, sendErr)
+	default:
+		next := now.Add(deliveryBackoff(delivery.Attempts))
+		delivery.Status = models.DeliveryStatusRetrying
+		delivery.NextAttemptAt = &next
+		delivery.Error = sendErr.Error()
+		log.Printf("[ERROR] Delivery %s failed (attempt %d), retrying at %v: %v", delivery.ID.Hex(), delivery.Attempts, next, sendErr)
 	}
 
-	// Get Messaging client
-	client, err := app.Messaging(context.Background())
+	_, err := s.db.Collection("notification_deliveries").ReplaceOne(ctx, bson.M{"_id": delivery.ID}, delivery)
 	if err != nil {
-		return nil, fmt.Errorf("error getting messaging client: %v", err)
+		log.Printf("[ERROR] Error updating notification delivery %s: %v", delivery.ID.Hex(), err)
 	}
+}
 
-	return &NotificationService{
-		db:                    db,
-		client:                client,
-		lastNotificationTimes: make(map[string]time.Time),
-	}, nil
-}
-
-func (s *NotificationService) SendNotification(token string, title, body string, data map[string]string) error {
-	badge := 1
-	message := &messaging.Message{
-		Token: token,
-		Notification: &messaging.Notification{
-			Title: title,
-			Body:  body,
-		},
-		Data: data,
-		Android: &messaging.AndroidConfig{
-			Priority: "high",
-			Notification: &messaging.AndroidNotification{
-				Title:    title,
-				Body:     body,
-				Priority: messaging.PriorityHigh,
-			},
-		},
-		APNS: &messaging.APNSConfig{
-			Payload: &messaging.APNSPayload{
-				Aps: &messaging.Aps{
-					Sound: "default",
-					Badge: &badge,
-				},
-			},
-		},
+// sendDelivery looks up the recipient and hands the payload to the channel's notifier
+func (s *NotificationService) sendDelivery(ctx context.Context, delivery *models.NotificationDelivery) error {
+	notifier, ok := s.notifiers[delivery.Channel]
+	if !ok {
+		return permanentErrorf("channel %q is not configured", delivery.Channel)
+	}
+
+	var user models.User
+	err := s.db.Collection("users").FindOne(ctx, bson.M{"user_id": delivery.UserID}).Decode(&user)
+	if err == mongo.ErrNoDocuments {
+		return permanentErrorf("user %s not found", delivery.UserID)
 	}
+	if err != nil {
+		return fmt.Errorf("error fetching user: %v", err)
+	}
+
+	return notifier.Send(ctx, &user, delivery.Payload)
+}
 
-	_, err := s.client.Send(context.Background(), message)
+// RetryDueDeliveries re-attempts deliveries whose backoff has elapsed
+func (s *NotificationService) RetryDueDeliveries() error {
+	ctx := context.Background()
+
+	cursor, err := s.db.Collection("notification_deliveries").Find(ctx, bson.M{
+		"status":          models.DeliveryStatusRetrying,
+		"next_attempt_at": bson.M{"$lte": s.clock.Now()},
+	}, options.Find().SetSort(bson.D{{Key: "next_attempt_at", Value: 1}}))
 	if err != nil {
-		return fmt.Errorf("error sending FCM message: %v", err)
+		return fmt.Errorf("error fetching deliveries to retry: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	var deliveries []models.NotificationDelivery
+	if err := cursor.All(ctx, &deliveries); err != nil {
+		return fmt.Errorf("error decoding deliveries to retry: %v", err)
+	}
+
+	for i := range deliveries {
+		log.Printf("[DEBUG] Retrying delivery %s (attempt %d)", deliveries[i].ID.Hex(), deliveries[i].Attempts+1)
+		s.attemptDelivery(ctx, &deliveries[i])
 	}
 
 	return nil
@@ -80,7 +185,7 @@ func (s *NotificationService) SendNotification(token string, title, body string,
 func (s *NotificationService) CheckAndSendReminders() error {
 	ctx := context.Background()
 	loc, _ := time.LoadLocation("Asia/Bangkok")
-	now := time.Now().In(loc)
+	now := s.clock.Now().In(loc)
 
 	// Get all active reminders
 	cursor, err := s.db.Collection("reminders").Find(ctx, bson.M{"is_active": true})
@@ -96,11 +201,12 @@ func (s *NotificationService) CheckAndSendReminders() error {
 
 	log.Printf("[DEBUG] Found %d active reminders", len(reminders))
 
+	batch := newDueBatch()
 	for _, reminder := range reminders {
 		log.Printf("[DEBUG] Processing reminder: ID=%s, Type=%s, Frequency=%s, ScheduledTime=%v",
 			reminder.ID.Hex(), reminder.Type, reminder.Frequency, reminder.ScheduledTime)
 
-		// Get user's FCM token
+		// Get the user and the channels chosen for this reminder type
 		var user models.User
 		err := s.db.Collection("users").FindOne(ctx, bson.M{"user_id": reminder.UserID}).Decode(&user)
 		if err != nil {
@@ -108,113 +214,545 @@ func (s *NotificationService) CheckAndSendReminders() error {
 			continue
 		}
 
-		if user.FCMToken == nil {
-			log.Printf("[ERROR] No FCM token found for user %s", user.User_id)
-			continue
-		}
+		channels := user.NotificationPreferences.ChannelsFor(reminder.Type)
+		log.Printf("[DEBUG] Channels for user %s, type %s: %v", user.User_id, reminder.Type, channels)
 
-		log.Printf("[DEBUG] User FCM token: %s", *user.FCMToken)
+		shouldSend := ReminderDue(reminder, now)
+		log.Printf("[DEBUG] Reminder %s (%s) due at %s: %v", reminder.ID.Hex(), reminder.Frequency, now.Format("2006-01-02 15:04 Monday"), shouldSend)
 
-		// Check if reminder should be sent based on its frequency
-		shouldSend := false
-		switch reminder.Frequency {
-		case "once":
-			// For one-time reminders, check if it's time
-			reminderTime := reminder.ScheduledTime.In(loc)
-			// Compare only hours and minutes, ignore seconds
-			nowTruncated := time.Date(now.Year(), now.Month(), now.Day(), now.Hour(), now.Minute(), 0, 0, loc)
-			reminderTimeTruncated := time.Date(reminderTime.Year(), reminderTime.Month(), reminderTime.Day(),
-				reminderTime.Hour(), reminderTime.Minute(), 0, 0, loc)
+		if shouldSend {
+			// Vacation mode pauses the reminder or hands it to the delegate
+			recipient, paused := s.resolveRecipient(ctx, &user, now)
+			if paused {
+				log.Printf("[DEBUG] User %s is on vacation, reminder %s is paused", user.User_id, reminder.ID.Hex())
+				continue
+			}
+			delegateUserID := ""
+			if recipient.User_id != user.User_id {
+				delegateUserID = recipient.User_id
+			}
 
-			log.Printf("[DEBUG] One-time reminder check: now=%v, scheduled=%v", nowTruncated, reminderTimeTruncated)
-			if nowTruncated.Equal(reminderTimeTruncated) {
-				shouldSend = true
-				log.Printf("[DEBUG] One-time reminder should be sent")
+			// The occurrence is unique per reminder and minute, so a reminder fires once per slot
+			slot := time.Date(now.Year(), now.Month(), now.Day(), now.Hour(), now.Minute(), 0, 0, loc)
+			occurrence, created, err := s.occurrences.Create(ctx, reminder, slot, s.clock.Now(), delegateUserID)
+			if err != nil {
+				log.Printf("[ERROR] Error recording occurrence for reminder %s: %v", reminder.ID.Hex(), err)
+				continue
 			}
-		case "daily":
-			// For daily reminders, check if current time matches (ignoring seconds)
-			currentTime := now.Format("15:04")
-			scheduledTime := reminder.TimeOfDay
-			log.Printf("[DEBUG] Daily reminder check: current=%s, scheduled=%s", currentTime, scheduledTime)
-
-			if currentTime == scheduledTime {
-				shouldSend = true
-				log.Printf("[DEBUG] Daily reminder should be sent")
+			if !created {
+				continue
 			}
-		case "weekly":
-			// For weekly reminders, check if current day and time match (ignoring seconds)
-			currentDay := now.Format("Monday")
-			currentTime := now.Format("15:04")
-			scheduledTime := reminder.TimeOfDay
-			log.Printf("[DEBUG] Weekly reminder check: day=%s, time=%s, scheduled_day=%s, scheduled_time=%s",
-				currentDay, currentTime, reminder.DayOfWeek, scheduledTime)
-
-			if currentDay == reminder.DayOfWeek && currentTime == scheduledTime {
-				shouldSend = true
-				log.Printf("[DEBUG] Weekly reminder should be sent")
+
+			log.Printf("[DEBUG] Preparing to send notification for reminder %s", reminder.ID.Hex())
+			batch.add(recipient, reminder, *occurrence)
+
+			// If it's a one-time reminder, mark it as inactive
+			if reminder.Frequency == "once" {
+				_, err = s.db.Collection("reminders").UpdateOne(
+					ctx,
+					bson.M{"_id": reminder.ID},
+					bson.M{"$set": bson.M{"is_active": false}, "$inc": bson.M{"version": 1}},
+				)
+				if err != nil {
+					log.Printf("[ERROR] Error updating one-time reminder %s: %v", reminder.ID.Hex(), err)
+				} else {
+					log.Printf("[DEBUG] Marked one-time reminder %s as inactive", reminder.ID.Hex())
+				}
 			}
 		}
+	}
 
-		if shouldSend {
-			// Check if we've already sent a notification for this reminder in the current minute
-			lastNotificationKey := fmt.Sprintf("last_notification_%s", reminder.ID.Hex())
-			lastNotificationTime, exists := s.lastNotificationTimes[lastNotificationKey]
-
-			if !exists || !lastNotificationTime.Equal(time.Date(now.Year(), now.Month(), now.Day(), now.Hour(), now.Minute(), 0, 0, loc)) {
-				log.Printf("[DEBUG] Preparing to send notification for reminder %s", reminder.ID.Hex())
-				// Parse notification data
-				var notificationData map[string]interface{}
-				if err := json.Unmarshal([]byte(reminder.NotificationData), &notificationData); err != nil {
-					log.Printf("[ERROR] Error parsing notification data for reminder %s: %v", reminder.ID.Hex(), err)
-					continue
-				}
+	s.dispatch(ctx, batch)
+	return nil
+}
 
-				// Extract title, body, and plantName from notification data
-				title, okTitle := notificationData["title"].(string)
-				body, okBody := notificationData["body"].(string)
+// ReminderDue reports whether the reminder fires in the minute of now. Times of day and
+// weekdays are read in now's location, which the scheduler sets to Asia/Bangkok.
+func ReminderDue(reminder models.Reminder, now time.Time) bool {
+	switch reminder.Frequency {
+	case "once":
+		// Compare only hours and minutes, ignore seconds
+		return now.Truncate(time.Minute).Equal(reminder.ScheduledTime.Truncate(time.Minute))
+	case "daily":
+		return now.Format("15:04") == reminder.TimeOfDay
+	case "weekly":
+		return now.Format("Monday") == reminder.DayOfWeek && now.Format("15:04") == reminder.TimeOfDay
+	case "interval":
+		// Every IntervalDays days at TimeOfDay, counted from the day of ScheduledTime
+		return IntervalReminderDue(reminder, now)
+	}
+	return false
+}
 
-				if !okTitle || !okBody || title == "" || body == "" {
-					log.Printf("[ERROR] Notification data missing title/body for reminder %s", reminder.ID.Hex())
-					continue
-				}
+// resolveRecipient returns who should get the user's reminders at now.
+// It returns paused=true when the user is on vacation in pause mode.
+func (s *NotificationService) resolveRecipient(ctx context.Context, user *models.User, now time.Time) (*models.User, bool) {
+	vacation := user.NotificationPreferences.ActiveVacation(now)
+	if vacation == nil {
+		return user, false
+	}
+	if vacation.Mode != models.VacationModeRedirect || vacation.DelegateUserID == "" {
+		return nil, true
+	}
 
-				// Convert notification data to string map for FCM
-				data := make(map[string]string)
-				for k, v := range notificationData {
-					data[k] = fmt.Sprintf("%v", v)
-				}
+	// The delegate may have stopped looking after the plants since the vacation was set
+	if _, err := NewCaretakerService(s.db).Accepted(ctx, user.User_id, vacation.DelegateUserID); err != nil {
+		log.Printf("[ERROR] Vacation delegate %s of user %s is no longer a caretaker: %v", vacation.DelegateUserID, user.User_id, err)
+		return user, false
+	}
+	var delegate models.User
+	err := s.db.Collection("users").FindOne(ctx, bson.M{"user_id": vacation.DelegateUserID}).Decode(&delegate)
+	if err != nil {
+		// Better the owner gets it while away than nobody does
+		log.Printf("[ERROR] Error fetching vacation delegate %s for user %s: %v", vacation.DelegateUserID, user.User_id, err)
+		return user, false
+	}
+	return &delegate, false
+}
 
-				// Send notification
-				err = s.SendNotification(*user.FCMToken, title, body, data)
-				if err != nil {
-					log.Printf("[ERROR] Error sending notification for reminder %s: %v", reminder.ID.Hex(), err)
-					continue
-				}
-				log.Printf("[DEBUG] Successfully sent notification for reminder %s", reminder.ID.Hex())
-
-				// Update last notification time
-				s.lastNotificationTimes[lastNotificationKey] = time.Date(now.Year(), now.Month(), now.Day(), now.Hour(), now.Minute(), 0, 0, loc)
-
-				// If it's a one-time reminder, mark it as inactive
-				if reminder.Frequency == "once" {
-					_, err = s.db.Collection("reminders").UpdateOne(
-						ctx,
-						bson.M{"_id": reminder.ID},
-						bson.M{"$set": bson.M{"is_active": false}},
-					)
-					if err != nil {
-						log.Printf("[ERROR] Error updating one-time reminder %s: %v", reminder.ID.Hex(), err)
-					} else {
-						log.Printf("[DEBUG] Marked one-time reminder %s as inactive", reminder.ID.Hex())
-					}
-				}
+// dueOccurrence is an occurrence ready to go out, with the reminder it belongs to
+type dueOccurrence struct {
+	reminder   models.Reminder
+	occurrence models.ReminderOccurrence
+}
+
+// dueBatch groups the occurrences due in one scheduler pass by recipient
+type dueBatch struct {
+	recipients map[string]*models.User
+	due        map[string][]dueOccurrence
+	order      []string
+}
+
+func newDueBatch() *dueBatch {
+	return &dueBatch{
+		recipients: make(map[string]*models.User),
+		due:        make(map[string][]dueOccurrence),
+	}
+}
+
+func (b *dueBatch) add(recipient *models.User, reminder models.Reminder, occurrence models.ReminderOccurrence) {
+	if _, ok := b.recipients[recipient.User_id]; !ok {
+		b.recipients[recipient.User_id] = recipient
+		b.order = append(b.order, recipient.User_id)
+	}
+	b.due[recipient.User_id] = append(b.due[recipient.User_id], dueOccurrence{reminder: reminder, occurrence: occurrence})
+}
+
+// dispatch sends every occurrence in the batch. A recipient who opted in to the digest
+// and has more than one occurrence due gets a single notification listing them all.
+func (s *NotificationService) dispatch(ctx context.Context, batch *dueBatch) {
+	for _, userID := range batch.order {
+		user := batch.recipients[userID]
+		items := batch.due[userID]
+
+		if s.deferIfQuiet(ctx, user, items) {
+			continue
+		}
+		if len(items) > 1 && user.NotificationPreferences.DigestEnabled() {
+			s.sendDigest(ctx, user, items)
+			continue
+		}
+		for i := range items {
+			s.sendOccurrence(ctx, items[i].reminder, user, &items[i].occurrence)
+		}
+	}
+}
+
+// deferIfQuiet holds the occurrences back until the end of the recipient's quiet hours.
+// It returns false when the recipient is not in quiet hours.
+func (s *NotificationService) deferIfQuiet(ctx context.Context, user *models.User, items []dueOccurrence) bool {
+	loc, _ := time.LoadLocation("Asia/Bangkok")
+	now := s.clock.Now().In(loc)
+	until, quiet := user.NotificationPreferences.QuietUntil(now)
+	if !quiet {
+		return false
+	}
+
+	for _, item := range items {
+		if err := s.occurrences.Defer(ctx, item.occurrence.ID, until, now); err != nil {
+			log.Printf("[ERROR] %v", err)
+			continue
+		}
+		log.Printf("[DEBUG] Quiet hours for user %s, occurrence %s deferred to %v", user.User_id, item.occurrence.ID.Hex(), until)
+	}
+	return true
+}
+
+// sendOccurrence records one delivery per channel and makes the first attempt; failures are retried by RetryDueDeliveries
+func (s *NotificationService) sendOccurrence(ctx context.Context, reminder models.Reminder, user *models.User, occurrence *models.ReminderOccurrence) {
+	s.sendOccurrenceOn(ctx, reminder, user, occurrence, user.NotificationPreferences.ChannelsFor(reminder.Type))
+}
+
+// sendOccurrenceOn sends an occurrence to the user on the given channels
+func (s *NotificationService) sendOccurrenceOn(ctx context.Context, reminder models.Reminder, user *models.User, occurrence *models.ReminderOccurrence, channels []string) {
+	payload := s.buildReminderPayload(ctx, reminder, user)
+	payload.Data["occurrenceId"] = occurrence.ID.Hex()
+	payload.Data["actions"] = "acknowledge,snooze,skip"
+	if user.User_id != occurrence.UserID {
+		payload.Data["ownerUserId"] = occurrence.UserID
+	}
+	if occurrence.EscalationLevel > 0 {
+		payload.Data["escalationStep"] = strconv.Itoa(occurrence.EscalationLevel)
+	}
+	s.addToInbox(ctx, user.User_id, reminder.Type, &reminder.PlantID, payload)
+	s.events.Publish(user.User_id, EventReminderFired, occurrence)
+
+	for _, channel := range channels {
+		if _, ok := s.notifiers[channel]; !ok {
+			log.Printf("[ERROR] Channel %s is not configured, skipping for reminder %s", channel, reminder.ID.Hex())
+			continue
+		}
+		delivery, err := s.enqueueDelivery(ctx, user.User_id, []primitive.ObjectID{reminder.ID}, channel, payload)
+		if err != nil {
+			log.Printf("[ERROR] Error queueing %s notification for reminder %s: %v", channel, reminder.ID.Hex(), err)
+			continue
+		}
+		log.Printf("[DEBUG] Delivery %s (%s) for occurrence %s is %s", delivery.ID.Hex(), channel, occurrence.ID.Hex(), delivery.Status)
+	}
+}
+
+// sendDigest sends one notification for several due occurrences on every channel the user
+// chose for any of them, so users with email turned on also get a digest email
+func (s *NotificationService) sendDigest(ctx context.Context, user *models.User, items []dueOccurrence) {
+	var (
+		names         []string
+		reminderIDs   []primitive.ObjectID
+		reminderHexes []string
+		occurrenceIDs []string
+		channels      []string
+	)
+	reminderType := items[0].reminder.Type
+	seenChannels := make(map[string]bool)
+
+	for _, item := range items {
+		names = append(names, s.reminderTemplateVars(ctx, item.reminder).PlantName)
+		reminderIDs = append(reminderIDs, item.reminder.ID)
+		reminderHexes = append(reminderHexes, item.reminder.ID.Hex())
+		occurrenceIDs = append(occurrenceIDs, item.occurrence.ID.Hex())
+		if item.reminder.Type != reminderType {
+			reminderType = ""
+		}
+		for _, channel := range user.NotificationPreferences.ChannelsFor(item.reminder.Type) {
+			if !seenChannels[channel] {
+				seenChannels[channel] = true
+				channels = append(channels, channel)
 			}
 		}
 	}
 
+	title, body := s.templates.Render(ctx, DigestTemplateType, user.Language, TemplateVars{
+		ReminderType:    reminderType,
+		Count:           len(items),
+		PlantNames:      strings.Join(names, ", "),
+		ShortPlantNames: shortList(names, 2),
+	})
+	payload := models.NotificationPayload{
+		Title: title,
+		Body:  body,
+		Data: map[string]string{
+			"type":          DigestTemplateType,
+			"count":         strconv.Itoa(len(items)),
+			"reminderIds":   strings.Join(reminderHexes, ","),
+			"occurrenceIds": strings.Join(occurrenceIDs, ","),
+			"link":          "/reminder?ids=" + strings.Join(reminderHexes, ","),
+		},
+	}
+	s.addToInbox(ctx, user.User_id, DigestTemplateType, nil, payload)
+	for _, item := range items {
+		s.events.Publish(user.User_id, EventReminderFired, item.occurrence)
+	}
+
+	for _, channel := range channels {
+		if _, ok := s.notifiers[channel]; !ok {
+			log.Printf("[ERROR] Channel %s is not configured, skipping digest for user %s", channel, user.User_id)
+			continue
+		}
+		delivery, err := s.enqueueDelivery(ctx, user.User_id, reminderIDs, channel, payload)
+		if err != nil {
+			log.Printf("[ERROR] Error queueing %s digest for user %s: %v", channel, user.User_id, err)
+			continue
+		}
+		log.Printf("[DEBUG] Digest delivery %s (%s) for %d reminder(s) is %s", delivery.ID.Hex(), channel, len(items), delivery.Status)
+	}
+}
+
+// addToInbox keeps a copy of the notification in the user's in-app inbox, whatever happens to the deliveries,
+// and tells the user's open dashboards about it
+func (s *NotificationService) addToInbox(ctx context.Context, userID, itemType string, plantID *primitive.ObjectID, payload models.NotificationPayload) {
+	item, err := s.inbox.Add(ctx, userID, itemType, plantID, payload)
+	if err != nil {
+		log.Printf("[ERROR] Error adding notification to inbox of user %s: %v", userID, err)
+		return
+	}
+	s.events.Publish(userID, EventNotificationReceived, item)
+}
+
+// SendGrowthAlert tells the user one of their plants was flagged, linking to the diagnosis prefilled with the
+// alert's symptoms. It returns false when the user has growth alerts turned off. During quiet hours or a
+// vacation the alert only goes to the inbox; unlike a reminder it is not worth waking anyone for.
+func (s *NotificationService) SendGrowthAlert(ctx context.Context, alert models.GrowthAlert) (bool, error) {
+	var user models.User
+	if err := s.db.Collection("users").FindOne(ctx, bson.M{"user_id": alert.UserID}).Decode(&user); err != nil {
+		return false, fmt.Errorf("error fetching user: %v", err)
+	}
+	if !user.NotificationPreferences.GrowthAlertsEnabled() {
+		return false, nil
+	}
+
+	title, body := s.templates.Render(ctx, GrowthAlertTemplateType, user.Language, TemplateVars{
+		PlantName: alert.PlantName,
+		AlertType: alert.Type,
+	})
+	payload := models.NotificationPayload{
+		Title: title,
+		Body:  body,
+		Data: map[string]string{
+			"type":      GrowthAlertTemplateType,
+			"alertType": alert.Type,
+			"alertId":   alert.ID.Hex(),
+			"plantId":   alert.PlantID.Hex(),
+			"link":      alert.DiagnosisURL,
+		},
+	}
+	plantID := alert.PlantID
+	s.addToInbox(ctx, user.User_id, GrowthAlertTemplateType, &plantID, payload)
+
+	loc, _ := time.LoadLocation("Asia/Bangkok")
+	now := s.clock.Now().In(loc)
+	if _, quiet := user.NotificationPreferences.QuietUntil(now); quiet || user.NotificationPreferences.ActiveVacation(now) != nil {
+		return true, nil
+	}
+	for _, channel := range user.NotificationPreferences.ChannelsFor(GrowthAlertTemplateType) {
+		if _, ok := s.notifiers[channel]; !ok {
+			log.Printf("[ERROR] Channel %s is not configured, skipping growth alert for user %s", channel, user.User_id)
+			continue
+		}
+		if _, err := s.enqueueDelivery(ctx, user.User_id, nil, channel, payload); err != nil {
+			log.Printf("[ERROR] Error queueing %s growth alert for user %s: %v", channel, user.User_id, err)
+		}
+	}
+	return true, nil
+}
+
+// shortList joins the first n names, ending with "…" when some are left out
+func shortList(names []string, n int) string {
+	if len(names) <= n {
+		return strings.Join(names, ", ")
+	}
+	return strings.Join(names[:n], ", ") + "…"
+}
+
+// ProcessOccurrenceFollowUps sends occurrences whose snooze or quiet hours have ended,
+// and resends once the occurrences left unacknowledged past AcknowledgementWindow
+func (s *NotificationService) ProcessOccurrenceFollowUps() error {
+	ctx := context.Background()
+	now := s.clock.Now()
+
+	snoozed, err := s.occurrences.ClaimDueSnoozed(ctx, now)
+	if err != nil {
+		return err
+	}
+	deferred, err := s.occurrences.ClaimDueDeferred(ctx, now)
+	if err != nil {
+		return err
+	}
+	unacknowledged, err := s.occurrences.ClaimUnacknowledged(ctx, now)
+	if err != nil {
+		return err
+	}
+
+	batch := newDueBatch()
+	followUps := append(append(snoozed, deferred...), unacknowledged...)
+	for _, occurrence := range followUps {
+		var reminder models.Reminder
+		if err := s.db.Collection("reminders").FindOne(ctx, bson.M{"_id": occurrence.ReminderID}).Decode(&reminder); err != nil {
+			log.Printf("[ERROR] Error fetching reminder for occurrence %s: %v", occurrence.ID.Hex(), err)
+			continue
+		}
+		recipient, ok := s.followUpRecipient(ctx, occurrence, now)
+		if !ok {
+			continue
+		}
+
+		log.Printf("[DEBUG] Sending follow-up for occurrence %s (status %s)", occurrence.ID.Hex(), occurrence.Status)
+		batch.add(recipient, reminder, occurrence)
+	}
+
+	s.dispatch(ctx, batch)
 	return nil
 }
 
+// followUpRecipient returns who a follow-up of the occurrence goes to. A delegated occurrence
+// stays with the delegate, otherwise the owner's vacation applies now.
+func (s *NotificationService) followUpRecipient(ctx context.Context, occurrence models.ReminderOccurrence, now time.Time) (*models.User, bool) {
+	recipientID := occurrence.UserID
+	if occurrence.DelegateUserID != "" {
+		recipientID = occurrence.DelegateUserID
+	}
+	var user models.User
+	if err := s.db.Collection("users").FindOne(ctx, bson.M{"user_id": recipientID}).Decode(&user); err != nil {
+		log.Printf("[ERROR] Error fetching user for occurrence %s: %v", occurrence.ID.Hex(), err)
+		return nil, false
+	}
+	if occurrence.DelegateUserID != "" {
+		return &user, true
+	}
+	recipient, paused := s.resolveRecipient(ctx, &user, now)
+	if paused {
+		log.Printf("[DEBUG] User %s is on vacation, follow-up for occurrence %s is dropped", user.User_id, occurrence.ID.Hex())
+		return nil, false
+	}
+	return recipient, true
+}
+
+// escalationDelegate fetches the delegate of an escalation step. It returns nil when the delegate
+// has stopped looking after the owner's plants since the step was set, or no longer has an account.
+func (s *NotificationService) escalationDelegate(ctx context.Context, ownerID, delegateID string) (*models.User, error) {
+	_, err := NewCaretakerService(s.db).Accepted(ctx, ownerID, delegateID)
+	if errors.Is(err, ErrCaretakerNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var delegate models.User
+	err = s.db.Collection("users").FindOne(ctx, bson.M{"user_id": delegateID}).Decode(&delegate)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error fetching user: %v", err)
+	}
+	return &delegate, nil
+}
+
+// ProcessEscalations takes the next escalation step of occurrences left unacknowledged.
+// Only pending occurrences escalate, so acknowledging, skipping or snoozing stops it.
+func (s *NotificationService) ProcessEscalations() error {
+	ctx := context.Background()
+	now := s.clock.Now()
+
+	occurrences, err := s.occurrences.ListEscalating(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, occurrence := range occurrences {
+		var reminder models.Reminder
+		err := s.db.Collection("reminders").FindOne(ctx, bson.M{"_id": occurrence.ReminderID}).Decode(&reminder)
+		if err == mongo.ErrNoDocuments {
+			// The reminder was deleted after this occurrence fired
+			if err := s.occurrences.StopEscalating(ctx, occurrence.ID, now); err != nil {
+				log.Printf("[ERROR] %v", err)
+			}
+			continue
+		}
+		if err != nil {
+			log.Printf("[ERROR] Error fetching reminder for occurrence %s: %v", occurrence.ID.Hex(), err)
+			continue
+		}
+		if occurrence.EscalationLevel >= len(reminder.Escalation) {
+			// The rules were shortened or removed after this occurrence fired
+			if err := s.occurrences.StopEscalating(ctx, occurrence.ID, now); err != nil {
+				log.Printf("[ERROR] %v", err)
+			}
+			continue
+		}
+
+		step := reminder.Escalation[occurrence.EscalationLevel]
+		if now.Before(LastEscalatedAt(occurrence).Add(time.Duration(step.AfterHours) * time.Hour)) {
+			continue
+		}
+
+		recipient, ok := s.followUpRecipient(ctx, occurrence, now)
+		if !ok {
+			continue
+		}
+		channels := recipient.NotificationPreferences.ChannelsFor(reminder.Type)
+		switch step.Action {
+		case models.EscalationActionChannel:
+			channels = []string{step.Channel}
+		case models.EscalationActionDelegate:
+			delegate, err := s.escalationDelegate(ctx, occurrence.UserID, step.DelegateUserID)
+			if err != nil {
+				log.Printf("[ERROR] Error fetching escalation delegate %s for occurrence %s: %v", step.DelegateUserID, occurrence.ID.Hex(), err)
+				continue
+			}
+			if delegate == nil {
+				log.Printf("[DEBUG] Escalation delegate %s of occurrence %s is no longer a caretaker, escalating to the owner", step.DelegateUserID, occurrence.ID.Hex())
+				break
+			}
+			recipient = delegate
+			channels = delegate.NotificationPreferences.ChannelsFor(reminder.Type)
+		}
+		// Quiet hours hold the step back; it is taken on the first tick after they end
+		if _, quiet := recipient.NotificationPreferences.QuietUntil(now); quiet {
+			continue
+		}
+
+		entry := models.EscalationEntry{
+			Step:            occurrence.EscalationLevel + 1,
+			Action:          step.Action,
+			Channels:        channels,
+			RecipientUserID: recipient.User_id,
+			At:              now,
+		}
+		escalated, err := s.occurrences.Escalate(ctx, occurrence, entry, entry.Step == len(reminder.Escalation), now)
+		if err != nil {
+			log.Printf("[ERROR] %v", err)
+			continue
+		}
+		if escalated == nil {
+			continue
+		}
+
+		log.Printf("[DEBUG] Escalating occurrence %s: step %d (%s) to user %s", occurrence.ID.Hex(), entry.Step, step.Action, recipient.User_id)
+		s.sendOccurrenceOn(ctx, reminder, recipient, escalated, channels)
+	}
+	return nil
+}
+
+// buildReminderPayload renders the notification for a reminder in the user's language.
+// The text comes from server-side templates, the data lets the app open the right plant.
+func (s *NotificationService) buildReminderPayload(ctx context.Context, reminder models.Reminder, user *models.User) models.NotificationPayload {
+	vars := s.reminderTemplateVars(ctx, reminder)
+	title, body := s.templates.Render(ctx, reminder.Type, user.Language, vars)
+
+	return models.NotificationPayload{
+		Title: title,
+		Body:  body,
+		Data: map[string]string{
+			"reminderId": reminder.ID.Hex(),
+			"plantId":    reminder.PlantID.Hex(),
+			"type":       reminder.Type,
+			"frequency":  reminder.Frequency,
+			"plantName":  vars.PlantName,
+		},
+	}
+}
+
+// reminderTemplateVars collects the template values of a reminder and its plant
+func (s *NotificationService) reminderTemplateVars(ctx context.Context, reminder models.Reminder) TemplateVars {
+	vars := TemplateVars{
+		ReminderType: reminder.Type,
+		Frequency:    reminder.Frequency,
+		TimeOfDay:    reminder.TimeOfDay,
+		DayOfWeek:    reminder.DayOfWeek,
+	}
+
+	var plant models.Plant
+	err := s.db.Collection("plants").FindOne(ctx, bson.M{"_id": reminder.PlantID}).Decode(&plant)
+	if err != nil {
+		log.Printf("[ERROR] Error fetching plant for reminder %s: %v", reminder.ID.Hex(), err)
+	} else {
+		vars.PlantName = plant.Name
+		vars.PlantType = plant.Type
+		vars.Container = plant.Container
+	}
+	return vars
+}
+
 // Helper function to parse time string to int
 func parseInt(s string) int {
 	var i int
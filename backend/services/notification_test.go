@@ -0,0 +1,207 @@
+package services
+
+import (
+	"authentication/models"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestDeliveryBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{4, 4 * time.Minute},
+		{7, 32 * time.Minute},
+		{8, time.Hour}, // 64 minutes, capped
+		{20, time.Hour},
+	}
+	for _, tt := range tests {
+		if got := deliveryBackoff(tt.attempts); got != tt.want {
+			t.Errorf("deliveryBackoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+// delivery reads back a stored delivery
+func (f *schedulerFixture) delivery(t *testing.T, id primitive.ObjectID) models.NotificationDelivery {
+	t.Helper()
+	var delivery models.NotificationDelivery
+	if err := f.db.Collection("notification_deliveries").FindOne(context.Background(), bson.M{"_id": id}).Decode(&delivery); err != nil {
+		t.Fatal(err)
+	}
+	return delivery
+}
+
+func TestFailedDeliveriesBackOffThenDeadLetter(t *testing.T) {
+	ctx := context.Background()
+	f := newSchedulerFixture(t, time.Date(2026, 3, 20, 9, 0, 0, 0, time.UTC))
+	f.push.Err = errors.New("service unavailable")
+
+	queued, err := f.service.enqueueDelivery(ctx, f.userID, nil, models.DeliveryChannelFCM, models.NotificationPayload{Title: "Water", Body: "Monstera"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wait := baseDeliveryBackoff
+	for attempt := 1; attempt < maxDeliveryAttempts; attempt++ {
+		delivery := f.delivery(t, queued.ID)
+		if delivery.Status != models.DeliveryStatusRetrying || delivery.Attempts != attempt {
+			t.Fatalf("after attempt %d: %s with %d attempt(s)", attempt, delivery.Status, delivery.Attempts)
+		}
+		if want := delivery.LastAttemptAt.Add(wait); delivery.NextAttemptAt == nil || !delivery.NextAttemptAt.Equal(want) {
+			t.Fatalf("after attempt %d: next attempt at %v, want %v", attempt, delivery.NextAttemptAt, want)
+		}
+
+		// Nothing is retried before the backoff has passed
+		f.clock.Set(delivery.NextAttemptAt.Add(-time.Second))
+		if err := f.service.RetryDueDeliveries(); err != nil {
+			t.Fatal(err)
+		}
+		if got := f.delivery(t, queued.ID).Attempts; got != attempt {
+			t.Fatalf("attempt %d retried before its backoff", attempt)
+		}
+
+		f.clock.Set(*delivery.NextAttemptAt)
+		if err := f.service.RetryDueDeliveries(); err != nil {
+			t.Fatal(err)
+		}
+		wait *= 2
+	}
+
+	delivery := f.delivery(t, queued.ID)
+	if delivery.Status != models.DeliveryStatusDeadLetter || delivery.Attempts != maxDeliveryAttempts || delivery.NextAttemptAt != nil {
+		t.Fatalf("after %d attempts: %+v, want dead letter", maxDeliveryAttempts, delivery)
+	}
+	if delivery.Error == "" {
+		t.Error("dead letter without the last error")
+	}
+
+	f.clock.Advance(24 * time.Hour)
+	if err := f.service.RetryDueDeliveries(); err != nil {
+		t.Fatal(err)
+	}
+	if got := f.delivery(t, queued.ID).Attempts; got != maxDeliveryAttempts {
+		t.Errorf("dead letter retried: %d attempts", got)
+	}
+}
+
+func TestFailedDeliveryIsSentOnRetry(t *testing.T) {
+	ctx := context.Background()
+	f := newSchedulerFixture(t, time.Date(2026, 3, 20, 9, 0, 0, 0, time.UTC))
+	f.push.Err = errors.New("service unavailable")
+
+	queued, err := f.service.enqueueDelivery(ctx, f.userID, nil, models.DeliveryChannelFCM, models.NotificationPayload{Title: "Water", Body: "Monstera"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.push.Err = nil
+	f.clock.Advance(baseDeliveryBackoff)
+	if err := f.service.RetryDueDeliveries(); err != nil {
+		t.Fatal(err)
+	}
+
+	delivery := f.delivery(t, queued.ID)
+	if delivery.Status != models.DeliveryStatusSent || delivery.Attempts != 2 || delivery.SentAt == nil || delivery.Error != "" {
+		t.Errorf("delivery = %+v, want sent on the second attempt", delivery)
+	}
+	if n := len(f.push.Sent()); n != 1 {
+		t.Errorf("%d notifications sent, want 1", n)
+	}
+}
+
+func TestPermanentDeliveryFailuresAreNotRetried(t *testing.T) {
+	ctx := context.Background()
+	f := newSchedulerFixture(t, time.Date(2026, 3, 20, 9, 0, 0, 0, time.UTC))
+	payload := models.NotificationPayload{Title: "Water", Body: "Monstera"}
+
+	tests := []struct {
+		name    string
+		userID  string
+		channel string
+		err     error
+	}{
+		{"rejected by the provider", f.userID, models.DeliveryChannelFCM, permanentErrorf("invalid token")},
+		{"channel not configured", f.userID, models.DeliveryChannelWebhook, nil},
+		{"user deleted", "user-gone", models.DeliveryChannelFCM, nil},
+	}
+	for _, tt := range tests {
+		f.push.Err = tt.err
+		queued, err := f.service.enqueueDelivery(ctx, tt.userID, nil, tt.channel, payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.clock.Advance(maxDeliveryBackoff)
+		if err := f.service.RetryDueDeliveries(); err != nil {
+			t.Fatal(err)
+		}
+
+		delivery := f.delivery(t, queued.ID)
+		if delivery.Status != models.DeliveryStatusDeadLetter || delivery.Attempts != 1 {
+			t.Errorf("%s: %s after %d attempt(s), want dead letter after 1", tt.name, delivery.Status, delivery.Attempts)
+		}
+	}
+}
+
+func TestRemindersDueTogetherAreMergedIntoADigest(t *testing.T) {
+	loc := bangkok(t)
+	for _, digest := range []bool{true, false} {
+		f := newSchedulerFixture(t, time.Date(2026, 3, 2, 7, 59, 0, 0, loc))
+		_, err := f.db.Collection("users").UpdateOne(context.Background(), bson.M{"user_id": f.userID},
+			bson.M{"$set": bson.M{"notification_preferences": models.NotificationPreferences{Digest: digest}}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.addReminder(t, "water", models.Reminder{Frequency: "daily", TimeOfDay: "08:00"})
+		f.addReminder(t, "mist", models.Reminder{Frequency: "daily", TimeOfDay: "08:00"})
+		f.addReminder(t, "later", models.Reminder{Frequency: "daily", TimeOfDay: "09:00"})
+
+		f.clock.Set(time.Date(2026, 3, 2, 8, 0, 0, 0, loc))
+		if err := f.service.CheckAndSendReminders(); err != nil {
+			t.Fatal(err)
+		}
+		sent := f.push.Sent()
+		f.push.Reset()
+
+		if !digest {
+			if len(sent) != 2 || sent[0].Payload.Data["type"] == DigestTemplateType || sent[1].Payload.Data["type"] == DigestTemplateType {
+				t.Errorf("digest off: sent %+v, want two separate reminders", sent)
+			}
+		} else if len(sent) != 1 || sent[0].Payload.Data["type"] != DigestTemplateType || sent[0].Payload.Data["count"] != "2" {
+			t.Errorf("digest on: sent %+v, want one digest of 2", sent)
+		} else {
+			var names []string
+			for _, hex := range strings.Split(sent[0].Payload.Data["reminderIds"], ",") {
+				id, _ := primitive.ObjectIDFromHex(hex)
+				names = append(names, f.reminder[id])
+			}
+			sort.Strings(names)
+			if fmt.Sprint(names) != "[mist water]" {
+				t.Errorf("digest on: merged %v, want [mist water]", names)
+			}
+			if n := len(strings.Split(sent[0].Payload.Data["occurrenceIds"], ",")); n != 2 {
+				t.Errorf("digest on: %d occurrence(s) in the digest, want 2", n)
+			}
+		}
+
+		// A reminder due alone is never wrapped in a digest
+		f.clock.Set(time.Date(2026, 3, 2, 9, 0, 0, 0, loc))
+		if err := f.service.CheckAndSendReminders(); err != nil {
+			t.Fatal(err)
+		}
+		if got := f.sent(t); fmt.Sprint(got) != "[later]" {
+			t.Errorf("digest %v: at 09:00 sent %v, want [later]", digest, got)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package services
+
+import (
+	"authentication/models"
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrPermanentDelivery marks send errors that will never succeed on retry
+// (e.g. unregistered token, invalid message)
+var ErrPermanentDelivery = errors.New("permanent delivery failure")
+
+// permanentErrorf builds an error that is reported as ErrPermanentDelivery
+func permanentErrorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrPermanentDelivery, fmt.Sprintf(format, args...))
+}
+
+// Notifier delivers a notification to a user over one channel
+type Notifier interface {
+	// Channel returns the channel name stored on deliveries and in user preferences
+	Channel() string
+	// Send delivers the payload, returning an error wrapping ErrPermanentDelivery when retrying cannot help
+	Send(ctx context.Context, user *models.User, payload models.NotificationPayload) error
+}
+
+// IsKnownChannel reports whether the channel name can be used in notification preferences
+func IsKnownChannel(channel string) bool {
+	switch channel {
+	case models.DeliveryChannelFCM, models.DeliveryChannelEmail, models.DeliveryChannelWebhook:
+		return true
+	}
+	return false
+}
+
+// ReachableChannels returns the channels chosen for the reminder type that the user can actually be reached on
+func ReachableChannels(user *models.User, devices []models.Device, reminderType string) []string {
+	var reachable []string
+	for _, channel := range user.NotificationPreferences.ChannelsFor(reminderType) {
+		switch channel {
+		case models.DeliveryChannelFCM:
+			if len(devices) > 0 {
+				reachable = append(reachable, channel)
+			}
+		case models.DeliveryChannelEmail:
+			if user.Email != nil && *user.Email != "" {
+				reachable = append(reachable, channel)
+			}
+		case models.DeliveryChannelWebhook:
+			if user.NotificationPreferences != nil && user.NotificationPreferences.WebhookURL != "" {
+				reachable = append(reachable, channel)
+			}
+		}
+	}
+	return reachable
+}
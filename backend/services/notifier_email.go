@@ -0,0 +1,151 @@
+package services
+
+import (
+	"authentication/models"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"time"
+)
+
+// EmailNotifier sends notifications as plain text email over SMTP
+type EmailNotifier struct {
+	host     string
+	port     string
+	username string
+	password string
+	from     string
+}
+
+func NewEmailNotifier(host, port, username, password, from string) *EmailNotifier {
+	return &EmailNotifier{
+		host:     host,
+		port:     port,
+		username: username,
+		password: password,
+		from:     from,
+	}
+}
+
+// NewEmailNotifierFromEnv configures the notifier from SMTP_* variables.
+// It returns nil when SMTP_HOST is not set so email stays disabled.
+func NewEmailNotifierFromEnv() *EmailNotifier {
+	host := os.Getenv("SMTP_HOST")
+	if host == "" {
+		return nil
+	}
+	port := os.Getenv("SMTP_PORT")
+	if port == "" {
+		port = "587"
+	}
+	from := os.Getenv("SMTP_FROM")
+	if from == "" {
+		from = os.Getenv("SMTP_USERNAME")
+	}
+	return NewEmailNotifier(host, port, os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"), from)
+}
+
+func (n *EmailNotifier) Channel() string {
+	return models.DeliveryChannelEmail
+}
+
+func (n *EmailNotifier) Send(ctx context.Context, user *models.User, payload models.NotificationPayload) error {
+	if user.Email == nil || *user.Email == "" {
+		return permanentErrorf("no email address for user %s", user.User_id)
+	}
+
+	msg := buildEmailMessage(n.from, *user.Email, payload)
+	if err := n.sendMail(ctx, *user.Email, msg); err != nil {
+		// 5xx replies (unknown mailbox, rejected message) will not change on retry
+		var protoErr *textproto.Error
+		if errors.As(err, &protoErr) && protoErr.Code >= 500 {
+			return fmt.Errorf("error sending email: %w: %v", ErrPermanentDelivery, err)
+		}
+		return fmt.Errorf("error sending email: %v", err)
+	}
+
+	return nil
+}
+
+// emailTimeout bounds a whole SMTP conversation when ctx has no earlier deadline
+const emailTimeout = 30 * time.Second
+
+// sendMail does what smtp.SendMail does, but on a connection bounded by ctx so a server
+// that stops answering cannot hold up the scheduler
+func (n *EmailNotifier) sendMail(ctx context.Context, to string, msg []byte) error {
+	deadline := time.Now().Add(emailTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	dialer := net.Dialer{Deadline: deadline}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(n.host, n.port))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(deadline); err != nil {
+		return err
+	}
+	// Cancelling ctx interrupts whatever the conversation is waiting for
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	client, err := smtp.NewClient(conn, n.host)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: n.host}); err != nil {
+			return err
+		}
+	}
+	if n.username != "" {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			return errors.New("smtp: server doesn't support AUTH")
+		}
+		if err := client.Auth(smtp.PlainAuth("", n.username, n.password, n.host)); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(n.from); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+func buildEmailMessage(from, to string, payload models.NotificationPayload) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", to)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", payload.Title))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	buf.WriteString("\r\n")
+	buf.WriteString(payload.Body)
+	buf.WriteString("\r\n")
+	return buf.Bytes()
+}
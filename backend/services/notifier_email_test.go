@@ -0,0 +1,46 @@
+package services
+
+import (
+	"authentication/models"
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestEmailNotifierGivesUpOnAServerThatHangs(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	// Accepts a connection but never sends the greeting
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		if conn, err := listener.Accept(); err == nil {
+			accepted <- conn
+		}
+	}()
+	defer func() {
+		select {
+		case conn := <-accepted:
+			conn.Close()
+		default:
+		}
+	}()
+
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	notifier := NewEmailNotifier(host, port, "", "", "plante@example.com")
+	email := "friend@example.com"
+	user := &models.User{User_id: "user-1", Email: &email}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := notifier.Send(ctx, user, models.NotificationPayload{Title: "Water", Body: "Monstera"}); err == nil {
+		t.Fatal("Send succeeded without a server reply")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Send took %v, want it bounded by the context", elapsed)
+	}
+}
@@ -0,0 +1,58 @@
+package services
+
+import (
+	"authentication/models"
+	"context"
+	"sync"
+)
+
+// FakeNotification is one notification captured by FakeNotifier
+type FakeNotification struct {
+	UserID  string
+	Payload models.NotificationPayload
+}
+
+// FakeNotifier is an in-memory Notifier for tests and local development.
+// It records every notification and fails with Err when it is set.
+type FakeNotifier struct {
+	channel string
+
+	mu   sync.Mutex
+	sent []FakeNotification
+	Err  error
+}
+
+func NewFakeNotifier(channel string) *FakeNotifier {
+	return &FakeNotifier{channel: channel}
+}
+
+func (n *FakeNotifier) Channel() string {
+	return n.channel
+}
+
+func (n *FakeNotifier) Send(ctx context.Context, user *models.User, payload models.NotificationPayload) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.Err != nil {
+		return n.Err
+	}
+	n.sent = append(n.sent, FakeNotification{UserID: user.User_id, Payload: payload})
+	return nil
+}
+
+// Sent returns a copy of the notifications recorded so far
+func (n *FakeNotifier) Sent() []FakeNotification {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return append([]FakeNotification(nil), n.sent...)
+}
+
+// Reset forgets recorded notifications
+func (n *FakeNotifier) Reset() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.sent = nil
+}
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"authentication/models"
+	"context"
+	"fmt"
+	"log"
+
+	firebase "firebase.google.com/go/v4"
+	"firebase.google.com/go/v4/messaging"
+	"google.golang.org/api/option"
+)
+
+// fcmMulticastLimit is the maximum number of tokens FCM accepts in one multicast
+const fcmMulticastLimit = 500
+
+// FCMNotifier sends push notifications to every registered device of a user
+// through Firebase Cloud Messaging
+type FCMNotifier struct {
+	client  *messaging.Client
+	devices *DeviceService
+}
+
+func NewFCMNotifier(credentialsFile string, devices *DeviceService) (*FCMNotifier, error) {
+	// Initialize Firebase Admin SDK
+	opt := option.WithCredentialsFile(credentialsFile)
+	app, err := firebase.NewApp(context.Background(), nil, opt)
+	if err != nil {
+		return nil, fmt.Errorf("error initializing firebase app: %v", err)
+	}
+
+	// Get Messaging client
+	client, err := app.Messaging(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("error getting messaging client: %v", err)
+	}
+
+	return &FCMNotifier{client: client, devices: devices}, nil
+}
+
+func (n *FCMNotifier) Channel() string {
+	return models.DeliveryChannelFCM
+}
+
+// Send fans the payload out to all of the user's devices with FCM multicast.
+// Tokens that FCM reports as unregistered or invalid are removed from the registry.
+// The send counts as delivered when at least one device received it.
+func (n *FCMNotifier) Send(ctx context.Context, user *models.User, payload models.NotificationPayload) error {
+	devices, err := n.devices.GetUserDevices(ctx, user.User_id)
+	if err != nil {
+		return err
+	}
+	if len(devices) == 0 {
+		return permanentErrorf("no registered devices for user %s", user.User_id)
+	}
+
+	tokens := make([]string, len(devices))
+	for i, device := range devices {
+		tokens[i] = device.Token
+	}
+
+	delivered := 0
+	var deadTokens []string
+	rejected := 0 // Failures that will not succeed on retry, dead tokens included
+	var lastErr error
+	for start := 0; start < len(tokens); start += fcmMulticastLimit {
+		end := start + fcmMulticastLimit
+		if end > len(tokens) {
+			end = len(tokens)
+		}
+		batch := tokens[start:end]
+
+		response, err := n.client.SendEachForMulticast(ctx, buildFCMMulticastMessage(batch, payload))
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		delivered += response.SuccessCount
+		for i, result := range response.Responses {
+			if result.Success {
+				continue
+			}
+			lastErr = result.Error
+			switch {
+			case isDeadFCMToken(result.Error):
+				deadTokens = append(deadTokens, batch[i])
+				rejected++
+			case isPermanentFCMError(result.Error):
+				// Our credentials or message are at fault, not the device, so its token is kept
+				log.Printf("[ERROR] FCM rejected the message for user %s: %v", user.User_id, result.Error)
+				rejected++
+			}
+		}
+	}
+
+	if len(deadTokens) > 0 {
+		removed, err := n.devices.RemoveTokens(ctx, deadTokens)
+		if err != nil {
+			log.Printf("[ERROR] Error pruning dead FCM tokens for user %s: %v", user.User_id, err)
+		} else {
+			log.Printf("[DEBUG] Pruned %d dead FCM token(s) for user %s", removed, user.User_id)
+		}
+	}
+
+	switch {
+	case delivered > 0:
+		return nil
+	case len(deadTokens) == len(tokens):
+		return fmt.Errorf("error sending FCM message: %w: all device tokens are invalid: %v", ErrPermanentDelivery, lastErr)
+	case rejected == len(tokens):
+		return fmt.Errorf("error sending FCM message: %w: %v", ErrPermanentDelivery, lastErr)
+	default:
+		return fmt.Errorf("error sending FCM message: %v", lastErr)
+	}
+}
+
+func buildFCMMulticastMessage(tokens []string, payload models.NotificationPayload) *messaging.MulticastMessage {
+	badge := 1
+	return &messaging.MulticastMessage{
+		Tokens: tokens,
+		Notification: &messaging.Notification{
+			Title: payload.Title,
+			Body:  payload.Body,
+		},
+		Data: payload.Data,
+		Android: &messaging.AndroidConfig{
+			Priority: "high",
+			Notification: &messaging.AndroidNotification{
+				Title:    payload.Title,
+				Body:     payload.Body,
+				Priority: messaging.PriorityHigh,
+			},
+		},
+		APNS: &messaging.APNSConfig{
+			Payload: &messaging.APNSPayload{
+				Aps: &messaging.Aps{
+					Sound: "default",
+					Badge: &badge,
+				},
+			},
+		},
+	}
+}
+
+// isDeadFCMToken reports whether FCM rejected the token itself, e.g. because the app was uninstalled.
+// Only these tokens are removed.
+func isDeadFCMToken(err error) bool {
+	return messaging.IsUnregistered(err) || messaging.IsSenderIDMismatch(err)
+}
+
+// isPermanentFCMError reports whether FCM rejected the message in a way a retry will not fix:
+// an invalid payload, or APNs or web push credentials it could not use. These point at our
+// configuration rather than at the device, so the token is kept.
+func isPermanentFCMError(err error) bool {
+	return messaging.IsInvalidArgument(err) || messaging.IsThirdPartyAuthError(err)
+}
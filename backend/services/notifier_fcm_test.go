@@ -0,0 +1,160 @@
+package services
+
+import (
+	"authentication/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+
+	firebase "firebase.google.com/go/v4"
+	"firebase.google.com/go/v4/messaging"
+	"google.golang.org/api/option"
+)
+
+// fcmFailures are the FCM v1 error replies the fake server sends, by token
+var fcmFailures = map[string]struct {
+	status    int
+	code      string // google.rpc status
+	errorCode string // FcmError detail, if any
+}{
+	"unregistered":    {http.StatusNotFound, "NOT_FOUND", "UNREGISTERED"},
+	"sender-mismatch": {http.StatusForbidden, "PERMISSION_DENIED", "SENDER_ID_MISMATCH"},
+	"invalid-message": {http.StatusBadRequest, "INVALID_ARGUMENT", "INVALID_ARGUMENT"},
+	"apns-auth":       {http.StatusUnauthorized, "UNAUTHENTICATED", "THIRD_PARTY_AUTH_ERROR"},
+	"quota":           {http.StatusTooManyRequests, "RESOURCE_EXHAUSTED", "QUOTA_EXCEEDED"},
+}
+
+// newFakeFCMClient returns a messaging client talking to a server that answers each token
+// from fcmFailures and accepts any other token
+func newFakeFCMClient(t *testing.T) *messaging.Client {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			Message struct {
+				Token string `json:"token"`
+			} `json:"message"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		failure, ok := fcmFailures[request.Message.Token]
+		if !ok {
+			fmt.Fprintf(w, `{"name":"projects/plante-test/messages/%s"}`, request.Message.Token)
+			return
+		}
+		w.WriteHeader(failure.status)
+		fmt.Fprintf(w, `{"error":{"code":%d,"message":"%s","status":"%s","details":[{"@type":"type.googleapis.com/google.firebase.fcm.v1.FcmError","errorCode":"%s"}]}}`,
+			failure.status, failure.errorCode, failure.code, failure.errorCode)
+	}))
+	t.Cleanup(server.Close)
+
+	app, err := firebase.NewApp(context.Background(), &firebase.Config{ProjectID: "plante-test"},
+		option.WithEndpoint(server.URL), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := app.Messaging(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func TestFCMErrorClassification(t *testing.T) {
+	client := newFakeFCMClient(t)
+	tokens := []string{"ok", "unregistered", "sender-mismatch", "invalid-message", "apns-auth", "quota"}
+	want := map[string]struct{ dead, permanent bool }{
+		"unregistered":    {dead: true},
+		"sender-mismatch": {dead: true},
+		"invalid-message": {permanent: true},
+		"apns-auth":       {permanent: true},
+		"quota":           {},
+	}
+
+	response, err := client.SendEachForMulticast(context.Background(), buildFCMMulticastMessage(tokens, models.NotificationPayload{Title: "Water", Body: "Monstera"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.SuccessCount != 1 {
+		t.Errorf("%d delivered, want 1", response.SuccessCount)
+	}
+	for i, result := range response.Responses {
+		token := tokens[i]
+		if result.Success {
+			if token != "ok" {
+				t.Errorf("%s: delivered", token)
+			}
+			continue
+		}
+		if dead := isDeadFCMToken(result.Error); dead != want[token].dead {
+			t.Errorf("%s: dead token = %v, want %v (%v)", token, dead, want[token].dead, result.Error)
+		}
+		if permanent := isPermanentFCMError(result.Error); permanent != want[token].permanent {
+			t.Errorf("%s: permanent = %v, want %v (%v)", token, permanent, want[token].permanent, result.Error)
+		}
+	}
+}
+
+func TestFCMNotifierPrunesOnlyDeadTokens(t *testing.T) {
+	ctx := context.Background()
+	devices := NewDeviceService(newTestDatabase(t))
+	if err := devices.EnsureIndexes(ctx); err != nil {
+		t.Fatal(err)
+	}
+	notifier := &FCMNotifier{client: newFakeFCMClient(t), devices: devices}
+	user := &models.User{User_id: "user-1"}
+	register := func(tokens ...string) {
+		for _, token := range tokens {
+			if _, err := devices.RegisterDevice(ctx, user.User_id, token, "android", "1.0"); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	remaining := func() []string {
+		list, err := devices.GetUserDevices(ctx, user.User_id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var tokens []string
+		for _, device := range list {
+			tokens = append(tokens, device.Token)
+		}
+		sort.Strings(tokens)
+		return tokens
+	}
+	payload := models.NotificationPayload{Title: "Water", Body: "Monstera"}
+
+	register("ok", "unregistered", "sender-mismatch", "invalid-message", "apns-auth", "quota")
+	if err := notifier.Send(ctx, user, payload); err != nil {
+		t.Fatalf("Send with one working device: %v", err)
+	}
+	if got := fmt.Sprint(remaining()); got != "[apns-auth invalid-message ok quota]" {
+		t.Errorf("devices left = %s, want only the unregistered and mismatched tokens pruned", got)
+	}
+
+	// Without a working device, failures that are not the device's fault keep it registered
+	if _, err := devices.RemoveTokens(ctx, []string{"ok", "quota"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := notifier.Send(ctx, user, payload); !errors.Is(err, ErrPermanentDelivery) {
+		t.Errorf("Send with only rejected messages = %v, want a permanent error", err)
+	}
+	if got := fmt.Sprint(remaining()); got != "[apns-auth invalid-message]" {
+		t.Errorf("devices left = %s, want both kept", got)
+	}
+
+	register("quota")
+	if err := notifier.Send(ctx, user, payload); err == nil || errors.Is(err, ErrPermanentDelivery) {
+		t.Errorf("Send with a throttled device = %v, want a transient error", err)
+	}
+	if got := fmt.Sprint(remaining()); got != "[apns-auth invalid-message quota]" {
+		t.Errorf("devices left = %s, want all kept", got)
+	}
+}
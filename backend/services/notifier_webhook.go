@@ -0,0 +1,159 @@
+package services
+
+import (
+	"authentication/models"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// ErrWebhookHost is returned for webhook URLs that are not public http(s) URLs.
+// Webhooks are posted from the server, so loopback, private and link-local addresses,
+// e.g. the cloud metadata service, would reach internal services.
+var ErrWebhookHost = errors.New("webhook_url must be a public http(s) URL")
+
+// WebhookNotifier posts notifications as JSON to the URL in the user's preferences,
+// e.g. a home-automation hub
+type WebhookNotifier struct {
+	client *http.Client
+}
+
+func NewWebhookNotifier() *WebhookNotifier {
+	return &WebhookNotifier{client: newWebhookClient()}
+}
+
+// newWebhookClient returns a client that only connects to public addresses. The address is checked
+// when connecting, after DNS resolution, so a host resolving to an internal address is refused too.
+// Redirects are not followed.
+func newWebhookClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !isPublicIP(ip) {
+				return fmt.Errorf("%w: %s is not a public address", ErrWebhookHost, host)
+			}
+			return nil
+		},
+	}
+	return &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: &http.Transport{
+			// No proxy, the dialer has to see the webhook's own address
+			Proxy:               nil,
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: 5 * time.Second,
+			MaxIdleConns:        10,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// sharedAddressSpace is the carrier-grade NAT range of RFC 6598, which net.IP.IsPrivate leaves out
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// isPublicIP reports whether ip is a globally routable unicast address
+func isPublicIP(ip net.IP) bool {
+	return ip.IsGlobalUnicast() && !ip.IsPrivate() && !ip.IsLoopback() && !ip.IsLinkLocalUnicast() &&
+		!sharedAddressSpace.Contains(ip)
+}
+
+// CheckWebhookURL checks that rawURL is an http(s) URL whose host only resolves to public addresses.
+// The notifier checks the address again when posting, in case the host resolves differently by then.
+func CheckWebhookURL(ctx context.Context, rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Hostname() == "" {
+		return ErrWebhookHost
+	}
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, parsed.Hostname())
+	if err != nil || len(addrs) == 0 {
+		return fmt.Errorf("%w: %s cannot be resolved", ErrWebhookHost, parsed.Hostname())
+	}
+	for _, addr := range addrs {
+		if !isPublicIP(addr.IP) {
+			return fmt.Errorf("%w: %s is not a public address", ErrWebhookHost, parsed.Hostname())
+		}
+	}
+	return nil
+}
+
+type webhookBody struct {
+	Event  string            `json:"event"`
+	UserID string            `json:"userId"`
+	Title  string            `json:"title"`
+	Body   string            `json:"body"`
+	Data   map[string]string `json:"data,omitempty"`
+	SentAt time.Time         `json:"sentAt"`
+}
+
+func (n *WebhookNotifier) Channel() string {
+	return models.DeliveryChannelWebhook
+}
+
+func (n *WebhookNotifier) Send(ctx context.Context, user *models.User, payload models.NotificationPayload) error {
+	prefs := user.NotificationPreferences
+	if prefs == nil || prefs.WebhookURL == "" {
+		return permanentErrorf("no webhook URL for user %s", user.User_id)
+	}
+
+	body, err := json.Marshal(webhookBody{
+		Event:  "notification",
+		UserID: user.User_id,
+		Title:  payload.Title,
+		Body:   payload.Body,
+		Data:   payload.Data,
+		SentAt: time.Now(),
+	})
+	if err != nil {
+		return permanentErrorf("error encoding webhook body: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, prefs.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return permanentErrorf("invalid webhook URL: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Plante-Webhook/1.0")
+	if prefs.WebhookSecret != "" {
+		// Receivers can verify the body with HMAC-SHA256 of their secret
+		mac := hmac.New(sha256.New, []byte(prefs.WebhookSecret))
+		mac.Write(body)
+		req.Header.Set("X-Plante-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		if errors.Is(err, ErrWebhookHost) {
+			return permanentErrorf("error calling webhook: %v", err)
+		}
+		return fmt.Errorf("error calling webhook: %v", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode >= 300 && resp.StatusCode < 400:
+		return permanentErrorf("webhook redirected to %s, redirects are not followed", resp.Header.Get("Location"))
+	case resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	default:
+		return permanentErrorf("webhook returned status %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"authentication/models"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestIsPublicIP(t *testing.T) {
+	tests := map[string]bool{
+		"93.184.216.34":   true,
+		"2606:4700::1111": true,
+		"127.0.0.1":       false,
+		"::1":             false,
+		"10.1.2.3":        false,
+		"172.16.0.1":      false,
+		"192.168.1.10":    false,
+		"169.254.169.254": false,
+		"fe80::1":         false,
+		"fd00::1":         false,
+		"100.64.0.1":      false,
+		"0.0.0.0":         false,
+		"::":              false,
+		"224.0.0.1":       false,
+	}
+	for addr, want := range tests {
+		if got := isPublicIP(net.ParseIP(addr)); got != want {
+			t.Errorf("isPublicIP(%s) = %v, want %v", addr, got, want)
+		}
+	}
+}
+
+func TestCheckWebhookURL(t *testing.T) {
+	for _, rawURL := range []string{
+		"http://127.0.0.1:8080/hook",
+		"http://localhost/hook",
+		"http://169.254.169.254/latest/meta-data/",
+		"https://[::1]/hook",
+		"http://10.0.0.5/hook",
+		"ftp://93.184.216.34/hook",
+		"not a url",
+	} {
+		if err := CheckWebhookURL(context.Background(), rawURL); !errors.Is(err, ErrWebhookHost) {
+			t.Errorf("CheckWebhookURL(%q) = %v, want ErrWebhookHost", rawURL, err)
+		}
+	}
+	if err := CheckWebhookURL(context.Background(), "https://93.184.216.34/hook"); err != nil {
+		t.Errorf("public address refused: %v", err)
+	}
+}
+
+func TestWebhookNotifierRefusesInternalAddresses(t *testing.T) {
+	called := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer server.Close()
+
+	user := &models.User{User_id: "user-1", NotificationPreferences: &models.NotificationPreferences{WebhookURL: server.URL}}
+	err := NewWebhookNotifier().Send(context.Background(), user, models.NotificationPayload{Title: "Water"})
+	if !errors.Is(err, ErrPermanentDelivery) {
+		t.Errorf("Send to %s = %v, want a permanent failure", server.URL, err)
+	}
+	if called {
+		t.Error("the loopback webhook was called")
+	}
+}
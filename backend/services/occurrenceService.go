@@ -0,0 +1,426 @@
+package services
+
+import (
+	"authentication/models"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// AcknowledgementWindow is how long after firing an acknowledgement still counts as on time.
+	// An occurrence that is still open after the window is resent once.
+	AcknowledgementWindow = time.Hour
+	DefaultSnooze         = time.Hour
+	MaxSnooze             = 24 * time.Hour
+)
+
+var (
+	ErrOccurrenceNotFound = errors.New("occurrence not found")
+	// ErrOccurrenceClosed is returned when acting on an occurrence that was already acknowledged or skipped
+	ErrOccurrenceClosed = errors.New("occurrence is already closed")
+)
+
+// ReminderStats summarises how well a reminder is being followed
+type ReminderStats struct {
+	Total         int     `json:"total"`        // Occurrences that are settled (closed or past the window)
+	Acknowledged  int     `json:"acknowledged"` // Done, on time or late
+	OnTime        int     `json:"onTime"`       // Done within AcknowledgementWindow
+	Skipped       int     `json:"skipped"`
+	Missed        int     `json:"missed"` // Never acknowledged and past the window
+	Open          int     `json:"open"`   // Still waiting for the user
+	OnTimeRate    float64 `json:"onTimeRate"`
+	CurrentStreak int     `json:"currentStreak"` // Consecutive acknowledged occurrences, newest first
+}
+
+// OccurrenceService tracks each firing of a reminder and what the user did with it
+type OccurrenceService struct {
+	db *mongo.Database
+}
+
+func NewOccurrenceService(db *mongo.Database) *OccurrenceService {
+	return &OccurrenceService{db: db}
+}
+
+func (s *OccurrenceService) collection() *mongo.Collection {
+	return s.db.Collection("reminder_occurrences")
+}
+
+// EnsureIndexes makes each reminder slot fire once and keeps the follow-up queries fast
+func (s *OccurrenceService) EnsureIndexes(ctx context.Context) error {
+	_, err := s.collection().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "reminder_id", Value: 1}, {Key: "scheduled_for", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "snooze_until", Value: 1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "sent_at", Value: 1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "deferred_until", Value: 1}}},
+		{Keys: bson.D{{Key: "escalating", Value: 1}, {Key: "status", Value: 1}}},
+	})
+	if err != nil {
+		return fmt.Errorf("error creating reminder occurrence indexes: %v", err)
+	}
+	return nil
+}
+
+// Create records that the reminder fired for the slot. It returns false when the slot
+// already has an occurrence, which is how a reminder is kept from firing twice.
+// delegateUserID is set when the owner is on vacation and someone else gets the reminder.
+func (s *OccurrenceService) Create(ctx context.Context, reminder models.Reminder, slot, now time.Time, delegateUserID string) (*models.ReminderOccurrence, bool, error) {
+	occurrence := models.ReminderOccurrence{
+		ID:             primitive.NewObjectID(),
+		ReminderID:     reminder.ID,
+		UserID:         reminder.UserID,
+		PlantID:        reminder.PlantID,
+		Type:           reminder.Type,
+		ScheduledFor:   slot,
+		SentAt:         now,
+		Status:         models.OccurrenceStatusPending,
+		DelegateUserID: delegateUserID,
+		Escalating:     len(reminder.Escalation) > 0,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+
+	_, err := s.collection().InsertOne(ctx, occurrence)
+	if mongo.IsDuplicateKeyError(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("error creating reminder occurrence: %v", err)
+	}
+	return &occurrence, true, nil
+}
+
+// ClaimDueSnoozed reopens snoozed occurrences whose snooze has ended so they can be sent again
+func (s *OccurrenceService) ClaimDueSnoozed(ctx context.Context, now time.Time) ([]models.ReminderOccurrence, error) {
+	return s.claim(ctx,
+		bson.M{"status": models.OccurrenceStatusSnoozed, "snooze_until": bson.M{"$lte": now}},
+		bson.M{
+			"$set":   bson.M{"status": models.OccurrenceStatusPending, "sent_at": now, "updated_at": now},
+			"$unset": bson.M{"snooze_until": ""},
+		},
+	)
+}
+
+// Defer holds an occurrence back until the recipient's quiet hours end
+func (s *OccurrenceService) Defer(ctx context.Context, occurrenceID primitive.ObjectID, until, now time.Time) error {
+	filter := bson.M{"_id": occurrenceID, "status": models.OccurrenceStatusPending}
+	_, err := s.collection().UpdateOne(ctx, filter, bson.M{
+		"$set": bson.M{"status": models.OccurrenceStatusDeferred, "deferred_until": until, "updated_at": now},
+	})
+	if err != nil {
+		return fmt.Errorf("error deferring reminder occurrence: %v", err)
+	}
+	return nil
+}
+
+// ClaimDueDeferred reopens occurrences whose quiet hours have ended so they can be sent
+func (s *OccurrenceService) ClaimDueDeferred(ctx context.Context, now time.Time) ([]models.ReminderOccurrence, error) {
+	return s.claim(ctx,
+		bson.M{"status": models.OccurrenceStatusDeferred, "deferred_until": bson.M{"$lte": now}},
+		bson.M{
+			"$set":   bson.M{"status": models.OccurrenceStatusPending, "sent_at": now, "updated_at": now},
+			"$unset": bson.M{"deferred_until": ""},
+		},
+	)
+}
+
+// ClaimUnacknowledged marks open occurrences that outlived the acknowledgement window as resent.
+// Each occurrence is claimed at most once. Occurrences of reminders with escalation rules
+// follow those instead, unless the rules were removed before any step was taken.
+func (s *OccurrenceService) ClaimUnacknowledged(ctx context.Context, now time.Time) ([]models.ReminderOccurrence, error) {
+	return s.claim(ctx,
+		bson.M{
+			"status":           models.OccurrenceStatusPending,
+			"resent_at":        bson.M{"$exists": false},
+			"escalating":       bson.M{"$ne": true},
+			"escalation_level": bson.M{"$in": bson.A{0, nil}},
+			"sent_at":          bson.M{"$lte": now.Add(-AcknowledgementWindow)},
+		},
+		bson.M{"$set": bson.M{"resent_at": now, "updated_at": now}},
+	)
+}
+
+// ListEscalating returns the open occurrences that still have escalation steps to take
+func (s *OccurrenceService) ListEscalating(ctx context.Context) ([]models.ReminderOccurrence, error) {
+	cursor, err := s.collection().Find(ctx, bson.M{"escalating": true, "status": models.OccurrenceStatusPending})
+	if err != nil {
+		return nil, fmt.Errorf("error finding escalating occurrences: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	var occurrences []models.ReminderOccurrence
+	if err := cursor.All(ctx, &occurrences); err != nil {
+		return nil, fmt.Errorf("error decoding escalating occurrences: %v", err)
+	}
+	return occurrences, nil
+}
+
+// LastEscalatedAt is when the occurrence was last sent or escalated; the next step counts from there
+func LastEscalatedAt(occurrence models.ReminderOccurrence) time.Time {
+	last := occurrence.SentAt
+	if n := len(occurrence.EscalationLog); n > 0 && occurrence.EscalationLog[n-1].At.After(last) {
+		last = occurrence.EscalationLog[n-1].At
+	}
+	return last
+}
+
+// Escalate logs the next escalation step of a pending occurrence. It returns nil when the
+// occurrence was acknowledged, snoozed or escalated elsewhere in the meantime.
+// A delegate recipient is added as a caretaker so they can act on the occurrence too.
+func (s *OccurrenceService) Escalate(ctx context.Context, occurrence models.ReminderOccurrence, entry models.EscalationEntry, last bool, now time.Time) (*models.ReminderOccurrence, error) {
+	filter := bson.M{
+		"_id":              occurrence.ID,
+		"status":           models.OccurrenceStatusPending,
+		"escalating":       true,
+		"escalation_level": occurrence.EscalationLevel,
+	}
+	if occurrence.EscalationLevel == 0 {
+		// Not stored until the first step
+		filter["escalation_level"] = bson.M{"$in": bson.A{0, nil}}
+	}
+
+	update := bson.M{
+		"$set":  bson.M{"escalation_level": occurrence.EscalationLevel + 1, "escalating": !last, "updated_at": now},
+		"$push": bson.M{"escalation_log": entry},
+	}
+	if entry.RecipientUserID != occurrence.UserID && entry.RecipientUserID != occurrence.DelegateUserID {
+		update["$addToSet"] = bson.M{"caretaker_ids": entry.RecipientUserID}
+	}
+
+	var updated models.ReminderOccurrence
+	err := s.collection().FindOneAndUpdate(ctx, filter, update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&updated)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error escalating reminder occurrence: %v", err)
+	}
+	return &updated, nil
+}
+
+// StopEscalating ends escalation of an occurrence, e.g. when its reminder's rules were removed
+func (s *OccurrenceService) StopEscalating(ctx context.Context, occurrenceID primitive.ObjectID, now time.Time) error {
+	_, err := s.collection().UpdateOne(ctx, bson.M{"_id": occurrenceID}, bson.M{
+		"$set": bson.M{"escalating": false, "updated_at": now},
+	})
+	if err != nil {
+		return fmt.Errorf("error stopping escalation: %v", err)
+	}
+	return nil
+}
+
+// DeleteForReminders removes the occurrences of deleted reminders so none of them is resent,
+// escalated or left open in the user's pending list
+func (s *OccurrenceService) DeleteForReminders(ctx context.Context, reminderIDs []primitive.ObjectID) error {
+	if len(reminderIDs) == 0 {
+		return nil
+	}
+	if _, err := s.collection().DeleteMany(ctx, bson.M{"reminder_id": bson.M{"$in": reminderIDs}}); err != nil {
+		return fmt.Errorf("error deleting reminder occurrences: %v", err)
+	}
+	return nil
+}
+
+// claim applies update to every document matching filter, one at a time with the filter
+// repeated, so that concurrent schedulers never claim the same occurrence
+func (s *OccurrenceService) claim(ctx context.Context, filter, update bson.M) ([]models.ReminderOccurrence, error) {
+	cursor, err := s.collection().Find(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("error finding reminder occurrences: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	var candidates []models.ReminderOccurrence
+	if err := cursor.All(ctx, &candidates); err != nil {
+		return nil, fmt.Errorf("error decoding reminder occurrences: %v", err)
+	}
+
+	var claimed []models.ReminderOccurrence
+	for _, candidate := range candidates {
+		claimFilter := bson.M{"_id": candidate.ID}
+		for k, v := range filter {
+			claimFilter[k] = v
+		}
+
+		var occurrence models.ReminderOccurrence
+		err := s.collection().FindOneAndUpdate(ctx, claimFilter, update,
+			options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&occurrence)
+		if err == mongo.ErrNoDocuments {
+			continue
+		}
+		if err != nil {
+			log.Printf("[ERROR] Error claiming reminder occurrence %s: %v", candidate.ID.Hex(), err)
+			continue
+		}
+		claimed = append(claimed, occurrence)
+	}
+	return claimed, nil
+}
+
+// Acknowledge closes the occurrence as done and records the matching care event.
+// details carries what the user noted about the care done, e.g. the amount of water.
+func (s *OccurrenceService) Acknowledge(ctx context.Context, userID string, occurrenceID primitive.ObjectID, details models.CareEvent, now time.Time) (*models.ReminderOccurrence, error) {
+	occurrence, err := s.close(ctx, userID, occurrenceID, bson.M{
+		"$set":   bson.M{"status": models.OccurrenceStatusAcknowledged, "acknowledged_at": now, "updated_at": now},
+		"$unset": bson.M{"snooze_until": "", "deferred_until": ""},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	event := details
+	event.ID = primitive.NewObjectID()
+	event.UserID = occurrence.UserID
+	event.PlantID = occurrence.PlantID
+	event.Type = occurrence.Type
+	if !models.IsCareEventType(event.Type) {
+		event.Type = models.CareEventOther
+	}
+	event.Date = now
+	event.ReminderID = &occurrence.ReminderID
+	event.OccurrenceID = &occurrence.ID
+	event.CreatedAt = now
+	if _, err := NewCareEventService(s.db).Create(ctx, event); err != nil {
+		log.Printf("[ERROR] Error recording care event for occurrence %s: %v", occurrence.ID.Hex(), err)
+	}
+
+	return occurrence, nil
+}
+
+// Snooze postpones the occurrence; the scheduler sends it again at now+duration
+func (s *OccurrenceService) Snooze(ctx context.Context, userID string, occurrenceID primitive.ObjectID, duration time.Duration, now time.Time) (*models.ReminderOccurrence, error) {
+	return s.close(ctx, userID, occurrenceID, bson.M{
+		"$set":   bson.M{"status": models.OccurrenceStatusSnoozed, "snooze_until": now.Add(duration), "updated_at": now},
+		"$unset": bson.M{"deferred_until": ""},
+		"$inc":   bson.M{"snooze_count": 1},
+	})
+}
+
+// Skip closes the occurrence without doing the task
+func (s *OccurrenceService) Skip(ctx context.Context, userID string, occurrenceID primitive.ObjectID, now time.Time) (*models.ReminderOccurrence, error) {
+	return s.close(ctx, userID, occurrenceID, bson.M{
+		"$set":   bson.M{"status": models.OccurrenceStatusSkipped, "updated_at": now},
+		"$unset": bson.M{"snooze_until": "", "deferred_until": ""},
+	})
+}
+
+// close applies update to an open occurrence owned by, delegated to or escalated to the user.
+// Delegates can only act while they are still an accepted caretaker of the owner.
+func (s *OccurrenceService) close(ctx context.Context, userID string, occurrenceID primitive.ObjectID, update bson.M) (*models.ReminderOccurrence, error) {
+	owners, err := NewCaretakerService(s.db).OwnersOf(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	recipient := bson.A{bson.M{"user_id": userID}}
+	if len(owners) > 0 {
+		recipient = append(recipient, bson.M{
+			"user_id": bson.M{"$in": owners},
+			"$or":     bson.A{bson.M{"delegate_user_id": userID}, bson.M{"caretaker_ids": userID}},
+		})
+	}
+	filter := bson.M{
+		"_id": occurrenceID,
+		"$or": recipient,
+		"status": bson.M{"$in": []string{
+			models.OccurrenceStatusPending,
+			models.OccurrenceStatusSnoozed,
+			models.OccurrenceStatusDeferred,
+		}},
+	}
+
+	var occurrence models.ReminderOccurrence
+	err = s.collection().FindOneAndUpdate(ctx, filter, update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&occurrence)
+	if err == mongo.ErrNoDocuments {
+		// Tell apart a missing occurrence from one that is no longer open
+		count, countErr := s.collection().CountDocuments(ctx, bson.M{"_id": occurrenceID, "$or": recipient})
+		if countErr == nil && count > 0 {
+			return nil, ErrOccurrenceClosed
+		}
+		return nil, ErrOccurrenceNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error updating reminder occurrence: %v", err)
+	}
+	return &occurrence, nil
+}
+
+// ListForReminder returns the user's occurrences of a reminder, newest first
+func (s *OccurrenceService) ListForReminder(ctx context.Context, userID string, reminderID primitive.ObjectID, limit int64) ([]models.ReminderOccurrence, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "scheduled_for", Value: -1}})
+	if limit > 0 {
+		opts.SetLimit(limit)
+	}
+
+	cursor, err := s.collection().Find(ctx, bson.M{"reminder_id": reminderID, "user_id": userID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error finding reminder occurrences: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	occurrences := []models.ReminderOccurrence{}
+	if err := cursor.All(ctx, &occurrences); err != nil {
+		return nil, fmt.Errorf("error decoding reminder occurrences: %v", err)
+	}
+	return occurrences, nil
+}
+
+// ComputeReminderStats works out on-time rate and current streak from a reminder's occurrences
+func ComputeReminderStats(occurrences []models.ReminderOccurrence, now time.Time) ReminderStats {
+	sorted := append([]models.ReminderOccurrence(nil), occurrences...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].ScheduledFor.After(sorted[j].ScheduledFor)
+	})
+
+	var stats ReminderStats
+	streakOpen := true
+	for _, occurrence := range sorted {
+		settled := true
+		switch occurrence.Status {
+		case models.OccurrenceStatusAcknowledged:
+			stats.Acknowledged++
+			if occurrence.AcknowledgedAt != nil && occurrence.AcknowledgedAt.Sub(occurrence.ScheduledFor) <= AcknowledgementWindow {
+				stats.OnTime++
+			}
+		case models.OccurrenceStatusSkipped:
+			stats.Skipped++
+		default:
+			// Pending: still open inside the window, missed after it. Snoozed and deferred are still to be sent.
+			if now.Sub(occurrence.ScheduledFor) <= AcknowledgementWindow || occurrence.Status != models.OccurrenceStatusPending {
+				stats.Open++
+				settled = false
+			} else {
+				stats.Missed++
+			}
+		}
+		if !settled {
+			continue
+		}
+
+		stats.Total++
+		if streakOpen {
+			if occurrence.Status == models.OccurrenceStatusAcknowledged {
+				stats.CurrentStreak++
+			} else {
+				streakOpen = false
+			}
+		}
+	}
+
+	if stats.Total > 0 {
+		stats.OnTimeRate = float64(stats.OnTime) / float64(stats.Total)
+	}
+	return stats
+}
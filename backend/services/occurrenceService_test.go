@@ -0,0 +1,102 @@
+package services
+
+import (
+	"authentication/models"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestDeleteForRemindersKeepsOtherReminders(t *testing.T) {
+	ctx := context.Background()
+	service := NewOccurrenceService(newTestDatabase(t))
+	now := time.Date(2026, 3, 20, 9, 0, 0, 0, time.UTC)
+
+	deleted := models.Reminder{ID: primitive.NewObjectID(), UserID: "user-1", PlantID: primitive.NewObjectID(), Type: "watering"}
+	kept := models.Reminder{ID: primitive.NewObjectID(), UserID: "user-1", PlantID: deleted.PlantID, Type: "watering"}
+	for _, reminder := range []models.Reminder{deleted, deleted, kept} {
+		now = now.Add(24 * time.Hour)
+		if _, _, err := service.Create(ctx, reminder, now, now, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := service.DeleteForReminders(ctx, []primitive.ObjectID{deleted.ID}); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := service.collection().CountDocuments(ctx, bson.M{"reminder_id": deleted.ID}); err != nil || n != 0 {
+		t.Errorf("%d occurrences of the deleted reminder left, %v", n, err)
+	}
+	if n, err := service.collection().CountDocuments(ctx, bson.M{"reminder_id": kept.ID}); err != nil || n != 1 {
+		t.Errorf("%d occurrences of the other reminder left, want 1, %v", n, err)
+	}
+	if err := service.DeleteForReminders(ctx, nil); err != nil {
+		t.Errorf("deleting for no reminders: %v", err)
+	}
+}
+
+func TestAcknowledgeRecordsTheCareEvent(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDatabase(t)
+	service := NewOccurrenceService(db)
+	events := NewCareEventService(db)
+	now := time.Date(2026, 3, 20, 8, 0, 0, 0, time.UTC)
+
+	watering := models.Reminder{ID: primitive.NewObjectID(), UserID: "user-1", PlantID: primitive.NewObjectID(), Type: "watering"}
+	occurrence, _, err := service.Create(ctx, watering, now, now, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the recipient can acknowledge, and nothing is recorded otherwise
+	if _, err := service.Acknowledge(ctx, "user-2", occurrence.ID, models.CareEvent{}, now); !errors.Is(err, ErrOccurrenceNotFound) {
+		t.Errorf("acknowledging another user's occurrence = %v, want ErrOccurrenceNotFound", err)
+	}
+
+	done := now.Add(10 * time.Minute)
+	if _, err := service.Acknowledge(ctx, "user-1", occurrence.ID, models.CareEvent{AmountML: 250, Notes: "Bottom watered"}, done); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.Acknowledge(ctx, "user-1", occurrence.ID, models.CareEvent{}, done); !errors.Is(err, ErrOccurrenceClosed) {
+		t.Errorf("acknowledging twice = %v, want ErrOccurrenceClosed", err)
+	}
+
+	list, total, err := events.List(ctx, "user-1", CareEventFilter{}, 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1 {
+		t.Fatalf("%d care events, want 1", total)
+	}
+	event := list[0]
+	if event.Type != models.CareEventWatering || event.PlantID != watering.PlantID || !event.Date.Equal(done) {
+		t.Errorf("care event = %+v, want watering of the plant at %v", event, done)
+	}
+	if event.ReminderID == nil || *event.ReminderID != watering.ID || event.OccurrenceID == nil || *event.OccurrenceID != occurrence.ID {
+		t.Errorf("care event points at reminder %v, occurrence %v", event.ReminderID, event.OccurrenceID)
+	}
+	if event.AmountML != 250 || event.Notes != "Bottom watered" {
+		t.Errorf("care event details = %v ml, %q, want what the user noted", event.AmountML, event.Notes)
+	}
+
+	// Reminder types that are not care event types are logged as other care
+	custom := models.Reminder{ID: primitive.NewObjectID(), UserID: "user-1", PlantID: watering.PlantID, Type: "check soil"}
+	occurrence, _, err = service.Create(ctx, custom, now, now, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.Acknowledge(ctx, "user-1", occurrence.ID, models.CareEvent{}, done); err != nil {
+		t.Fatal(err)
+	}
+	list, _, err = events.List(ctx, "user-1", CareEventFilter{Types: []string{models.CareEventOther}}, 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || *list[0].ReminderID != custom.ID {
+		t.Errorf("other care events = %+v, want the custom reminder's", list)
+	}
+}
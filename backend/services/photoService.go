@@ -0,0 +1,277 @@
+package services
+
+import (
+	"authentication/models"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrPhotoNotFound is returned when the photo does not exist or belongs to another plant
+var ErrPhotoNotFound = errors.New("photo not found")
+
+// PhotoService keeps plant galleries. The images themselves are kept by ImageService and tracked as
+// assets: every photo counts as a reference to its image, so images of removed photos are swept.
+type PhotoService struct {
+	db *mongo.Database
+}
+
+func NewPhotoService(db *mongo.Database) *PhotoService {
+	return &PhotoService{db: db}
+}
+
+func (s *PhotoService) collection() *mongo.Collection {
+	return s.db.Collection("plant_photos")
+}
+
+// EnsureIndexes creates the indexes used for the timeline and to clean up after records and events
+func (s *PhotoService) EnsureIndexes(ctx context.Context) error {
+	_, err := s.collection().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "plant_id", Value: 1}, {Key: "taken_at", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "growth_record_id", Value: 1}}, Options: options.Index().SetSparse(true)},
+		{Keys: bson.D{{Key: "care_event_id", Value: 1}}, Options: options.Index().SetSparse(true)},
+	})
+	if err != nil {
+		return fmt.Errorf("error creating plant photo indexes: %v", err)
+	}
+	return nil
+}
+
+// Create adds the photo to the plant's gallery
+func (s *PhotoService) Create(ctx context.Context, photo models.PlantPhoto) (*models.PlantPhoto, error) {
+	if photo.ID.IsZero() {
+		photo.ID = primitive.NewObjectID()
+	}
+	if _, err := s.collection().InsertOne(ctx, photo); err != nil {
+		return nil, fmt.Errorf("error saving photo: %v", err)
+	}
+	if err := adjustAssetRefs(ctx, s.db, []string{photo.URL}, 1, photo.CreatedAt); err != nil {
+		// The sweeper counts the references again before deleting anything
+		log.Printf("[ERROR] Error retaining image of photo %s: %v", photo.ID.Hex(), err)
+	}
+	return &photo, nil
+}
+
+// Timeline returns the plant's photos oldest first, optionally taken within [From, To)
+func (s *PhotoService) Timeline(ctx context.Context, plantID primitive.ObjectID, filter GrowthFilter) ([]models.PlantPhoto, error) {
+	query := bson.M{"plant_id": plantID}
+	takenAt := bson.M{}
+	if !filter.From.IsZero() {
+		takenAt["$gte"] = filter.From
+	}
+	if !filter.To.IsZero() {
+		takenAt["$lt"] = filter.To
+	}
+	if len(takenAt) > 0 {
+		query["taken_at"] = takenAt
+	}
+
+	cursor, err := s.collection().Find(ctx, query,
+		options.Find().SetSort(bson.D{{Key: "taken_at", Value: 1}, {Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("error finding photos: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	photos := []models.PlantPhoto{}
+	if err := cursor.All(ctx, &photos); err != nil {
+		return nil, fmt.Errorf("error decoding photos: %v", err)
+	}
+	return photos, nil
+}
+
+// Get returns one of the plant's photos
+func (s *PhotoService) Get(ctx context.Context, plantID, photoID primitive.ObjectID) (*models.PlantPhoto, error) {
+	var photo models.PlantPhoto
+	err := s.collection().FindOne(ctx, bson.M{"_id": photoID, "plant_id": plantID}).Decode(&photo)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrPhotoNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error finding photo: %v", err)
+	}
+	return &photo, nil
+}
+
+// Update replaces the caption, date and the record or event the photo belongs to
+func (s *PhotoService) Update(ctx context.Context, photo models.PlantPhoto) (*models.PlantPhoto, error) {
+	set := bson.M{"caption": photo.Caption, "taken_at": photo.TakenAt, "updated_at": photo.UpdatedAt}
+	unset := bson.M{}
+	if photo.GrowthRecordID != nil {
+		set["growth_record_id"] = *photo.GrowthRecordID
+	} else {
+		unset["growth_record_id"] = ""
+	}
+	if photo.CareEventID != nil {
+		set["care_event_id"] = *photo.CareEventID
+	} else {
+		unset["care_event_id"] = ""
+	}
+	update := bson.M{"$set": set}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+
+	var updated models.PlantPhoto
+	err := s.collection().FindOneAndUpdate(ctx,
+		bson.M{"_id": photo.ID, "plant_id": photo.PlantID},
+		update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&updated)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrPhotoNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error updating photo: %v", err)
+	}
+	return &updated, nil
+}
+
+// SetCover makes the photo the plant's cover; the plant's image_url and image_urls follow it
+func (s *PhotoService) SetCover(ctx context.Context, photo models.PlantPhoto, now time.Time) error {
+	_, err := s.db.Collection("plants").UpdateOne(ctx,
+		bson.M{"_id": photo.PlantID},
+		bson.M{
+			"$set": bson.M{"cover_photo_id": photo.ID, "image_url": photo.URL, "image_urls": photo.Sizes(), "updated_at": now},
+			"$inc": bson.M{"version": 1},
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("error setting cover photo: %v", err)
+	}
+	return nil
+}
+
+// AdoptImage adds an image uploaded for the plant itself to its gallery, as taken at takenAt, and makes it the cover
+func (s *PhotoService) AdoptImage(ctx context.Context, plant models.Plant, image StoredImage, takenAt, now time.Time) (*models.PlantPhoto, error) {
+	var photo models.PlantPhoto
+	err := s.collection().FindOne(ctx, bson.M{"plant_id": plant.ID, "url": image.URLs.Full}).Decode(&photo)
+	if err == mongo.ErrNoDocuments {
+		created, err := s.Create(ctx, models.PlantPhoto{
+			UserID:    plant.UserID,
+			PlantID:   plant.ID,
+			URL:       image.URLs.Full,
+			ImageURLs: &image.URLs,
+			ImageKey:  image.Key,
+			TakenAt:   takenAt,
+			CreatedAt: now,
+			UpdatedAt: now,
+		})
+		if err != nil {
+			return nil, err
+		}
+		photo = *created
+	} else if err != nil {
+		return nil, fmt.Errorf("error finding photo: %v", err)
+	}
+
+	if err := s.SetCover(ctx, photo, now); err != nil {
+		return nil, err
+	}
+	return &photo, nil
+}
+
+// Delete removes one of the plant's photos
+func (s *PhotoService) Delete(ctx context.Context, plantID, photoID primitive.ObjectID, now time.Time) (*models.PlantPhoto, error) {
+	photos, err := s.deleteWhere(ctx, bson.M{"_id": photoID, "plant_id": plantID}, now)
+	if err != nil {
+		return nil, err
+	}
+	if len(photos) == 0 {
+		return nil, ErrPhotoNotFound
+	}
+	return &photos[0], nil
+}
+
+// DeleteForPlant removes the whole gallery of a deleted plant
+func (s *PhotoService) DeleteForPlant(ctx context.Context, plantID primitive.ObjectID, now time.Time) ([]models.PlantPhoto, error) {
+	return s.deleteWhere(ctx, bson.M{"plant_id": plantID}, now)
+}
+
+// DeleteForGrowthRecord removes the photos of a deleted growth record
+func (s *PhotoService) DeleteForGrowthRecord(ctx context.Context, recordID primitive.ObjectID, now time.Time) ([]models.PlantPhoto, error) {
+	return s.deleteWhere(ctx, bson.M{"growth_record_id": recordID}, now)
+}
+
+// DeleteForCareEvent removes the photos of a deleted care event
+func (s *PhotoService) DeleteForCareEvent(ctx context.Context, eventID primitive.ObjectID, now time.Time) ([]models.PlantPhoto, error) {
+	return s.deleteWhere(ctx, bson.M{"care_event_id": eventID}, now)
+}
+
+// deleteWhere removes the matching photos and clears the cover of plants that used one of them
+func (s *PhotoService) deleteWhere(ctx context.Context, query bson.M, now time.Time) ([]models.PlantPhoto, error) {
+	cursor, err := s.collection().Find(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("error finding photos: %v", err)
+	}
+	photos := []models.PlantPhoto{}
+	if err := cursor.All(ctx, &photos); err != nil {
+		return nil, fmt.Errorf("error decoding photos: %v", err)
+	}
+	if len(photos) == 0 {
+		return photos, nil
+	}
+
+	ids := make([]primitive.ObjectID, len(photos))
+	urls := make([]string, len(photos))
+	for i, photo := range photos {
+		ids[i] = photo.ID
+		urls[i] = photo.URL
+	}
+	if _, err := s.collection().DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids}}); err != nil {
+		return nil, fmt.Errorf("error deleting photos: %v", err)
+	}
+	if err := adjustAssetRefs(ctx, s.db, urls, -1, now); err != nil {
+		log.Printf("[ERROR] Error releasing images of deleted photos: %v", err)
+	}
+
+	_, err = s.db.Collection("plants").UpdateMany(ctx,
+		bson.M{"cover_photo_id": bson.M{"$in": ids}},
+		bson.M{
+			"$set":   bson.M{"image_url": "", "updated_at": now},
+			"$unset": bson.M{"cover_photo_id": "", "image_urls": ""},
+			"$inc":   bson.M{"version": 1},
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error clearing cover photo: %v", err)
+	}
+	return photos, nil
+}
+
+// MigratePlantImages adds the image of plants created before galleries to their gallery as the cover.
+// find looks up the stored image at a URL.
+func (s *PhotoService) MigratePlantImages(ctx context.Context, find func(url string) StoredImage) (int, error) {
+	cursor, err := s.db.Collection("plants").Find(ctx, bson.M{
+		"image_url":      bson.M{"$nin": bson.A{"", nil}},
+		"cover_photo_id": bson.M{"$exists": false},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("error finding plants with an image: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	migrated := 0
+	for cursor.Next(ctx) {
+		var plant models.Plant
+		if err := cursor.Decode(&plant); err != nil {
+			return migrated, fmt.Errorf("error decoding plant: %v", err)
+		}
+		takenAt := plant.CreatedAt
+		if takenAt.IsZero() {
+			takenAt = plant.ID.Timestamp()
+		}
+		if _, err := s.AdoptImage(ctx, plant, find(plant.ImageURL), takenAt, time.Now()); err != nil {
+			return migrated, err
+		}
+		migrated++
+	}
+	return migrated, cursor.Err()
+}
@@ -0,0 +1,251 @@
+package services
+
+import (
+	"authentication/models"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Reasons a preset was suggested the way it was
+const (
+	PresetReasonCatalogWater = "catalog_water" // Watering from the catalog's น้ำ requirement
+	PresetReasonCareLevel    = "care_level"    // Fertilizing from the catalog's care level
+	PresetReasonContainer    = "container"     // Adjusted for the container
+	PresetReasonHumidity     = "humidity"      // Misting for plants that like humid, shaded spots
+	PresetReasonUsualTime    = "usual_time"    // The time the user usually sets for this type
+	PresetReasonDefault      = "default"       // Nothing better was known
+)
+
+// presetDefaultTimes are used until the user has reminders of their own
+var presetDefaultTimes = map[string]string{
+	"watering":    "07:00",
+	"fertilizing": "08:00",
+	"misting":     "09:00",
+}
+
+// containerWateringAdjustment lengthens or shortens the watering interval per container.
+// Hanging pots dry out fastest; beds, bottles and glass vases without drainage hold water longest.
+var containerWateringAdjustment = map[string]int{
+	"กระถางแขวน":        -1,
+	"แปลงดิน":           1,
+	"ขวดพลาสติกดัดแปลง": 1,
+	"แจกันแก้ว":         2,
+}
+
+// ReminderPreset is a reminder suggested for a plant
+type ReminderPreset struct {
+	Type         string   `json:"type"`
+	Frequency    string   `json:"frequency"`
+	IntervalDays int      `json:"intervalDays,omitempty"`
+	DayOfWeek    string   `json:"dayOfWeek,omitempty"`
+	TimeOfDay    string   `json:"timeOfDay"`
+	Reasons      []string `json:"reasons"`
+	Exists       bool     `json:"exists"` // The plant already has a reminder of this type
+}
+
+// Reminder turns the preset into a reminder for the plant, not yet saved
+func (p ReminderPreset) Reminder(plant models.Plant) models.Reminder {
+	return models.Reminder{
+		UserID:       plant.UserID,
+		PlantID:      plant.ID,
+		Type:         p.Type,
+		Frequency:    p.Frequency,
+		IntervalDays: p.IntervalDays,
+		DayOfWeek:    p.DayOfWeek,
+		TimeOfDay:    p.TimeOfDay,
+	}
+}
+
+// PlantPresets are the reminders suggested for one plant
+type PlantPresets struct {
+	PlantID   string           `json:"plantId"`
+	CatalogID int              `json:"catalogId,omitempty"`
+	Water     string           `json:"water,omitempty"`
+	CareLevel string           `json:"careLevel,omitempty"`
+	Presets   []ReminderPreset `json:"presets"`
+}
+
+// PresetService suggests reminders for a plant from its catalog entry, its container
+// and the times the user usually picks
+type PresetService struct {
+	db *mongo.Database
+}
+
+func NewPresetService(db *mongo.Database) *PresetService {
+	return &PresetService{db: db}
+}
+
+// Suggest returns the watering, fertilizing and (for humidity-loving plants) misting presets
+func (s *PresetService) Suggest(ctx context.Context, plant models.Plant) (*PlantPresets, error) {
+	entry, err := FindCatalogEntry(ctx, s.db, plant)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := s.db.Collection("reminders").Find(ctx, bson.M{"user_id": plant.UserID})
+	if err != nil {
+		return nil, fmt.Errorf("error fetching reminders: %v", err)
+	}
+	var reminders []models.Reminder
+	if err := cursor.All(ctx, &reminders); err != nil {
+		return nil, fmt.Errorf("error decoding reminders: %v", err)
+	}
+
+	result := &PlantPresets{PlantID: plant.ID.Hex(), Presets: BuildReminderPresets(entry, plant.Container, reminders)}
+	if entry != nil {
+		result.CatalogID = entry.ID
+		result.Water = entry.Conditions.Water
+		result.CareLevel = entry.CareLevel
+	}
+	for i := range result.Presets {
+		for _, reminder := range reminders {
+			if reminder.PlantID == plant.ID && reminder.Type == result.Presets[i].Type {
+				result.Presets[i].Exists = true
+			}
+		}
+	}
+	return result, nil
+}
+
+// BuildReminderPresets works out the presets from a catalog entry (nil when the plant has none),
+// the container and the user's existing reminders
+func BuildReminderPresets(entry *models.PlantRecommendation, container string, reminders []models.Reminder) []ReminderPreset {
+	var presets []ReminderPreset
+
+	// Watering
+	watering := ReminderPreset{Type: "watering"}
+	interval, ok := 0, false
+	if entry != nil {
+		interval, ok = ParseCatalogWatering(entry.Conditions.Water)
+	}
+	if ok {
+		watering.Reasons = append(watering.Reasons, PresetReasonCatalogWater)
+	} else {
+		interval = defaultWateringInterval
+		watering.Reasons = append(watering.Reasons, PresetReasonDefault)
+	}
+	if adjustment, ok := containerWateringAdjustment[container]; ok {
+		interval = clampWateringInterval(interval + adjustment)
+		watering.Reasons = append(watering.Reasons, PresetReasonContainer)
+	}
+	setPresetInterval(&watering, interval)
+	presets = append(presets, watering)
+
+	// Fertilizing: plants that need more care feed more often
+	fertilizing := ReminderPreset{Type: "fertilizing"}
+	fertilizingInterval := 30
+	if entry != nil && entry.CareLevel != "" {
+		if entry.CareLevel != "ง่าย" {
+			fertilizingInterval = 14
+		}
+		fertilizing.Reasons = append(fertilizing.Reasons, PresetReasonCareLevel)
+	} else {
+		fertilizing.Reasons = append(fertilizing.Reasons, PresetReasonDefault)
+	}
+	setPresetInterval(&fertilizing, fertilizingInterval)
+	presets = append(presets, fertilizing)
+
+	// Misting only for plants that like shade or bathrooms, never for low-water plants
+	if entry != nil && likesHumidity(entry.Conditions) {
+		misting := ReminderPreset{Type: "misting", Reasons: []string{PresetReasonHumidity}}
+		mistingInterval := 2
+		if interval == 1 {
+			mistingInterval = 1
+		}
+		setPresetInterval(&misting, mistingInterval)
+		presets = append(presets, misting)
+	}
+
+	for i := range presets {
+		if timeOfDay, ok := usualTimeOfDay(reminders, presets[i].Type); ok {
+			presets[i].TimeOfDay = timeOfDay
+			presets[i].Reasons = append(presets[i].Reasons, PresetReasonUsualTime)
+		} else {
+			presets[i].TimeOfDay = presetDefaultTimes[presets[i].Type]
+		}
+		if presets[i].Frequency == "weekly" {
+			presets[i].DayOfWeek = usualDayOfWeek(reminders)
+		}
+	}
+	return presets
+}
+
+// setPresetInterval uses the plain frequencies where they fit
+func setPresetInterval(preset *ReminderPreset, days int) {
+	switch days {
+	case 1:
+		preset.Frequency = "daily"
+	case 7:
+		preset.Frequency = "weekly"
+	default:
+		preset.Frequency = "interval"
+		preset.IntervalDays = days
+	}
+}
+
+func likesHumidity(conditions models.PlantConditions) bool {
+	if strings.HasPrefix(conditions.Water, "ต่ำ") {
+		return false
+	}
+	for _, area := range conditions.Area {
+		if area == "ห้องน้ำ" {
+			return true
+		}
+	}
+	for _, light := range conditions.Light {
+		if light == "น้อย" {
+			return true
+		}
+	}
+	return false
+}
+
+// usualTimeOfDay returns the time the user most often picks for the type, or for any type
+func usualTimeOfDay(reminders []models.Reminder, reminderType string) (string, bool) {
+	if timeOfDay, ok := mostCommon(reminders, func(r models.Reminder) string {
+		if r.Type != reminderType {
+			return ""
+		}
+		return r.TimeOfDay
+	}); ok {
+		return timeOfDay, true
+	}
+	return mostCommon(reminders, func(r models.Reminder) string { return r.TimeOfDay })
+}
+
+// usualDayOfWeek returns the weekday the user most often picks for weekly reminders
+func usualDayOfWeek(reminders []models.Reminder) string {
+	if day, ok := mostCommon(reminders, func(r models.Reminder) string { return r.DayOfWeek }); ok {
+		return day
+	}
+	return "Sunday"
+}
+
+// mostCommon returns the most frequent non-empty key, the smallest one on a tie
+func mostCommon(reminders []models.Reminder, key func(models.Reminder) string) (string, bool) {
+	counts := make(map[string]int)
+	for _, reminder := range reminders {
+		if k := key(reminder); k != "" {
+			counts[k]++
+		}
+	}
+	if len(counts) == 0 {
+		return "", false
+	}
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	return keys[0], true
+}
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"authentication/models"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// describePreset renders a preset as e.g. "watering every 3 days at 07:00 [default]"
+func describePreset(p ReminderPreset) string {
+	schedule := p.Frequency
+	if p.IntervalDays > 0 {
+		schedule = fmt.Sprintf("every %d days", p.IntervalDays)
+	}
+	if p.DayOfWeek != "" {
+		schedule += " on " + p.DayOfWeek
+	}
+	return fmt.Sprintf("%s %s at %s %v", p.Type, schedule, p.TimeOfDay, p.Reasons)
+}
+
+func TestBuildReminderPresets(t *testing.T) {
+	catalog := func(water, careLevel string, area, light []string) *models.PlantRecommendation {
+		return &models.PlantRecommendation{
+			CareLevel:  careLevel,
+			Conditions: models.PlantConditions{Water: water, Area: area, Light: light},
+		}
+	}
+	at := func(reminderType, timeOfDay string) models.Reminder {
+		return models.Reminder{Type: reminderType, TimeOfDay: timeOfDay}
+	}
+
+	tests := []struct {
+		name      string
+		entry     *models.PlantRecommendation
+		container string
+		reminders []models.Reminder
+		want      []string
+	}{
+		{
+			"no catalog entry", nil, "", nil,
+			[]string{
+				"watering every 3 days at 07:00 [default]",
+				"fertilizing every 30 days at 08:00 [default]",
+			},
+		},
+		{
+			"catalog watering and an easy plant", catalog("สูง (รดน้ำทุกวัน)", "ง่าย", nil, nil), "", nil,
+			[]string{
+				"watering daily at 07:00 [catalog_water]",
+				"fertilizing every 30 days at 08:00 [care_level]",
+			},
+		},
+		{
+			"unreadable catalog watering and a demanding plant", catalog("when dry", "ยาก", nil, nil), "", nil,
+			[]string{
+				"watering every 3 days at 07:00 [default]",
+				"fertilizing every 14 days at 08:00 [care_level]",
+			},
+		},
+		{
+			"hanging pot dries out sooner", catalog("ปานกลาง (รดน้ำ 2-3 ครั้ง/สัปดาห์)", "", nil, nil), "กระถางแขวน", nil,
+			[]string{
+				"watering every 2 days at 07:00 [catalog_water container]",
+				"fertilizing every 30 days at 08:00 [default]",
+			},
+		},
+		{
+			"container adjustment keeps at least a day", catalog("สูง", "", nil, nil), "กระถางแขวน", nil,
+			[]string{
+				"watering daily at 07:00 [catalog_water container]",
+				"fertilizing every 30 days at 08:00 [default]",
+			},
+		},
+		{
+			"glass vase makes it weekly", catalog("ต่ำ (รดน้ำ 1-2 ครั้ง/สัปดาห์)", "", nil, nil), "แจกันแก้ว", nil,
+			[]string{
+				"watering weekly on Sunday at 07:00 [catalog_water container]",
+				"fertilizing every 30 days at 08:00 [default]",
+			},
+		},
+		{
+			"unknown container is ignored", catalog("ปานกลาง", "", nil, nil), "กระถางดินเผา", nil,
+			[]string{
+				"watering every 3 days at 07:00 [catalog_water]",
+				"fertilizing every 30 days at 08:00 [default]",
+			},
+		},
+		{
+			"low light gets misting", catalog("ปานกลาง", "", nil, []string{"น้อย"}), "", nil,
+			[]string{
+				"watering every 3 days at 07:00 [catalog_water]",
+				"fertilizing every 30 days at 08:00 [default]",
+				"misting every 2 days at 09:00 [humidity]",
+			},
+		},
+		{
+			"bathroom plant watered daily is misted daily", catalog("สูง", "", []string{"ห้องน้ำ"}, nil), "", nil,
+			[]string{
+				"watering daily at 07:00 [catalog_water]",
+				"fertilizing every 30 days at 08:00 [default]",
+				"misting daily at 09:00 [humidity]",
+			},
+		},
+		{
+			"low-water plants are never misted", catalog("ต่ำ", "", []string{"ห้องน้ำ"}, []string{"น้อย"}), "", nil,
+			[]string{
+				"watering every 5 days at 07:00 [catalog_water]",
+				"fertilizing every 30 days at 08:00 [default]",
+			},
+		},
+		{
+			"usual time per type", nil, "",
+			[]models.Reminder{at("watering", "06:30"), at("fertilizing", "20:00"), at("fertilizing", "20:00"), at("watering", "06:30"), at("watering", "21:00")},
+			[]string{
+				"watering every 3 days at 06:30 [default usual_time]",
+				"fertilizing every 30 days at 20:00 [default usual_time]",
+			},
+		},
+		{
+			"usual time of any type, earliest on a tie", catalog("ปานกลาง", "", nil, []string{"น้อย"}), "",
+			[]models.Reminder{at("watering", "18:00"), at("watering", "06:00")},
+			[]string{
+				"watering every 3 days at 06:00 [catalog_water usual_time]",
+				"fertilizing every 30 days at 06:00 [default usual_time]",
+				"misting every 2 days at 06:00 [humidity usual_time]",
+			},
+		},
+		{
+			"usual weekday for weekly presets", catalog("รดน้ำ 1 ครั้ง/สัปดาห์", "", nil, nil), "",
+			[]models.Reminder{{Type: "fertilizing", Frequency: "weekly", DayOfWeek: "Wednesday"}},
+			[]string{
+				"watering weekly on Wednesday at 07:00 [catalog_water]",
+				"fertilizing every 30 days at 08:00 [default]",
+			},
+		},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, preset := range BuildReminderPresets(tt.entry, tt.container, tt.reminders) {
+			got = append(got, describePreset(preset))
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s:\n got %q\nwant %q", tt.name, got, tt.want)
+		}
+	}
+}
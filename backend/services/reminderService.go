@@ -4,20 +4,16 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 
-	"authentication/config"
 	"authentication/models"
 )
 
-// GetDB returns the database instance from config
-func GetDB() *mongo.Database {
-	return config.DB
-}
-
 // NewReminderService creates a new reminder service
 func NewReminderService(database *mongo.Database) *ReminderService {
 	return &ReminderService{
@@ -45,7 +41,7 @@ func (s *ReminderService) GetRemindersByPlantID(plantID string) ([]models.Remind
 	// Find all reminders for the plant
 	cursor, err := s.db.Collection("reminders").Find(context.Background(), bson.M{
 		"plant_id": objID,
-	})
+	}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
 	if err != nil {
 		log.Printf("[ERROR] Service: Error finding reminders: %v", err)
 		return nil, fmt.Errorf("error finding reminders: %v", err)
@@ -62,3 +58,66 @@ func (s *ReminderService) GetRemindersByPlantID(plantID string) ([]models.Remind
 	log.Printf("[DEBUG] Service: Successfully retrieved %d reminders", len(reminders))
 	return reminders, nil
 }
+
+// ClearLegacyNotificationData removes the client-supplied notification JSON from existing reminders.
+// Notifications are rendered from templates now, so a malformed value can no longer drop a reminder.
+func (s *ReminderService) ClearLegacyNotificationData(ctx context.Context) (int64, error) {
+	result, err := s.db.Collection("reminders").UpdateMany(ctx,
+		bson.M{"notification_data": bson.M{"$exists": true}},
+		bson.M{"$unset": bson.M{"notification_data": ""}, "$inc": bson.M{"version": 1}},
+	)
+	if err != nil {
+		return 0, fmt.Errorf("error clearing legacy notification data: %v", err)
+	}
+	return result.ModifiedCount, nil
+}
+
+// ShiftReminderSchedule moves a reminder's schedule by offset, e.g. one hour later.
+// A time of day that crosses midnight moves a weekly reminder to the next or previous
+// weekday and an interval reminder's anchor by a day, so every firing moves by exactly offset.
+func ShiftReminderSchedule(reminder models.Reminder, offset time.Duration) (models.Reminder, error) {
+	shifted := reminder
+	switch reminder.Frequency {
+	case "once":
+		shifted.ScheduledTime = reminder.ScheduledTime.Add(offset)
+		return shifted, nil
+	case "daily", "weekly", "interval":
+	default:
+		return reminder, fmt.Errorf("unknown frequency %q", reminder.Frequency)
+	}
+
+	clock, err := time.Parse("15:04", reminder.TimeOfDay)
+	if err != nil {
+		return reminder, fmt.Errorf("invalid time of day %q", reminder.TimeOfDay)
+	}
+	minutes := clock.Hour()*60 + clock.Minute() + int(offset/time.Minute)
+	days := minutes / minutesPerDay
+	if minutes < 0 && minutes%minutesPerDay != 0 {
+		days--
+	}
+	minutes -= days * minutesPerDay
+	shifted.TimeOfDay = fmt.Sprintf("%02d:%02d", minutes/60, minutes%60)
+
+	switch reminder.Frequency {
+	case "weekly":
+		weekday, ok := parseWeekday(reminder.DayOfWeek)
+		if !ok {
+			return reminder, fmt.Errorf("invalid day of week %q", reminder.DayOfWeek)
+		}
+		shifted.DayOfWeek = time.Weekday(((int(weekday)+days)%7 + 7) % 7).String()
+	case "interval":
+		shifted.ScheduledTime = reminder.ScheduledTime.AddDate(0, 0, days)
+	}
+	return shifted, nil
+}
+
+const minutesPerDay = 24 * 60
+
+func parseWeekday(name string) (time.Weekday, bool) {
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if day.String() == name {
+			return day, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,145 @@
+package services
+
+import (
+	"authentication/models"
+	"testing"
+	"time"
+)
+
+func TestShiftReminderSchedule(t *testing.T) {
+	loc := bangkok(t)
+	anchor := time.Date(2026, 3, 4, 8, 0, 0, 0, loc)
+	day := 24 * time.Hour
+	week := 7 * day
+
+	tests := []struct {
+		name     string
+		reminder models.Reminder
+		offset   time.Duration
+		want     models.Reminder
+	}{
+		{
+			name:     "once moves its time",
+			reminder: models.Reminder{Frequency: "once", ScheduledTime: anchor},
+			offset:   -90 * time.Minute,
+			want:     models.Reminder{Frequency: "once", ScheduledTime: anchor.Add(-90 * time.Minute)},
+		},
+		{
+			name:     "daily within the day",
+			reminder: models.Reminder{Frequency: "daily", TimeOfDay: "08:00"},
+			offset:   time.Hour,
+			want:     models.Reminder{Frequency: "daily", TimeOfDay: "09:00"},
+		},
+		{
+			name:     "daily forward over midnight",
+			reminder: models.Reminder{Frequency: "daily", TimeOfDay: "23:30"},
+			offset:   time.Hour,
+			want:     models.Reminder{Frequency: "daily", TimeOfDay: "00:30"},
+		},
+		{
+			name:     "daily back over midnight",
+			reminder: models.Reminder{Frequency: "daily", TimeOfDay: "00:30"},
+			offset:   -time.Hour,
+			want:     models.Reminder{Frequency: "daily", TimeOfDay: "23:30"},
+		},
+		{
+			name:     "weekly forward over midnight moves to the next day",
+			reminder: models.Reminder{Frequency: "weekly", DayOfWeek: "Monday", TimeOfDay: "23:30"},
+			offset:   time.Hour,
+			want:     models.Reminder{Frequency: "weekly", DayOfWeek: "Tuesday", TimeOfDay: "00:30"},
+		},
+		{
+			name:     "weekly back over midnight wraps from Sunday to Saturday",
+			reminder: models.Reminder{Frequency: "weekly", DayOfWeek: "Sunday", TimeOfDay: "00:30"},
+			offset:   -time.Hour,
+			want:     models.Reminder{Frequency: "weekly", DayOfWeek: "Saturday", TimeOfDay: "23:30"},
+		},
+		{
+			name:     "weekly forward wraps from Saturday to Sunday",
+			reminder: models.Reminder{Frequency: "weekly", DayOfWeek: "Saturday", TimeOfDay: "23:00"},
+			offset:   2 * time.Hour,
+			want:     models.Reminder{Frequency: "weekly", DayOfWeek: "Sunday", TimeOfDay: "01:00"},
+		},
+		{
+			name:     "weekly back exactly a day",
+			reminder: models.Reminder{Frequency: "weekly", DayOfWeek: "Monday", TimeOfDay: "00:00"},
+			offset:   -day,
+			want:     models.Reminder{Frequency: "weekly", DayOfWeek: "Sunday", TimeOfDay: "00:00"},
+		},
+		{
+			name:     "weekly forward a week keeps the day",
+			reminder: models.Reminder{Frequency: "weekly", DayOfWeek: "Monday", TimeOfDay: "08:00"},
+			offset:   week,
+			want:     models.Reminder{Frequency: "weekly", DayOfWeek: "Monday", TimeOfDay: "08:00"},
+		},
+		{
+			name:     "weekly back a week keeps the day",
+			reminder: models.Reminder{Frequency: "weekly", DayOfWeek: "Monday", TimeOfDay: "08:00"},
+			offset:   -week,
+			want:     models.Reminder{Frequency: "weekly", DayOfWeek: "Monday", TimeOfDay: "08:00"},
+		},
+		{
+			name:     "weekly back a week and a minute",
+			reminder: models.Reminder{Frequency: "weekly", DayOfWeek: "Monday", TimeOfDay: "00:00"},
+			offset:   -week - time.Minute,
+			want:     models.Reminder{Frequency: "weekly", DayOfWeek: "Sunday", TimeOfDay: "23:59"},
+		},
+		{
+			name:     "weekly forward most of a week",
+			reminder: models.Reminder{Frequency: "weekly", DayOfWeek: "Wednesday", TimeOfDay: "23:59"},
+			offset:   week - time.Minute,
+			want:     models.Reminder{Frequency: "weekly", DayOfWeek: "Wednesday", TimeOfDay: "23:58"},
+		},
+		{
+			name:     "interval forward over midnight moves the anchor a day",
+			reminder: models.Reminder{Frequency: "interval", IntervalDays: 3, TimeOfDay: "22:00", ScheduledTime: anchor},
+			offset:   3 * time.Hour,
+			want:     models.Reminder{Frequency: "interval", IntervalDays: 3, TimeOfDay: "01:00", ScheduledTime: anchor.AddDate(0, 0, 1)},
+		},
+		{
+			name:     "interval back over midnight moves the anchor back a day",
+			reminder: models.Reminder{Frequency: "interval", IntervalDays: 3, TimeOfDay: "01:00", ScheduledTime: anchor},
+			offset:   -2 * time.Hour,
+			want:     models.Reminder{Frequency: "interval", IntervalDays: 3, TimeOfDay: "23:00", ScheduledTime: anchor.AddDate(0, 0, -1)},
+		},
+		{
+			name:     "interval within the day keeps the anchor",
+			reminder: models.Reminder{Frequency: "interval", IntervalDays: 3, TimeOfDay: "08:00", ScheduledTime: anchor},
+			offset:   -30 * time.Minute,
+			want:     models.Reminder{Frequency: "interval", IntervalDays: 3, TimeOfDay: "07:30", ScheduledTime: anchor},
+		},
+		{
+			name:     "interval back a week",
+			reminder: models.Reminder{Frequency: "interval", IntervalDays: 3, TimeOfDay: "08:00", ScheduledTime: anchor},
+			offset:   -week,
+			want:     models.Reminder{Frequency: "interval", IntervalDays: 3, TimeOfDay: "08:00", ScheduledTime: anchor.AddDate(0, 0, -7)},
+		},
+	}
+	for _, tt := range tests {
+		got, err := ShiftReminderSchedule(tt.reminder, tt.offset)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got.TimeOfDay != tt.want.TimeOfDay || got.DayOfWeek != tt.want.DayOfWeek || !got.ScheduledTime.Equal(tt.want.ScheduledTime) {
+			t.Errorf("%s: got %s %q %v, want %s %q %v", tt.name,
+				got.DayOfWeek, got.TimeOfDay, got.ScheduledTime, tt.want.DayOfWeek, tt.want.TimeOfDay, tt.want.ScheduledTime)
+		}
+	}
+}
+
+func TestShiftReminderScheduleRejectsInvalidSchedules(t *testing.T) {
+	for name, reminder := range map[string]models.Reminder{
+		"unknown frequency": {Frequency: "monthly", TimeOfDay: "08:00"},
+		"bad time of day":   {Frequency: "daily", TimeOfDay: "8am"},
+		"unknown weekday":   {Frequency: "weekly", DayOfWeek: "Funday", TimeOfDay: "08:00"},
+	} {
+		got, err := ShiftReminderSchedule(reminder, time.Hour)
+		if err == nil {
+			t.Errorf("%s: no error", name)
+		}
+		if got.TimeOfDay != reminder.TimeOfDay || got.DayOfWeek != reminder.DayOfWeek {
+			t.Errorf("%s: schedule changed to %s %q", name, got.DayOfWeek, got.TimeOfDay)
+		}
+	}
+}
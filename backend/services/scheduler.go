@@ -1,31 +1,41 @@
 package services
 
 import (
+	"context"
 	"log"
 	"time"
 )
 
+// schedulerInterval is how often reminders are checked; reminders are due to the minute
+const schedulerInterval = 5 * time.Second
+
 type Scheduler struct {
 	notificationService *NotificationService
+	growthAlerts        *GrowthAlertService // Nil to skip growth scans
+	assets              *AssetService       // Nil to skip image sweeps
+	clock               Clock
+	lastGrowthScan      time.Time
+	lastAssetSweep      time.Time
 	stopChan            chan struct{}
 }
 
-func NewScheduler(notificationService *NotificationService) *Scheduler {
+func NewScheduler(notificationService *NotificationService, growthAlerts *GrowthAlertService, assets *AssetService, clock Clock) *Scheduler {
 	return &Scheduler{
 		notificationService: notificationService,
+		growthAlerts:        growthAlerts,
+		assets:              assets,
+		clock:               clock,
 		stopChan:            make(chan struct{}),
 	}
 }
 
 func (s *Scheduler) Start() {
-	ticker := time.NewTicker(5 * time.Second)
+	ticker := s.clock.NewTicker(schedulerInterval)
 	go func() {
 		for {
 			select {
-			case <-ticker.C:
-				if err := s.notificationService.CheckAndSendReminders(); err != nil {
-					log.Printf("Error checking reminders: %v", err)
-				}
+			case <-ticker.C():
+				s.Tick()
 			case <-s.stopChan:
 				ticker.Stop()
 				return
@@ -34,6 +44,41 @@ func (s *Scheduler) Start() {
 	}()
 }
 
+// Tick runs one round of the scheduler: due reminders, follow-ups, escalations, delivery retries
+// and, every growthScanInterval, a scan of all plants' growth and, every assetSweepInterval,
+// a sweep of unused images
+func (s *Scheduler) Tick() {
+	if err := s.notificationService.CheckAndSendReminders(); err != nil {
+		log.Printf("Error checking reminders: %v", err)
+	}
+	if err := s.notificationService.ProcessOccurrenceFollowUps(); err != nil {
+		log.Printf("Error processing reminder follow-ups: %v", err)
+	}
+	if err := s.notificationService.ProcessEscalations(); err != nil {
+		log.Printf("Error processing reminder escalations: %v", err)
+	}
+	if err := s.notificationService.RetryDueDeliveries(); err != nil {
+		log.Printf("Error retrying notification deliveries: %v", err)
+	}
+
+	if now := s.clock.Now(); s.growthAlerts != nil && now.Sub(s.lastGrowthScan) >= growthScanInterval {
+		s.lastGrowthScan = now
+		if err := s.growthAlerts.ScanAll(context.Background()); err != nil {
+			log.Printf("Error scanning plant growth: %v", err)
+		}
+	}
+
+	if now := s.clock.Now(); s.assets != nil && now.Sub(s.lastAssetSweep) >= assetSweepInterval {
+		s.lastAssetSweep = now
+		report, err := s.assets.Sweep(context.Background(), now, false)
+		if err != nil {
+			log.Printf("Error sweeping unused images: %v", err)
+		} else if report.Deleted > 0 || report.Failed > 0 {
+			log.Printf("Swept %d unused image(s), %d left for the next sweep", report.Deleted, report.Failed)
+		}
+	}
+}
+
 func (s *Scheduler) Stop() {
 	close(s.stopChan)
 }
@@ -0,0 +1,460 @@
+package services
+
+import (
+	"authentication/models"
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func bangkok(t *testing.T) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation("Asia/Bangkok")
+	if err != nil {
+		t.Fatalf("loading Asia/Bangkok: %v", err)
+	}
+	return loc
+}
+
+func TestReminderDue(t *testing.T) {
+	loc := bangkok(t)
+	at := func(year int, month time.Month, day, hour, min, sec int) time.Time {
+		return time.Date(year, month, day, hour, min, sec, 0, loc)
+	}
+
+	daily := models.Reminder{Frequency: "daily", TimeOfDay: "08:00"}
+	midnight := models.Reminder{Frequency: "daily", TimeOfDay: "00:00"}
+	monday := models.Reminder{Frequency: "weekly", DayOfWeek: "Monday", TimeOfDay: "00:00"}
+	// 2026-03-03 23:59 in Bangkok, stored in UTC as the API receives it
+	once := models.Reminder{Frequency: "once", ScheduledTime: at(2026, 3, 3, 23, 59, 0).UTC()}
+	everyThirdDay := models.Reminder{Frequency: "interval", IntervalDays: 3, TimeOfDay: "08:00", ScheduledTime: at(2026, 2, 27, 21, 0, 0)}
+
+	tests := []struct {
+		name     string
+		reminder models.Reminder
+		now      time.Time
+		want     bool
+	}{
+		{"daily at its minute", daily, at(2026, 3, 2, 8, 0, 0), true},
+		{"daily ignores seconds", daily, at(2026, 3, 2, 8, 0, 59), true},
+		{"daily a minute early", daily, at(2026, 3, 2, 7, 59, 59), false},
+		{"daily a minute late", daily, at(2026, 3, 2, 8, 1, 0), false},
+		{"daily at midnight", midnight, at(2026, 3, 2, 0, 0, 0), true},
+		{"daily just before midnight", midnight, at(2026, 3, 1, 23, 59, 59), false},
+		{"daily from a UTC instant", daily, time.Date(2026, 3, 2, 1, 0, 0, 0, time.UTC).In(loc), true},
+		{"weekly on its day", monday, at(2026, 3, 2, 0, 0, 0), true},
+		{"weekly on Sunday night", monday, at(2026, 3, 1, 23, 59, 0), false},
+		{"weekly on Sunday in UTC is Monday in Bangkok", monday, time.Date(2026, 3, 1, 17, 0, 0, 0, time.UTC).In(loc), true},
+		{"weekly on another day", monday, at(2026, 3, 3, 0, 0, 0), false},
+		{"once at its minute", once, at(2026, 3, 3, 23, 59, 30), true},
+		{"once compared across timezones", once, time.Date(2026, 3, 3, 16, 59, 0, 0, time.UTC), true},
+		{"once a day later", once, at(2026, 3, 4, 23, 59, 0), false},
+		{"once the next minute, past midnight", once, at(2026, 3, 4, 0, 0, 0), false},
+		{"interval on its anchor day", everyThirdDay, at(2026, 2, 27, 8, 0, 0), true},
+		{"interval between runs", everyThirdDay, at(2026, 2, 28, 8, 0, 0), false},
+		{"interval across the month end", everyThirdDay, at(2026, 3, 2, 8, 0, 0), true},
+		{"interval before its anchor", everyThirdDay, at(2026, 2, 24, 8, 0, 0), false},
+		{"unknown frequency", models.Reminder{Frequency: "hourly", TimeOfDay: "08:00"}, at(2026, 3, 2, 8, 0, 0), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ReminderDue(tt.reminder, tt.now); got != tt.want {
+				t.Errorf("ReminderDue(%s) at %v = %v, want %v", tt.reminder.Frequency, tt.now, got, tt.want)
+			}
+		})
+	}
+}
+
+// newTestDatabase returns an empty database on the server at MONGODB_TEST_URI, dropped
+// when the test ends. Tests that need it are skipped when the variable is not set.
+func newTestDatabase(t *testing.T) *mongo.Database {
+	t.Helper()
+	uri := os.Getenv("MONGODB_TEST_URI")
+	if uri == "" {
+		t.Skip("MONGODB_TEST_URI is not set")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatalf("connecting to MongoDB: %v", err)
+	}
+	if err := client.Ping(ctx, nil); err != nil {
+		t.Fatalf("pinging MongoDB: %v", err)
+	}
+
+	db := client.Database(fmt.Sprintf("plante_test_%s", primitive.NewObjectID().Hex()))
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		db.Drop(ctx)
+		client.Disconnect(ctx)
+	})
+	return db
+}
+
+// schedulerFixture is a notification service on a fake clock with a fake push sender
+type schedulerFixture struct {
+	db       *mongo.Database
+	clock    *FakeClock
+	push     *FakeNotifier
+	service  *NotificationService
+	userID   string
+	plantID  primitive.ObjectID
+	reminder map[primitive.ObjectID]string // Reminder IDs to test names
+}
+
+func newSchedulerFixture(t *testing.T, start time.Time) *schedulerFixture {
+	t.Helper()
+	db := newTestDatabase(t)
+	ctx := context.Background()
+
+	occurrences := NewOccurrenceService(db)
+	if err := occurrences.EnsureIndexes(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	f := &schedulerFixture{
+		db:       db,
+		clock:    NewFakeClock(start),
+		push:     NewFakeNotifier(models.DeliveryChannelFCM),
+		userID:   "user-1",
+		plantID:  primitive.NewObjectID(),
+		reminder: make(map[primitive.ObjectID]string),
+	}
+	f.service = NewNotificationService(db, f.clock, NewTemplateService(db), occurrences, NewInboxService(db), NewEventBus(f.clock), f.push)
+
+	if _, err := db.Collection("users").InsertOne(ctx, models.User{User_id: f.userID, Language: models.LocaleEnglish}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Collection("plants").InsertOne(ctx, models.Plant{ID: f.plantID, UserID: f.userID, Name: "Monstera"}); err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func (f *schedulerFixture) addReminder(t *testing.T, name string, reminder models.Reminder) {
+	t.Helper()
+	reminder.ID = primitive.NewObjectID()
+	reminder.UserID = f.userID
+	reminder.PlantID = f.plantID
+	reminder.Type = "watering"
+	reminder.IsActive = true
+	if _, err := f.db.Collection("reminders").InsertOne(context.Background(), reminder); err != nil {
+		t.Fatal(err)
+	}
+	f.reminder[reminder.ID] = name
+}
+
+// sent returns the names of the reminders pushed since the last call, sorted
+func (f *schedulerFixture) sent(t *testing.T) []string {
+	t.Helper()
+	var names []string
+	for _, notification := range f.push.Sent() {
+		id, err := primitive.ObjectIDFromHex(notification.Payload.Data["reminderId"])
+		if err != nil {
+			t.Fatalf("notification without reminder ID: %+v", notification.Payload)
+		}
+		names = append(names, f.reminder[id])
+	}
+	f.push.Reset()
+	sort.Strings(names)
+	return names
+}
+
+func TestCheckAndSendRemindersOnFakeTime(t *testing.T) {
+	loc := bangkok(t)
+	at := func(month time.Month, day, hour, min, sec int) time.Time {
+		// The fake clock runs in UTC; the service reads it in Bangkok time
+		return time.Date(2026, month, day, hour, min, sec, 0, loc).UTC()
+	}
+
+	f := newSchedulerFixture(t, at(3, 1, 23, 0, 0))
+	f.addReminder(t, "daily", models.Reminder{Frequency: "daily", TimeOfDay: "08:00"})
+	f.addReminder(t, "weekly", models.Reminder{Frequency: "weekly", DayOfWeek: "Monday", TimeOfDay: "00:00"})
+	f.addReminder(t, "once", models.Reminder{Frequency: "once", ScheduledTime: at(3, 3, 23, 59, 0)})
+	f.addReminder(t, "interval", models.Reminder{Frequency: "interval", IntervalDays: 2, TimeOfDay: "08:00", ScheduledTime: at(3, 2, 0, 0, 0)})
+
+	steps := []struct {
+		now  time.Time
+		want []string
+	}{
+		{at(3, 1, 23, 59, 30), nil},                                    // Sunday night
+		{at(3, 2, 0, 0, 0), []string{"weekly"}},                        // Monday at midnight
+		{at(3, 2, 0, 0, 55), nil},                                      // Same minute, already sent
+		{at(3, 2, 7, 59, 59), nil},                                     // A second early
+		{at(3, 2, 8, 0, 5), []string{"daily", "interval"}},             // Interval anchor day
+		{at(3, 2, 8, 0, 10), nil},                                      // Next tick, same minute
+		{at(3, 3, 8, 0, 0), []string{"daily"}},                         // Not an interval day
+		{at(3, 3, 23, 59, 0), []string{"once"}},                        // One-time, just before midnight
+		{at(3, 4, 0, 0, 0), nil},                                       // Past midnight
+		{at(3, 4, 8, 0, 0), []string{"daily", "interval"}},             // Two days after the anchor
+		{at(3, 5, 23, 59, 0), nil},                                     // The one-time reminder is done
+		{at(3, 9, 0, 0, 0), []string{"weekly"}},                        // Next Monday
+		{at(3, 9, 8, 0, 0), []string{"daily"}},                         // Seven days after the anchor
+		{at(3, 10, 8, 0, 0), []string{"daily", "interval"}},            // Eight days after the anchor
+		{at(3, 10, 8, 1, 0), nil},                                      // A minute late
+		{at(3, 16, 0, 0, 0).Add(-time.Second), nil},                    // Sunday 23:59:59
+		{at(3, 16, 0, 0, 0).Add(59 * time.Second), []string{"weekly"}}, // Monday 00:00:59
+	}
+
+	for _, step := range steps {
+		f.clock.Set(step.now)
+		if err := f.service.CheckAndSendReminders(); err != nil {
+			t.Fatalf("at %v: %v", step.now.In(loc), err)
+		}
+		if got := f.sent(t); fmt.Sprint(got) != fmt.Sprint(step.want) {
+			t.Errorf("at %v: sent %v, want %v", step.now.In(loc), got, step.want)
+		}
+	}
+
+	var once models.Reminder
+	for id, name := range f.reminder {
+		if name == "once" {
+			if err := f.db.Collection("reminders").FindOne(context.Background(), bson.M{"_id": id}).Decode(&once); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if once.IsActive {
+		t.Error("one-time reminder is still active after firing")
+	}
+}
+
+func TestSchedulerResendsUnacknowledgedOnce(t *testing.T) {
+	loc := bangkok(t)
+	start := time.Date(2026, 3, 2, 7, 59, 58, 0, loc)
+
+	f := newSchedulerFixture(t, start)
+	f.addReminder(t, "daily", models.Reminder{Frequency: "daily", TimeOfDay: "08:00"})
+	scheduler := NewScheduler(f.service, nil, nil, f.clock)
+
+	tick := func(d time.Duration) []string {
+		f.clock.Advance(d)
+		scheduler.Tick()
+		return f.sent(t)
+	}
+
+	if got := tick(schedulerInterval); fmt.Sprint(got) != "[daily]" {
+		t.Fatalf("at 08:00 sent %v, want [daily]", got)
+	}
+	if got := tick(AcknowledgementWindow - schedulerInterval); len(got) != 0 {
+		t.Fatalf("inside the acknowledgement window sent %v, want nothing", got)
+	}
+	if got := tick(schedulerInterval); fmt.Sprint(got) != "[daily]" {
+		t.Fatalf("after the acknowledgement window sent %v, want the resend", got)
+	}
+	if got := tick(time.Hour); len(got) != 0 {
+		t.Fatalf("an hour after the resend sent %v, want nothing", got)
+	}
+
+	// Acknowledging the next day's occurrence stops its resend
+	f.clock.Set(time.Date(2026, 3, 3, 7, 59, 58, 0, loc))
+	if got := tick(schedulerInterval); fmt.Sprint(got) != "[daily]" {
+		t.Fatalf("on the next day sent %v, want [daily]", got)
+	}
+	var occurrence models.ReminderOccurrence
+	err := f.db.Collection("reminder_occurrences").FindOne(context.Background(),
+		bson.M{"status": models.OccurrenceStatusPending},
+		options.FindOne().SetSort(bson.D{{Key: "scheduled_for", Value: -1}})).Decode(&occurrence)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.service.occurrences.Acknowledge(context.Background(), f.userID, occurrence.ID, models.CareEvent{}, f.clock.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if got := tick(2 * AcknowledgementWindow); len(got) != 0 {
+		t.Fatalf("after acknowledging sent %v, want nothing", got)
+	}
+}
+
+// occurrence returns the fixture's only reminder occurrence
+func (f *schedulerFixture) occurrence(t *testing.T) models.ReminderOccurrence {
+	t.Helper()
+	var occurrence models.ReminderOccurrence
+	if err := f.db.Collection("reminder_occurrences").FindOne(context.Background(), bson.M{}).Decode(&occurrence); err != nil {
+		t.Fatal(err)
+	}
+	return occurrence
+}
+
+// sentTo returns the recipients of the notifications sent on n since the last call
+func sentTo(n *FakeNotifier) []string {
+	var users []string
+	for _, notification := range n.Sent() {
+		users = append(users, notification.UserID)
+	}
+	n.Reset()
+	return users
+}
+
+func TestSchedulerTakesEscalationSteps(t *testing.T) {
+	ctx := context.Background()
+	loc := bangkok(t)
+	f := newSchedulerFixture(t, time.Date(2026, 3, 2, 7, 59, 58, 0, loc))
+	email := NewFakeNotifier(models.DeliveryChannelEmail)
+	f.service = NewNotificationService(f.db, f.clock, NewTemplateService(f.db), f.service.occurrences, NewInboxService(f.db), NewEventBus(f.clock), f.push, email)
+	scheduler := NewScheduler(f.service, nil, nil, f.clock)
+
+	if _, err := f.db.Collection("users").InsertOne(ctx, models.User{User_id: "friend", Language: models.LocaleEnglish}); err != nil {
+		t.Fatal(err)
+	}
+	caretakers := NewCaretakerService(f.db)
+	invitation, err := caretakers.Invite(ctx, f.userID, "friend@example.com", f.clock.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := caretakers.Accept(ctx, invitation.ID, "friend", "friend@example.com", f.clock.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	f.addReminder(t, "daily", models.Reminder{Frequency: "daily", TimeOfDay: "08:00", Escalation: []models.EscalationStep{
+		{AfterHours: 1, Action: models.EscalationActionChannel, Channel: models.DeliveryChannelEmail},
+		{AfterHours: 2, Action: models.EscalationActionDelegate, DelegateUserID: "friend"},
+		{AfterHours: 1, Action: models.EscalationActionResend},
+	}})
+
+	f.clock.Advance(schedulerInterval)
+	scheduler.Tick()
+	if got := sentTo(f.push); fmt.Sprint(got) != "[user-1]" {
+		t.Fatalf("at 08:00 pushed to %v, want the owner", got)
+	}
+	last := f.clock.Now()
+
+	steps := []struct {
+		after     time.Duration // Since the previous step
+		wantPush  []string
+		wantEmail []string
+		wantLevel int
+	}{
+		{time.Hour - time.Second, nil, nil, 0},
+		{time.Second, nil, []string{"user-1"}, 1},               // Another channel
+		{2*time.Hour - time.Second, nil, nil, 1},                // Counted from the previous step
+		{time.Second, []string{"friend"}, nil, 2},               // The caretaker
+		{time.Hour, []string{"user-1"}, nil, 3},                 // The last step
+		{24*time.Hour - 4*time.Hour - time.Minute, nil, nil, 3}, // Nothing is left to take
+	}
+	for i, step := range steps {
+		last = last.Add(step.after)
+		f.clock.Set(last)
+		scheduler.Tick()
+		if got := sentTo(f.push); fmt.Sprint(got) != fmt.Sprint(step.wantPush) {
+			t.Errorf("step %d: pushed to %v, want %v", i, got, step.wantPush)
+		}
+		if got := sentTo(email); fmt.Sprint(got) != fmt.Sprint(step.wantEmail) {
+			t.Errorf("step %d: emailed %v, want %v", i, got, step.wantEmail)
+		}
+		if got := f.occurrence(t).EscalationLevel; got != step.wantLevel {
+			t.Errorf("step %d: escalation level %d, want %d", i, got, step.wantLevel)
+		}
+	}
+
+	occurrence := f.occurrence(t)
+	if occurrence.Escalating || len(occurrence.EscalationLog) != 3 {
+		t.Errorf("occurrence = %+v, want escalation finished after three steps", occurrence)
+	}
+	if occurrence.ResentAt != nil {
+		t.Error("escalated occurrence was also resent")
+	}
+}
+
+func TestEscalationToAFormerCaretakerGoesToTheOwner(t *testing.T) {
+	ctx := context.Background()
+	loc := bangkok(t)
+	f := newSchedulerFixture(t, time.Date(2026, 3, 2, 7, 59, 58, 0, loc))
+	scheduler := NewScheduler(f.service, nil, nil, f.clock)
+	if _, err := f.db.Collection("users").InsertOne(ctx, models.User{User_id: "friend", Language: models.LocaleEnglish}); err != nil {
+		t.Fatal(err)
+	}
+	// Never accepted an invitation
+	f.addReminder(t, "daily", models.Reminder{Frequency: "daily", TimeOfDay: "08:00", Escalation: []models.EscalationStep{
+		{AfterHours: 1, Action: models.EscalationActionDelegate, DelegateUserID: "friend"},
+	}})
+
+	f.clock.Advance(schedulerInterval)
+	scheduler.Tick()
+	sentTo(f.push)
+
+	f.clock.Advance(time.Hour)
+	scheduler.Tick()
+	if got := sentTo(f.push); fmt.Sprint(got) != "[user-1]" {
+		t.Errorf("pushed to %v, want the owner", got)
+	}
+	if occurrence := f.occurrence(t); occurrence.Escalating {
+		t.Error("occurrence is still escalating")
+	}
+}
+
+func TestEscalationStopsWhenTheReminderIsDeleted(t *testing.T) {
+	ctx := context.Background()
+	loc := bangkok(t)
+	f := newSchedulerFixture(t, time.Date(2026, 3, 2, 7, 59, 58, 0, loc))
+	scheduler := NewScheduler(f.service, nil, nil, f.clock)
+	f.addReminder(t, "daily", models.Reminder{Frequency: "daily", TimeOfDay: "08:00", Escalation: []models.EscalationStep{
+		{AfterHours: 1, Action: models.EscalationActionResend},
+	}})
+
+	f.clock.Advance(schedulerInterval)
+	scheduler.Tick()
+	sentTo(f.push)
+	if _, err := f.db.Collection("reminders").DeleteMany(ctx, bson.M{}); err != nil {
+		t.Fatal(err)
+	}
+
+	f.clock.Advance(time.Hour)
+	scheduler.Tick()
+	if got := sentTo(f.push); len(got) != 0 {
+		t.Errorf("pushed to %v after the reminder was deleted", got)
+	}
+	if occurrence := f.occurrence(t); occurrence.Escalating {
+		t.Error("occurrence of a deleted reminder is still escalating")
+	}
+}
+
+func TestUnacknowledgedIsResentWhenEscalationRulesAreRemoved(t *testing.T) {
+	ctx := context.Background()
+	loc := bangkok(t)
+	f := newSchedulerFixture(t, time.Date(2026, 3, 2, 7, 59, 58, 0, loc))
+	scheduler := NewScheduler(f.service, nil, nil, f.clock)
+	f.addReminder(t, "daily", models.Reminder{Frequency: "daily", TimeOfDay: "08:00", Escalation: []models.EscalationStep{
+		{AfterHours: 2, Action: models.EscalationActionResend},
+	}})
+
+	f.clock.Advance(schedulerInterval)
+	scheduler.Tick()
+	if got := f.sent(t); fmt.Sprint(got) != "[daily]" {
+		t.Fatalf("at 08:00 sent %v, want [daily]", got)
+	}
+	sentAt := f.clock.Now()
+
+	// The rules are removed before the first step, which stops the escalation
+	if _, err := f.db.Collection("reminders").UpdateMany(ctx, bson.M{}, bson.M{"$unset": bson.M{"escalation": ""}}); err != nil {
+		t.Fatal(err)
+	}
+	f.clock.Advance(schedulerInterval)
+	scheduler.Tick()
+	if occurrence := f.occurrence(t); occurrence.Escalating {
+		t.Fatal("occurrence is still escalating without rules")
+	}
+
+	f.clock.Set(sentAt.Add(AcknowledgementWindow))
+	scheduler.Tick()
+	if got := f.sent(t); fmt.Sprint(got) != "[daily]" {
+		t.Fatalf("after the acknowledgement window sent %v, want the resend", got)
+	}
+	f.clock.Advance(time.Hour)
+	scheduler.Tick()
+	if got := f.sent(t); len(got) != 0 {
+		t.Fatalf("an hour after the resend sent %v, want nothing", got)
+	}
+}
@@ -0,0 +1,336 @@
+package services
+
+import (
+	"authentication/models"
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"text/template"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// DigestTemplateType is the template used when several reminders are merged into one notification
+const DigestTemplateType = "digest"
+
+// GrowthAlertTemplateType is the template used when a plant's growth history is flagged
+const GrowthAlertTemplateType = "growth_alert"
+
+// TemplateVars are the values available to notification templates
+type TemplateVars struct {
+	PlantName    string
+	PlantType    string
+	Container    string
+	ReminderType string // Empty in a digest that mixes reminder types
+	Frequency    string // "once", "daily" or "weekly"
+	TimeOfDay    string
+	DayOfWeek    string
+	// Digest only
+	Count           int
+	PlantNames      string // All plant names, comma separated
+	ShortPlantNames string // The first plant names, ending with "…" when there are more
+	// Growth alerts only
+	AlertType string // "height_drop", "stalled" or "declining_mood"
+}
+
+// defaultTemplates are seeded into the database and used when a stored template cannot be rendered
+var defaultTemplates = []models.NotificationTemplate{
+	{
+		Type:   "watering",
+		Locale: models.LocaleThai,
+		Title:  "🪴 ถึงเวลารดน้ำ {{.PlantName}} แล้ว!",
+		Body:   `{{if eq .Frequency "daily"}}ถึงเวลารดน้ำประจำวันแล้ว{{else if eq .Frequency "weekly"}}ถึงเวลารดน้ำประจำสัปดาห์แล้ว{{else}}ถึงเวลาที่กำหนดไว้สำหรับการรดน้ำแล้ว{{end}}`,
+	},
+	{
+		Type:   "watering",
+		Locale: models.LocaleEnglish,
+		Title:  "🪴 Time to water {{.PlantName}}!",
+		Body:   `{{if eq .Frequency "daily"}}Your daily watering is due.{{else if eq .Frequency "weekly"}}Your weekly watering is due.{{else}}It's time for the watering you scheduled.{{end}}`,
+	},
+	{
+		Type:   "fertilizing",
+		Locale: models.LocaleThai,
+		Title:  "🌱 ถึงเวลาใส่ปุ๋ย {{.PlantName}} แล้ว!",
+		Body:   `{{if eq .Frequency "daily"}}ถึงเวลาใส่ปุ๋ยประจำวันแล้ว{{else if eq .Frequency "weekly"}}ถึงเวลาใส่ปุ๋ยประจำสัปดาห์แล้ว{{else}}ถึงเวลาที่กำหนดไว้สำหรับการใส่ปุ๋ยแล้ว{{end}}`,
+	},
+	{
+		Type:   "fertilizing",
+		Locale: models.LocaleEnglish,
+		Title:  "🌱 Time to fertilize {{.PlantName}}!",
+		Body:   `{{if eq .Frequency "daily"}}Your daily fertilizing is due.{{else if eq .Frequency "weekly"}}Your weekly fertilizing is due.{{else}}It's time for the fertilizing you scheduled.{{end}}`,
+	},
+	{
+		Type:   "misting",
+		Locale: models.LocaleThai,
+		Title:  "💧 ถึงเวลาพ่นละอองน้ำให้ {{.PlantName}} แล้ว!",
+		Body:   "พ่นละอองน้ำที่ใบเพื่อเพิ่มความชื้น",
+	},
+	{
+		Type:   "misting",
+		Locale: models.LocaleEnglish,
+		Title:  "💧 Time to mist {{.PlantName}}!",
+		Body:   "Mist the leaves to keep the humidity up.",
+	},
+	{
+		Type:   DigestTemplateType,
+		Locale: models.LocaleThai,
+		Title:  `{{if eq .ReminderType "watering"}}🪴 รดน้ำต้นไม้ {{.Count}} ต้น{{else if eq .ReminderType "fertilizing"}}🌱 ใส่ปุ๋ยต้นไม้ {{.Count}} ต้น{{else}}🔔 ดูแลต้นไม้ {{.Count}} ต้น{{end}}: {{.ShortPlantNames}}`,
+		Body:   "ถึงเวลาดูแล {{.PlantNames}} แล้ว",
+	},
+	{
+		Type:   DigestTemplateType,
+		Locale: models.LocaleEnglish,
+		Title:  `{{if eq .ReminderType "watering"}}🪴 Water {{.Count}} plants{{else if eq .ReminderType "fertilizing"}}🌱 Fertilize {{.Count}} plants{{else}}🔔 Care for {{.Count}} plants{{end}}: {{.ShortPlantNames}}`,
+		Body:   "It's time to take care of {{.PlantNames}}.",
+	},
+	{
+		Type:   GrowthAlertTemplateType,
+		Locale: models.LocaleThai,
+		Title:  "⚠️ {{.PlantName}} ต้องการการดูแล",
+		Body:   `{{if eq .AlertType "height_drop"}}ความสูงลดลงจากครั้งก่อน{{else if eq .AlertType "stalled"}}ไม่โตขึ้นเลยในช่วงหลายสัปดาห์ที่ผ่านมา{{else}}อารมณ์ของต้นไม้แย่ลงในการบันทึกล่าสุด{{end}} ลองวิเคราะห์อาการดูนะ`,
+	},
+	{
+		Type:   GrowthAlertTemplateType,
+		Locale: models.LocaleEnglish,
+		Title:  "⚠️ {{.PlantName}} needs attention",
+		Body:   `{{if eq .AlertType "height_drop"}}It is shorter than last time.{{else if eq .AlertType "stalled"}}It hasn't grown in several weeks.{{else}}Its mood has been getting worse.{{end}} Run a diagnosis to find out why.`,
+	},
+	{
+		Type:   "default",
+		Locale: models.LocaleThai,
+		Title:  "🔔 การแจ้งเตือนสำหรับ {{.PlantName}}",
+		Body:   "ถึงเวลาดูแลต้นไม้ของคุณแล้ว",
+	},
+	{
+		Type:   "default",
+		Locale: models.LocaleEnglish,
+		Title:  "🔔 Reminder for {{.PlantName}}",
+		Body:   "It's time to take care of your plant.",
+	},
+}
+
+// sampleTemplateVars are used to check that a template renders before it is saved
+var sampleTemplateVars = TemplateVars{
+	PlantName:       "Monstera",
+	PlantType:       "ไม้ใบ",
+	Container:       "กระถาง",
+	ReminderType:    "watering",
+	Frequency:       "daily",
+	TimeOfDay:       "08:00",
+	DayOfWeek:       "Monday",
+	Count:           2,
+	PlantNames:      "Monstera, Pothos",
+	ShortPlantNames: "Monstera, Pothos",
+	AlertType:       "stalled",
+}
+
+// NormalizeLocale maps a user language preference to a supported locale, Thai by default
+func NormalizeLocale(language string) string {
+	if strings.HasPrefix(strings.ToLower(language), models.LocaleEnglish) {
+		return models.LocaleEnglish
+	}
+	return models.LocaleThai
+}
+
+// IsSupportedLocale reports whether templates can be stored for the locale
+func IsSupportedLocale(locale string) bool {
+	return locale == models.LocaleThai || locale == models.LocaleEnglish
+}
+
+// TemplateService renders notification text from templates stored per reminder type and locale
+type TemplateService struct {
+	db *mongo.Database
+}
+
+func NewTemplateService(db *mongo.Database) *TemplateService {
+	return &TemplateService{db: db}
+}
+
+func (s *TemplateService) collection() *mongo.Collection {
+	return s.db.Collection("notification_templates")
+}
+
+// EnsureDefaults creates the unique index and seeds built-in templates that are not in the database yet
+func (s *TemplateService) EnsureDefaults(ctx context.Context) error {
+	_, err := s.collection().Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "type", Value: 1}, {Key: "locale", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("error creating notification template index: %v", err)
+	}
+
+	for _, tmpl := range defaultTemplates {
+		_, err := s.collection().UpdateOne(ctx,
+			bson.M{"type": tmpl.Type, "locale": tmpl.Locale},
+			bson.M{"$setOnInsert": bson.M{
+				"title":      tmpl.Title,
+				"body":       tmpl.Body,
+				"updated_at": time.Now(),
+			}},
+			options.Update().SetUpsert(true),
+		)
+		if err != nil {
+			return fmt.Errorf("error seeding notification template %s/%s: %v", tmpl.Type, tmpl.Locale, err)
+		}
+	}
+	return nil
+}
+
+// List returns all stored templates ordered by type and locale
+func (s *TemplateService) List(ctx context.Context) ([]models.NotificationTemplate, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "type", Value: 1}, {Key: "locale", Value: 1}})
+	cursor, err := s.collection().Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error finding notification templates: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	templates := []models.NotificationTemplate{}
+	if err := cursor.All(ctx, &templates); err != nil {
+		return nil, fmt.Errorf("error decoding notification templates: %v", err)
+	}
+	return templates, nil
+}
+
+// Save validates and stores the template for its type and locale
+func (s *TemplateService) Save(ctx context.Context, tmpl models.NotificationTemplate) (*models.NotificationTemplate, error) {
+	if _, _, err := renderTemplate(tmpl, sampleTemplateVars); err != nil {
+		return nil, err
+	}
+
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	var saved models.NotificationTemplate
+	err := s.collection().FindOneAndUpdate(ctx,
+		bson.M{"type": tmpl.Type, "locale": tmpl.Locale},
+		bson.M{"$set": bson.M{
+			"title":      tmpl.Title,
+			"body":       tmpl.Body,
+			"updated_at": time.Now(),
+			"updated_by": tmpl.UpdatedBy,
+		}},
+		opts,
+	).Decode(&saved)
+	if err != nil {
+		return nil, fmt.Errorf("error saving notification template: %v", err)
+	}
+	return &saved, nil
+}
+
+// Reset restores the built-in text of a template, or removes a custom type that has none.
+// It returns false when there was nothing to reset.
+func (s *TemplateService) Reset(ctx context.Context, reminderType, locale string) (bool, error) {
+	if builtIn, ok := builtInTemplate(reminderType, locale); ok {
+		_, err := s.collection().UpdateOne(ctx,
+			bson.M{"type": reminderType, "locale": locale},
+			bson.M{
+				"$set":   bson.M{"title": builtIn.Title, "body": builtIn.Body, "updated_at": time.Now()},
+				"$unset": bson.M{"updated_by": ""},
+			},
+			options.Update().SetUpsert(true),
+		)
+		if err != nil {
+			return false, fmt.Errorf("error resetting notification template: %v", err)
+		}
+		return true, nil
+	}
+
+	result, err := s.collection().DeleteOne(ctx, bson.M{"type": reminderType, "locale": locale})
+	if err != nil {
+		return false, fmt.Errorf("error deleting notification template: %v", err)
+	}
+	return result.DeletedCount > 0, nil
+}
+
+// Render produces the title and body for a reminder type in the user's language.
+// Lookup falls back from the locale to Thai and from the type to "default",
+// and finally to the built-in text, so a notification is never dropped for lack of a template.
+func (s *TemplateService) Render(ctx context.Context, reminderType, language string, vars TemplateVars) (string, string) {
+	locale := NormalizeLocale(language)
+
+	for _, key := range templateFallbacks(reminderType, locale) {
+		var tmpl models.NotificationTemplate
+		err := s.collection().FindOne(ctx, bson.M{"type": key.Type, "locale": key.Locale}).Decode(&tmpl)
+		if err == mongo.ErrNoDocuments {
+			continue
+		}
+		if err != nil {
+			log.Printf("[ERROR] Error fetching notification template %s/%s: %v", key.Type, key.Locale, err)
+			break
+		}
+
+		title, body, err := renderTemplate(tmpl, vars)
+		if err != nil {
+			log.Printf("[ERROR] Error rendering notification template %s/%s: %v", key.Type, key.Locale, err)
+			break
+		}
+		return title, body
+	}
+
+	for _, key := range templateFallbacks(reminderType, locale) {
+		if builtIn, ok := builtInTemplate(key.Type, key.Locale); ok {
+			if title, body, err := renderTemplate(builtIn, vars); err == nil {
+				return title, body
+			}
+		}
+	}
+	// Unreachable while defaultTemplates has a Thai "default" entry
+	return vars.PlantName, ""
+}
+
+// templateFallbacks lists the (type, locale) pairs to try, most specific first
+func templateFallbacks(reminderType, locale string) []models.NotificationTemplate {
+	keys := []models.NotificationTemplate{{Type: reminderType, Locale: locale}}
+	if locale != models.LocaleThai {
+		keys = append(keys, models.NotificationTemplate{Type: reminderType, Locale: models.LocaleThai})
+	}
+	if reminderType != "default" {
+		keys = append(keys, models.NotificationTemplate{Type: "default", Locale: locale})
+		if locale != models.LocaleThai {
+			keys = append(keys, models.NotificationTemplate{Type: "default", Locale: models.LocaleThai})
+		}
+	}
+	return keys
+}
+
+func builtInTemplate(reminderType, locale string) (models.NotificationTemplate, bool) {
+	for _, tmpl := range defaultTemplates {
+		if tmpl.Type == reminderType && tmpl.Locale == locale {
+			return tmpl, true
+		}
+	}
+	return models.NotificationTemplate{}, false
+}
+
+// renderTemplate executes the title and body of a template, rejecting empty results
+func renderTemplate(tmpl models.NotificationTemplate, vars TemplateVars) (string, string, error) {
+	title, err := executeTemplate("title", tmpl.Title, vars)
+	if err != nil {
+		return "", "", err
+	}
+	body, err := executeTemplate("body", tmpl.Body, vars)
+	if err != nil {
+		return "", "", err
+	}
+	if strings.TrimSpace(title) == "" || strings.TrimSpace(body) == "" {
+		return "", "", fmt.Errorf("template renders an empty title or body")
+	}
+	return title, body, nil
+}
+
+func executeTemplate(name, text string, vars TemplateVars) (string, error) {
+	t, err := template.New(name).Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s template: %v", name, err)
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, vars); err != nil {
+		return "", fmt.Errorf("error rendering %s template: %v", name, err)
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"authentication/models"
+	"context"
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestTemplateFallbacks(t *testing.T) {
+	key := func(reminderType, locale string) models.NotificationTemplate {
+		return models.NotificationTemplate{Type: reminderType, Locale: locale}
+	}
+	tests := []struct {
+		reminderType, locale string
+		want                 []models.NotificationTemplate
+	}{
+		{"watering", models.LocaleEnglish, []models.NotificationTemplate{
+			key("watering", "en"), key("watering", "th"), key("default", "en"), key("default", "th"),
+		}},
+		{"watering", models.LocaleThai, []models.NotificationTemplate{
+			key("watering", "th"), key("default", "th"),
+		}},
+		{"default", models.LocaleEnglish, []models.NotificationTemplate{
+			key("default", "en"), key("default", "th"),
+		}},
+		{"default", models.LocaleThai, []models.NotificationTemplate{
+			key("default", "th"),
+		}},
+	}
+	for _, tt := range tests {
+		if got := templateFallbacks(tt.reminderType, tt.locale); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("templateFallbacks(%q, %q) = %v, want %v", tt.reminderType, tt.locale, got, tt.want)
+		}
+	}
+}
+
+func TestRenderFallsBack(t *testing.T) {
+	ctx := context.Background()
+	service := NewTemplateService(newTestDatabase(t))
+	vars := TemplateVars{PlantName: "Monstera", ReminderType: "watering", Frequency: "daily"}
+	stored := func(reminderType, locale, title string) models.NotificationTemplate {
+		return models.NotificationTemplate{Type: reminderType, Locale: locale, Title: title, Body: "body " + title}
+	}
+
+	tests := []struct {
+		name         string
+		stored       []models.NotificationTemplate
+		reminderType string
+		language     string
+		wantTitle    string
+	}{
+		{
+			name:         "the user's locale",
+			stored:       []models.NotificationTemplate{stored("watering", "en", "en {{.PlantName}}"), stored("watering", "th", "th {{.PlantName}}")},
+			reminderType: "watering",
+			language:     "en-US",
+			wantTitle:    "en Monstera",
+		},
+		{
+			name:         "Thai when the locale has no template",
+			stored:       []models.NotificationTemplate{stored("watering", "th", "th {{.PlantName}}"), stored("default", "en", "default en")},
+			reminderType: "watering",
+			language:     "en",
+			wantTitle:    "th Monstera",
+		},
+		{
+			name:         "unknown languages use Thai",
+			stored:       []models.NotificationTemplate{stored("watering", "en", "en"), stored("watering", "th", "th")},
+			reminderType: "watering",
+			language:     "fr",
+			wantTitle:    "th",
+		},
+		{
+			name:         "the default type in the user's locale",
+			stored:       []models.NotificationTemplate{stored("default", "en", "default en"), stored("default", "th", "default th")},
+			reminderType: "repotting",
+			language:     "en",
+			wantTitle:    "default en",
+		},
+		{
+			name:         "the default type in Thai",
+			stored:       []models.NotificationTemplate{stored("default", "th", "default th")},
+			reminderType: "repotting",
+			language:     "en",
+			wantTitle:    "default th",
+		},
+		{
+			name:         "built-in text when nothing is stored",
+			reminderType: "watering",
+			language:     "en",
+			wantTitle:    "🪴 Time to water Monstera!",
+		},
+		{
+			name:         "built-in default for a type without built-in text",
+			reminderType: "repotting",
+			language:     "en",
+			wantTitle:    "🔔 Reminder for Monstera",
+		},
+		{
+			name:         "built-in text when the template does not parse",
+			stored:       []models.NotificationTemplate{stored("watering", "en", "{{.PlantName"), stored("watering", "th", "th")},
+			reminderType: "watering",
+			language:     "en",
+			wantTitle:    "🪴 Time to water Monstera!",
+		},
+		{
+			name:         "built-in text when the template uses an unknown variable",
+			stored:       []models.NotificationTemplate{stored("watering", "en", "{{.Nickname}}")},
+			reminderType: "watering",
+			language:     "en",
+			wantTitle:    "🪴 Time to water Monstera!",
+		},
+		{
+			name:         "built-in text when the template renders empty",
+			stored:       []models.NotificationTemplate{stored("watering", "th", `{{if eq .Frequency "weekly"}}weekly{{end}}`)},
+			reminderType: "watering",
+			language:     "th",
+			wantTitle:    "🪴 ถึงเวลารดน้ำ Monstera แล้ว!",
+		},
+	}
+	for _, tt := range tests {
+		if _, err := service.collection().DeleteMany(ctx, bson.M{}); err != nil {
+			t.Fatal(err)
+		}
+		for _, tmpl := range tt.stored {
+			if _, err := service.collection().InsertOne(ctx, tmpl); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		title, body := service.Render(ctx, tt.reminderType, tt.language, vars)
+		if title != tt.wantTitle {
+			t.Errorf("%s: title = %q, want %q", tt.name, title, tt.wantTitle)
+		}
+		if body == "" {
+			t.Errorf("%s: empty body", tt.name)
+		}
+	}
+}
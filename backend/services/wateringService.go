@@ -0,0 +1,406 @@
+package services
+
+import (
+	"authentication/models"
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// wateringLookback is how far back care history is read when suggesting an interval
+	wateringLookback    = 30 * 24 * time.Hour
+	MinWateringInterval = 1
+	MaxWateringInterval = 14
+	// defaultWateringInterval is used when neither the catalog nor the reminder gives one
+	defaultWateringInterval = 3
+)
+
+// Reasons a watering interval was changed
+const (
+	WateringReasonOverwateringDiagnosis  = "overwatering_diagnosis"
+	WateringReasonUnderwateringDiagnosis = "underwatering_diagnosis"
+	WateringReasonOftenSkipped           = "often_skipped"
+	WateringReasonOftenMissed            = "often_missed"
+	WateringReasonPoorMood               = "poor_mood"
+)
+
+// Symptoms and diagnosis wording from plant_problem_data.json that point to a watering problem
+var (
+	overwateringSymptoms = []string{"รากเน่า", "รากเน่าจากน้ำขัง", "ลำต้นเน่า"}
+	overwateringPhrases  = []string{"รดน้ำมากเกินไป", "ให้น้ำมากเกินไป", "น้ำขัง"}
+	underwateringPhrases = []string{"ขาดน้ำ"}
+)
+
+var timesPerWeekPattern = regexp.MustCompile(`(\d+)(?:\s*-\s*(\d+))?\s*ครั้ง\s*/\s*สัปดาห์`)
+
+// ParseCatalogWatering turns the catalog's น้ำ requirement, e.g. "ปานกลาง (รดน้ำ 2-3 ครั้ง/สัปดาห์)",
+// into a watering interval in days
+func ParseCatalogWatering(water string) (int, bool) {
+	if strings.Contains(water, "ทุกวัน") {
+		return 1, true
+	}
+	if m := timesPerWeekPattern.FindStringSubmatch(water); m != nil {
+		low, _ := strconv.ParseFloat(m[1], 64)
+		high := low
+		if m[2] != "" {
+			high, _ = strconv.ParseFloat(m[2], 64)
+		}
+		if perWeek := (low + high) / 2; perWeek > 0 {
+			return clampWateringInterval(int(7/perWeek + 0.5)), true
+		}
+	}
+	// Level only
+	switch {
+	case strings.HasPrefix(water, "สูง"):
+		return 1, true
+	case strings.HasPrefix(water, "ปานกลาง"):
+		return 3, true
+	case strings.HasPrefix(water, "ต่ำ"):
+		return 5, true
+	}
+	return 0, false
+}
+
+// ReminderIntervalDays returns how many days apart a reminder fires, 0 for one-time reminders
+func ReminderIntervalDays(reminder models.Reminder) int {
+	switch reminder.Frequency {
+	case "daily":
+		return 1
+	case "weekly":
+		return 7
+	case "interval":
+		return reminder.IntervalDays
+	}
+	return 0
+}
+
+// IntervalReminderDue reports whether an "interval" reminder fires at now: the time of day
+// matches and a whole number of intervals has passed since the day of ScheduledTime
+func IntervalReminderDue(reminder models.Reminder, now time.Time) bool {
+	if reminder.IntervalDays < 1 || now.Format("15:04") != reminder.TimeOfDay {
+		return false
+	}
+	anchor := reminder.ScheduledTime.In(now.Location())
+	anchorDay := time.Date(anchor.Year(), anchor.Month(), anchor.Day(), 0, 0, 0, 0, now.Location())
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	if today.Before(anchorDay) {
+		return false
+	}
+	// Round to whole days so a DST-free timezone and UTC agree
+	days := int(today.Sub(anchorDay).Hours()/24 + 0.5)
+	return days%reminder.IntervalDays == 0
+}
+
+// WateringSignals is the care history a suggestion is based on
+type WateringSignals struct {
+	Acknowledged  int `json:"acknowledged"`
+	Skipped       int `json:"skipped"`
+	Missed        int `json:"missed"`
+	GoodMoods     int `json:"goodMoods"`
+	PoorMoods     int `json:"poorMoods"`
+	Overwatering  int `json:"overwateringDiagnoses"`
+	Underwatering int `json:"underwateringDiagnoses"`
+}
+
+// AdjustWateringInterval applies the care history to a base interval and returns the new
+// interval with the reasons for any change. Overwatering is by far the most common diagnosis,
+// so signs of it lengthen the interval more than other signals shorten it.
+func AdjustWateringInterval(base int, signals WateringSignals) (int, []string) {
+	interval := base
+	var reasons []string
+
+	if signals.Overwatering > 0 {
+		interval = max(interval*2, interval+2)
+		reasons = append(reasons, WateringReasonOverwateringDiagnosis)
+	} else if signals.Underwatering > 0 {
+		interval = interval / 2
+		reasons = append(reasons, WateringReasonUnderwateringDiagnosis)
+	}
+
+	settled := signals.Acknowledged + signals.Skipped + signals.Missed
+	if settled >= 4 {
+		// Skipping says the plant did not need it yet; missing says the schedule is too busy
+		if float64(signals.Skipped)/float64(settled) >= 0.4 {
+			interval++
+			reasons = append(reasons, WateringReasonOftenSkipped)
+		} else if float64(signals.Missed)/float64(settled) >= 0.5 && signals.Overwatering == 0 {
+			interval++
+			reasons = append(reasons, WateringReasonOftenMissed)
+		}
+	}
+
+	moods := signals.GoodMoods + signals.PoorMoods
+	if moods >= 2 && signals.Overwatering == 0 && signals.Underwatering == 0 &&
+		float64(signals.PoorMoods)/float64(moods) >= 0.5 {
+		// Without a diagnosis, poor moods on frequent watering most often mean too much water
+		if interval <= 2 {
+			interval++
+		} else {
+			interval--
+		}
+		reasons = append(reasons, WateringReasonPoorMood)
+	}
+
+	return clampWateringInterval(interval), reasons
+}
+
+func clampWateringInterval(days int) int {
+	return min(max(days, MinWateringInterval), MaxWateringInterval)
+}
+
+// DescribeWateringInterval words an interval for the suggestion message
+func DescribeWateringInterval(days int, locale string) string {
+	if locale == models.LocaleEnglish {
+		switch days {
+		case 1:
+			return "daily"
+		case 7:
+			return "weekly"
+		}
+		return fmt.Sprintf("every %d days", days)
+	}
+	switch days {
+	case 1:
+		return "ทุกวัน"
+	case 7:
+		return "ทุกสัปดาห์"
+	}
+	return fmt.Sprintf("ทุก %d วัน", days)
+}
+
+// WateringSuggestion is the interval suggested for a watering reminder
+type WateringSuggestion struct {
+	ReminderID        string          `json:"reminderId"`
+	CurrentInterval   int             `json:"currentIntervalDays"`
+	BaseInterval      int             `json:"baseIntervalDays"`
+	BaseSource        string          `json:"baseSource"` // "catalog", "reminder" or "default"
+	SuggestedInterval int             `json:"suggestedIntervalDays"`
+	Changed           bool            `json:"changed"`
+	Reasons           []string        `json:"reasons"`
+	Signals           WateringSignals `json:"signals"`
+	Message           string          `json:"message"`
+}
+
+// WateringService suggests watering intervals from a plant's care history
+type WateringService struct {
+	db *mongo.Database
+}
+
+func NewWateringService(db *mongo.Database) *WateringService {
+	return &WateringService{db: db}
+}
+
+// CatalogInterval returns the watering interval of the plant's catalog entry, if it has one
+func (s *WateringService) CatalogInterval(ctx context.Context, plant models.Plant) (int, bool) {
+	entry, err := FindCatalogEntry(ctx, s.db, plant)
+	if err != nil || entry == nil {
+		return 0, false
+	}
+	return ParseCatalogWatering(entry.Conditions.Water)
+}
+
+// FindCatalogEntry returns the catalog entry the plant is linked to, or the one with the
+// plant's name when it was never linked. It returns nil when there is none.
+func FindCatalogEntry(ctx context.Context, db *mongo.Database, plant models.Plant) (*models.PlantRecommendation, error) {
+	filter := bson.M{"id": plant.CatalogID}
+	if plant.CatalogID == 0 {
+		if plant.Name == "" {
+			return nil, nil
+		}
+		filter = bson.M{"name": plant.Name}
+	}
+
+	var entry models.PlantRecommendation
+	err := db.Collection("plant_recommendations").FindOne(ctx, filter).Decode(&entry)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error fetching catalog entry: %v", err)
+	}
+	return &entry, nil
+}
+
+// Suggest works out the watering interval for a reminder. The base is the reminder's own
+// interval once it is adaptive, the catalog requirement before that, and the care history
+// of the last 30 days adjusts it.
+func (s *WateringService) Suggest(ctx context.Context, reminder models.Reminder, language string, now time.Time) (*WateringSuggestion, error) {
+	var plant models.Plant
+	if err := s.db.Collection("plants").FindOne(ctx, bson.M{"_id": reminder.PlantID}).Decode(&plant); err != nil {
+		return nil, fmt.Errorf("error fetching plant: %v", err)
+	}
+
+	current := ReminderIntervalDays(reminder)
+	base, source := current, "reminder"
+	if !(reminder.Frequency == "interval" && reminder.Adaptive) {
+		if catalog, ok := s.CatalogInterval(ctx, plant); ok {
+			base, source = catalog, "catalog"
+		}
+	}
+	if base < 1 {
+		base, source = defaultWateringInterval, "default"
+	}
+
+	signals, err := s.signals(ctx, reminder, plant, now)
+	if err != nil {
+		return nil, err
+	}
+
+	suggested, reasons := AdjustWateringInterval(base, signals)
+	if reasons == nil {
+		reasons = []string{}
+	}
+
+	locale := NormalizeLocale(language)
+	var message string
+	switch {
+	case suggested == current && locale == models.LocaleEnglish:
+		message = "Keep watering " + DescribeWateringInterval(current, locale)
+	case suggested == current:
+		message = "รดน้ำ" + DescribeWateringInterval(current, locale) + "ต่อไป"
+	case locale == models.LocaleEnglish && current > 0:
+		message = fmt.Sprintf("Water %s instead of %s", DescribeWateringInterval(suggested, locale), DescribeWateringInterval(current, locale))
+	case locale == models.LocaleEnglish:
+		message = "Water " + DescribeWateringInterval(suggested, locale)
+	case current > 0:
+		message = fmt.Sprintf("แนะนำให้รดน้ำ%s แทน%s", DescribeWateringInterval(suggested, locale), DescribeWateringInterval(current, locale))
+	default:
+		message = "แนะนำให้รดน้ำ" + DescribeWateringInterval(suggested, locale)
+	}
+
+	return &WateringSuggestion{
+		ReminderID:        reminder.ID.Hex(),
+		CurrentInterval:   current,
+		BaseInterval:      base,
+		BaseSource:        source,
+		SuggestedInterval: suggested,
+		Changed:           suggested != current,
+		Reasons:           reasons,
+		Signals:           signals,
+		Message:           message,
+	}, nil
+}
+
+// signals reads the reminder's occurrences, the plant's growth moods and its diagnoses of the lookback period
+func (s *WateringService) signals(ctx context.Context, reminder models.Reminder, plant models.Plant, now time.Time) (WateringSignals, error) {
+	var signals WateringSignals
+	since := now.Add(-wateringLookback)
+
+	cursor, err := s.db.Collection("reminder_occurrences").Find(ctx, bson.M{
+		"reminder_id":   reminder.ID,
+		"scheduled_for": bson.M{"$gte": since},
+	})
+	if err != nil {
+		return signals, fmt.Errorf("error fetching reminder occurrences: %v", err)
+	}
+	var occurrences []models.ReminderOccurrence
+	if err := cursor.All(ctx, &occurrences); err != nil {
+		return signals, fmt.Errorf("error decoding reminder occurrences: %v", err)
+	}
+	stats := ComputeReminderStats(occurrences, now)
+	signals.Acknowledged = stats.Acknowledged
+	signals.Skipped = stats.Skipped
+	signals.Missed = stats.Missed
+
+	records, err := NewGrowthService(s.db).Since(ctx, plant.ID, since)
+	if err != nil {
+		return signals, err
+	}
+	for _, record := range records {
+		// Neutral and unknown moods say nothing either way
+		switch score := moodScores[record.Mood]; {
+		case score < 0:
+			signals.PoorMoods++
+		case score > 0:
+			signals.GoodMoods++
+		}
+	}
+
+	cursor, err = s.db.Collection("plant_diagnoses").Find(ctx, bson.M{
+		"plant_id":   plant.ID,
+		"created_at": bson.M{"$gte": since},
+	}, options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+	if err != nil {
+		return signals, fmt.Errorf("error fetching plant diagnoses: %v", err)
+	}
+	var diagnoses []models.PlantDiagnosis
+	if err := cursor.All(ctx, &diagnoses); err != nil {
+		return signals, fmt.Errorf("error decoding plant diagnoses: %v", err)
+	}
+	for _, diagnosis := range diagnoses {
+		switch {
+		case IsOverwateringDiagnosis(diagnosis):
+			signals.Overwatering++
+		case containsAny(diagnosis.Diagnosis, underwateringPhrases):
+			signals.Underwatering++
+		}
+	}
+
+	return signals, nil
+}
+
+// IsOverwateringDiagnosis reports whether a diagnosis points to too much water, such as root rot
+func IsOverwateringDiagnosis(diagnosis models.PlantDiagnosis) bool {
+	for _, symptom := range diagnosis.Symptoms {
+		for _, s := range overwateringSymptoms {
+			if symptom == s {
+				return true
+			}
+		}
+	}
+	return containsAny(diagnosis.Diagnosis, overwateringPhrases)
+}
+
+func containsAny(text string, phrases []string) bool {
+	for _, phrase := range phrases {
+		if strings.Contains(text, phrase) {
+			return true
+		}
+	}
+	return false
+}
+
+// Accept switches the reminder to an adaptive "interval" schedule of the given days.
+// Counting starts from today at the reminder's time of day.
+func (s *WateringService) Accept(ctx context.Context, reminder models.Reminder, intervalDays int, now time.Time) (*models.Reminder, error) {
+	timeOfDay := reminder.TimeOfDay
+	if timeOfDay == "" {
+		timeOfDay = reminder.ScheduledTime.In(now.Location()).Format("15:04")
+	}
+	clock, err := time.Parse("15:04", timeOfDay)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time of day %q: %v", timeOfDay, err)
+	}
+	anchor := time.Date(now.Year(), now.Month(), now.Day(), clock.Hour(), clock.Minute(), 0, 0, now.Location())
+
+	var updated models.Reminder
+	err = s.db.Collection("reminders").FindOneAndUpdate(ctx,
+		bson.M{"_id": reminder.ID, "user_id": reminder.UserID},
+		bson.M{
+			"$set": bson.M{
+				"frequency":      "interval",
+				"interval_days":  clampWateringInterval(intervalDays),
+				"adaptive":       true,
+				"time_of_day":    timeOfDay,
+				"scheduled_time": anchor,
+				"is_active":      true,
+				"updated_at":     now,
+			},
+			"$unset": bson.M{"day_of_week": ""},
+			"$inc":   bson.M{"version": 1},
+		},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&updated)
+	if err != nil {
+		return nil, fmt.Errorf("error updating reminder: %v", err)
+	}
+	return &updated, nil
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseCatalogWatering(t *testing.T) {
+	tests := []struct {
+		water  string
+		want   int
+		wantOK bool
+	}{
+		// The wordings used in the plant catalog
+		{"ปานกลาง (รดน้ำ 2-3 ครั้ง/สัปดาห์)", 3, true},
+		{"สูง (รดน้ำทุกวัน)", 1, true},
+		{"ต่ำ (รดน้ำ 1-2 ครั้ง/สัปดาห์)", 5, true},
+		{"ปานกลาง", 3, true},
+		// Other counts per week
+		{"รดน้ำ 3 ครั้ง / สัปดาห์", 2, true},
+		{"รดน้ำ 1 ครั้ง/สัปดาห์", 7, true},
+		{"รดน้ำ 14 ครั้ง/สัปดาห์", 1, true},
+		// Levels only
+		{"สูง", 1, true},
+		{"ต่ำ", 5, true},
+		// Nothing to go on
+		{"", 0, false},
+		{"รดน้ำ 0 ครั้ง/สัปดาห์", 0, false},
+		{"when dry", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseCatalogWatering(tt.water)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("ParseCatalogWatering(%q) = %d, %v; want %d, %v", tt.water, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestAdjustWateringInterval(t *testing.T) {
+	tests := []struct {
+		name        string
+		base        int
+		signals     WateringSignals
+		want        int
+		wantReasons []string
+	}{
+		{"no history", 3, WateringSignals{}, 3, nil},
+		{"overwatering doubles", 3, WateringSignals{Overwatering: 1}, 6, []string{WateringReasonOverwateringDiagnosis}},
+		{"overwatering adds at least two days", 1, WateringSignals{Overwatering: 1}, 3, []string{WateringReasonOverwateringDiagnosis}},
+		{"overwatering is capped", 10, WateringSignals{Overwatering: 2}, MaxWateringInterval, []string{WateringReasonOverwateringDiagnosis}},
+		{"underwatering halves", 4, WateringSignals{Underwatering: 1}, 2, []string{WateringReasonUnderwateringDiagnosis}},
+		{"underwatering keeps at least a day", 1, WateringSignals{Underwatering: 1}, MinWateringInterval, []string{WateringReasonUnderwateringDiagnosis}},
+		{"overwatering wins over underwatering", 3, WateringSignals{Overwatering: 1, Underwatering: 1}, 6, []string{WateringReasonOverwateringDiagnosis}},
+		{"often skipped", 3, WateringSignals{Acknowledged: 2, Skipped: 2}, 4, []string{WateringReasonOftenSkipped}},
+		{"often missed", 3, WateringSignals{Acknowledged: 2, Missed: 2}, 4, []string{WateringReasonOftenMissed}},
+		{"missed after overwatering is not counted twice", 3, WateringSignals{Acknowledged: 1, Missed: 3, Overwatering: 1}, 6, []string{WateringReasonOverwateringDiagnosis}},
+		{"too few occurrences to tell", 3, WateringSignals{Skipped: 3}, 3, nil},
+		{"mostly acknowledged", 3, WateringSignals{Acknowledged: 8, Skipped: 1, Missed: 1}, 3, nil},
+		// Poor moods mean too much water on a frequent schedule and too little on a sparse one
+		{"poor moods on a frequent schedule lengthen it", 2, WateringSignals{PoorMoods: 2}, 3, []string{WateringReasonPoorMood}},
+		{"poor moods on daily watering lengthen it", 1, WateringSignals{PoorMoods: 1, GoodMoods: 1}, 2, []string{WateringReasonPoorMood}},
+		{"poor moods on a sparse schedule shorten it", 5, WateringSignals{PoorMoods: 2, GoodMoods: 1}, 4, []string{WateringReasonPoorMood}},
+		{"mostly good moods", 5, WateringSignals{PoorMoods: 1, GoodMoods: 3}, 5, nil},
+		{"a single poor mood", 5, WateringSignals{PoorMoods: 1}, 5, nil},
+		{"poor moods are explained by a diagnosis", 4, WateringSignals{PoorMoods: 3, Underwatering: 1}, 2, []string{WateringReasonUnderwateringDiagnosis}},
+	}
+	for _, tt := range tests {
+		got, reasons := AdjustWateringInterval(tt.base, tt.signals)
+		if got != tt.want || !reflect.DeepEqual(reasons, tt.wantReasons) {
+			t.Errorf("%s: AdjustWateringInterval(%d, %+v) = %d, %v; want %d, %v", tt.name, tt.base, tt.signals, got, reasons, tt.want, tt.wantReasons)
+		}
+	}
+}
+
+func TestNeutralMoodsAreNeitherGoodNorPoor(t *testing.T) {
+	for mood, want := range map[string]int{"happy": 1, "ดี": 1, "neutral": 0, "ปกติ": 0, "sad": -1, "ไม่ดี": -1, "": 0} {
+		if got := moodScores[mood]; got != want {
+			t.Errorf("moodScores[%q] = %d, want %d", mood, got, want)
+		}
+	}
+}
@@ -250,7 +250,7 @@ const page = () => {
               <div className="h-40 w-full shadow-sm rounded-md overflow-hidden mb-2 flex justify-center items-center">
                 {plant.image_url && (
                   <img
-                    src={plant.image_url}
+                    src={plant.image_urls?.thumbnail || plant.image_url}
                     alt={plant.name}
                     className="w-full h-40 object-cover rounded-md"
                     onError={(e) => {
@@ -1,5 +1,6 @@
 'use client'
-import React, { useState } from 'react';
+import React, { useEffect, useState } from 'react';
+import { useSearchParams } from 'next/navigation';
 import { TriangleAlert, Thermometer } from 'lucide-react';
 import {
   Select,
@@ -23,6 +24,20 @@ const TreeDiagnosisForm = () => {
   const [materials, setMaterials] = useState([]);
   const [fertilizers, setFertilizers] = useState([]);
 
+  // Growth alerts link here with the problem part and symptoms filled in,
+  // e.g. /diagnosis?problemPart=ใบ&symptoms=ใบเหี่ยว,ใบเหลือง
+  const searchParams = useSearchParams();
+  useEffect(() => {
+    const part = searchParams.get('problemPart');
+    if (part) {
+      setProblemPart(part);
+    }
+    const linkedSymptoms = searchParams.get('symptoms');
+    if (linkedSymptoms) {
+      setSymptoms(linkedSymptoms.split(',').filter(Boolean));
+    }
+  }, [searchParams]);
+
   const symptomOptions = [
     { id: 'yellow', label: 'ใบเหลือง' },
     { id: 'withered', label: 'ใบเหี่ยว' },
@@ -113,7 +128,7 @@ const TreeDiagnosisForm = () => {
 
             <div className='mb-6'>
                 <p className='font-semibold mb-2'>ส่วนที่มีปัญหา</p>
-                <Select onValueChange={setProblemPart}>
+                <Select value={problemPart} onValueChange={setProblemPart}>
                 <SelectTrigger className="w-full rounded-xl border-black">
                     <SelectValue placeholder="เลือกส่วนที่มีปัญหา" />
                 </SelectTrigger>
@@ -135,6 +150,7 @@ const TreeDiagnosisForm = () => {
                     <input
                         type="checkbox"
                         value={symptom.label}
+                        checked={symptoms.includes(symptom.label)}
                         onChange={(e) => handleCheckboxChange(e, setSymptoms, symptoms)}
                         className='mr-2'
                     />